@@ -0,0 +1,43 @@
+package pl
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultAdmissionRecheckInterval is how long a Step denied by WorkflowAdmissionControl waits
+// before being checked again, if no other Step terminates in between.
+var DefaultAdmissionRecheckInterval = 100 * time.Millisecond
+
+// WorkflowAdmissionControl sets the callback consulted right before starting each ready Step,
+// e.g. to delay Steps when the system memory or CPU is high, as backpressure beyond WorkflowMaxConcurrency.
+//
+// If admit returns false, the Step stays Pending in the ready queue, and is checked again
+// once another Step terminates, or after DefaultAdmissionRecheckInterval.
+// Denied Steps don't block the Steps behind them in the queue.
+//
+// admit is called in the Run goroutine, it should not block.
+// Nothing bounds how long a Step can be denied, a callback always returning false starves the Step,
+// use StartDeadline to detect or fail such Steps.
+func WorkflowAdmissionControl(admit func(ctx context.Context, step StepReader) bool) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("admission control", summarizeSet(admit != nil))
+		s.admit = admit
+	}
+}
+
+// admitted consults the admission control, a Step is admitted without it.
+// A denied Step schedules a recheck.
+func (s *Workflow) admitted(ctx context.Context, step StepDoer) bool {
+	if s.admit == nil || s.admit(ctx, step) {
+		return true
+	}
+	if recheck := s.admitRecheck; recheck.CompareAndSwap(false, true) {
+		wake := s.wake
+		s.clock().AfterFunc(DefaultAdmissionRecheckInterval, func() {
+			recheck.Store(false)
+			wakeUp(wake)
+		})
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package pl_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+	"github.com/xuxife/pl/pltest"
+)
+
+func TestWorkflowAdmissionControl(t *testing.T) {
+	clock := pltest.NewClock(time.Unix(0, 0))
+	var admitted atomic.Bool
+	gated, free := succeed("gated"), succeed("free")
+	w := new(pl.Workflow).
+		WithOptions(
+			pl.WorkflowClock(clock),
+			pl.WorkflowAdmissionControl(func(_ context.Context, step pl.StepReader) bool {
+				return step != gated || admitted.Load()
+			}),
+		).
+		Add(pl.Steps(gated, free))
+
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	clock.BlockUntilTimers(1) // the recheck of gated is scheduled
+	if got := gated.GetStatus(); got != pl.StepStatusPending {
+		t.Errorf("want gated Pending while denied, got %s", got)
+	}
+
+	admitted.Store(true)
+	clock.Advance(pl.DefaultAdmissionRecheckInterval)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := gated.GetStatus(); got != pl.StepStatusSucceeded {
+		t.Errorf("want gated Succeeded once admitted, got %s", got)
+	}
+	if got := free.GetStatus(); got != pl.StepStatusSucceeded {
+		t.Errorf("want free not blocked by gated, got %s", got)
+	}
+}
@@ -0,0 +1,73 @@
+package pl
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrStepNotInWorkflow is returned by AwaitStep if the awaited Step is not in the other Workflow.
+var ErrStepNotInWorkflow = fmt.Errorf("Step is not in the Workflow")
+
+// ErrAwaitedStep is returned by AwaitStep if the awaited Step terminated but not Succeeded.
+type ErrAwaitedStep struct {
+	Step   StepReader
+	Status StepStatus
+}
+
+func (e ErrAwaitedStep) Error() string {
+	return fmt.Sprintf("awaited Step %s is %s", e.Step, e.Status)
+}
+
+// AwaitStep constructs a Step waiting for a Step in another Workflow to terminate,
+// e.g. Workflows in the same process sharing a cache warmed by one of them.
+//
+// It succeeds if the awaited Step Succeeded, and fails with ErrAwaitedStep otherwise.
+// It keeps waiting if the other Workflow hasn't started yet, until its context is done,
+// see Timeout to bound the wait.
+// It fails with ErrStepNotInWorkflow at once if the other Workflow doesn't contain the Step.
+//
+// A Step already terminated in the last run of the other Workflow is not waited again,
+// Reset the other Workflow to wait for its next run.
+func AwaitStep(other *Workflow, step StepDoer) StepDoer {
+	return FuncNoInOut(fmt.Sprintf("Await(%s)", step), func(ctx context.Context) error {
+		if _, ok := other.deps[step]; !ok {
+			return fmt.Errorf("%w: %s", ErrStepNotInWorkflow, step)
+		}
+		for {
+			// subscribe before checking, not to miss the termination in between
+			terminated := other.waitForTerminated(ctx)
+			switch status := step.GetStatus(); status {
+			case StepStatusSucceeded:
+				return nil
+			case StepStatusFailed, StepStatusCanceled, StepStatusSkipped:
+				return ErrAwaitedStep{Step: step, Status: status}
+			}
+			select {
+			case <-terminated:
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			}
+		}
+	})
+}
+
+// waitForTerminated returns a channel closed when the next Step of the Workflow terminates,
+// it's in the form of RetryOption.WaitFor.
+func (s *Workflow) waitForTerminated(context.Context) <-chan struct{} {
+	s.terminatedMu.Lock()
+	defer s.terminatedMu.Unlock()
+	if s.terminated == nil {
+		s.terminated = make(chan struct{})
+	}
+	return s.terminated
+}
+
+// notifyTerminated wakes up the waiters of waitForTerminated.
+func (s *Workflow) notifyTerminated() {
+	s.terminatedMu.Lock()
+	defer s.terminatedMu.Unlock()
+	if s.terminated != nil {
+		close(s.terminated)
+		s.terminated = nil
+	}
+}
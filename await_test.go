@@ -0,0 +1,66 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+)
+
+func TestAwaitStep(t *testing.T) {
+	t.Run("awaiting Workflow starts first", func(t *testing.T) {
+		warm := succeed("warm")
+		a := new(pl.Workflow).Add(pl.Step(warm))
+		await, use := pl.AwaitStep(a, warm), succeed("use")
+		b := new(pl.Workflow).Add(pl.Step(use).ExtraDependsOn(await))
+
+		done := make(chan error)
+		go func() { done <- b.Run(context.Background()) }()
+		for deadline := time.Now().Add(time.Second); await.GetStatus() != pl.StepStatusRunning; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("want await Running, got %s", await.GetStatus())
+			}
+		}
+		if got := use.GetStatus(); got != pl.StepStatusPending {
+			t.Errorf("want use Pending before warm terminated, got %s", got)
+		}
+		if err := a.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("awaited Workflow terminated first", func(t *testing.T) {
+		warm := succeed("warm")
+		a := new(pl.Workflow).Add(pl.Step(warm))
+		if err := a.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		b := new(pl.Workflow).Add(pl.Steps(pl.AwaitStep(a, warm)))
+		if err := b.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("awaited Step failed", func(t *testing.T) {
+		warm := fail("warm")
+		a := new(pl.Workflow).Add(pl.Step(warm))
+		b := new(pl.Workflow).Add(pl.Steps(pl.AwaitStep(a, warm)))
+		done := make(chan error)
+		go func() { done <- b.Run(context.Background()) }()
+		_ = a.Run(context.Background())
+		var awaited pl.ErrAwaitedStep
+		if err := <-done; !errors.As(err, &awaited) || awaited.Status != pl.StepStatusFailed {
+			t.Errorf("want ErrAwaitedStep with Failed, got %v", err)
+		}
+	})
+	t.Run("Step not in Workflow", func(t *testing.T) {
+		a := new(pl.Workflow).Add(pl.Step(succeed("other")))
+		b := new(pl.Workflow).Add(pl.Steps(pl.AwaitStep(a, succeed("warm"))))
+		if err := b.Run(context.Background()); !errors.Is(err, pl.ErrStepNotInWorkflow) {
+			t.Errorf("want ErrStepNotInWorkflow, got %v", err)
+		}
+	})
+}
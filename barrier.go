@@ -0,0 +1,60 @@
+package pl
+
+import "fmt"
+
+// WorkflowBarrier sets the barrier Steps, e.g. to model a manual approval gate in a CD pipeline:
+// the Dependers of barrier Steps are held Pending after the barrier Steps terminated,
+// until Continue is called, then the rest of the Workflow resumes.
+//
+// Steps not depending on barrier Steps are not held.
+// Cancel stops the held Steps as other Pending Steps.
+func WorkflowBarrier(steps ...StepDoer) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("barrier", fmt.Sprint(steps))
+		s.barrier = make(map[StepDoer]bool, len(steps))
+		for _, step := range steps {
+			s.barrier[step] = true
+		}
+	}
+}
+
+// Continue resumes the Steps held by the barrier, see WorkflowBarrier.
+//
+// Continue is safe to call from another goroutine.
+// If called before the barrier is reached, the barrier will not hold, until Reset.
+func (s *Workflow) Continue() {
+	s.continued.Store(true)
+	s.stopMu.Lock()
+	defer s.stopMu.Unlock()
+	wakeUp(s.wake)
+}
+
+// Paused returns whether some Steps are held by the barrier, waiting for Continue.
+func (s *Workflow) Paused() bool {
+	return s.paused.Load()
+}
+
+// heldByBarrier returns whether a Step should be held, i.e. it depends on a barrier Step.
+func (s *Workflow) heldByBarrier(step StepDoer) bool {
+	if len(s.barrier) == 0 || s.continued.Load() {
+		return false
+	}
+	for _, e := range s.deps.UpstreamOf(step) {
+		if s.barrier[e] {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseBarrier pushes the held Steps back to the frontier once continued.
+func (s *Workflow) releaseBarrier() {
+	if len(s.frontier.held) == 0 || !s.continued.Load() {
+		return
+	}
+	for _, step := range s.frontier.held {
+		s.frontier.push(step)
+	}
+	s.frontier.held = nil
+	s.paused.Store(false)
+}
@@ -0,0 +1,47 @@
+package pl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+)
+
+func TestWorkflowBarrier(t *testing.T) {
+	build, deploy, lint := succeed("build"), succeed("deploy"), succeed("lint")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowBarrier(build)).
+		Add(
+			pl.Step(deploy).ExtraDependsOn(build),
+			pl.Step(lint),
+		)
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+
+	deadline := time.After(time.Second)
+	for !w.Paused() {
+		select {
+		case <-deadline:
+			t.Fatal("want Workflow paused at the barrier")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if got := build.GetStatus(); got != pl.StepStatusSucceeded {
+		t.Errorf("want barrier Step terminated, got %s", got)
+	}
+	if got := deploy.GetStatus(); got != pl.StepStatusPending {
+		t.Errorf("want deploy held Pending, got %s", got)
+	}
+
+	w.Continue()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := deploy.GetStatus(); got != pl.StepStatusSucceeded {
+		t.Errorf("want deploy resumed, got %s", got)
+	}
+	if w.Paused() {
+		t.Error("want not paused after Continue")
+	}
+}
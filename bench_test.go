@@ -0,0 +1,106 @@
+package pl_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+// BenchmarkWorkflowRun runs 20k Steps in 100 parallel chains of 200 Steps.
+func BenchmarkWorkflowRun(b *testing.B) {
+	const chains, length = 100, 200
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		w := new(pl.Workflow)
+		for c := 0; c < chains; c++ {
+			var prev pl.StepDoer
+			for l := 0; l < length; l++ {
+				step := succeed(fmt.Sprintf("step-%d-%d", c, l))
+				if prev != nil {
+					w.Add(pl.Step(step).ExtraDependsOn(prev))
+				} else {
+					w.Add(pl.Step(step))
+				}
+				prev = step
+			}
+		}
+		b.StartTimer()
+		if err := w.Run(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDownstreamOf queries the Dependers of every Step in a wide DAG,
+// 50 roots each fanning out to 40 Steps.
+func BenchmarkDownstreamOf(b *testing.B) {
+	const roots, width = 50, 40
+	w := new(pl.Workflow)
+	var steps []pl.StepDoer
+	for r := 0; r < roots; r++ {
+		root := succeed(fmt.Sprintf("root-%d", r))
+		steps = append(steps, root)
+		for i := 0; i < width; i++ {
+			step := succeed(fmt.Sprintf("step-%d-%d", r, i))
+			steps = append(steps, step)
+			w.Add(pl.Step(step).ExtraDependsOn(root))
+		}
+	}
+	b.Run("dependency", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			d := w.Dep()
+			for _, step := range steps {
+				_ = d.DownstreamOf(step)
+			}
+		}
+	})
+	b.Run("Workflow", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			w.Add() // invalidates the index
+			for _, step := range steps {
+				_ = w.DownstreamOf(step)
+			}
+		}
+	})
+}
+
+// BenchmarkWorkflowRunSetup runs 5k Steps in 50 parallel chains of 100 Steps,
+// with and without the compiled Plan.
+func BenchmarkWorkflowRunSetup(b *testing.B) {
+	const chains, length = 50, 100
+	w := new(pl.Workflow)
+	for c := 0; c < chains; c++ {
+		var prev pl.StepDoer
+		for l := 0; l < length; l++ {
+			step := succeed(fmt.Sprintf("step-%d-%d", c, l))
+			if prev != nil {
+				w.Add(pl.Step(step).ExtraDependsOn(prev))
+			} else {
+				w.Add(pl.Step(step))
+			}
+			prev = step
+		}
+	}
+	run := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := w.Reset(); err != nil {
+				b.Fatal(err)
+			}
+			if err := w.Run(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.Run("raw", func(b *testing.B) {
+		w.Add() // invalidates the Plan
+		run(b)
+	})
+	b.Run("compiled", func(b *testing.B) {
+		if _, err := w.Compile(); err != nil {
+			b.Fatal(err)
+		}
+		run(b)
+	})
+}
@@ -0,0 +1,91 @@
+package pl
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time for Workflow scheduling, see WorkflowClock.
+//
+// Replace it in tests to control time without sleeping.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine after duration d.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is returned by Clock.AfterFunc, *time.Timer implements it.
+type Timer interface {
+	// Stop prevents the Timer from firing,
+	// returns false if the Timer has already fired or been stopped.
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+// WorkflowClock sets the Clock of the Workflow, the default Clock is the wall clock.
+//
+// The Clock drives WorkflowTimeout, Step Timeout, the backoff between retry attempts (unless RetryOption.Timer is set),
+// StartDeadline, the recheck of WorkflowAdmissionControl and WorkflowRootStagger, see pltest.Clock for tests.
+func WorkflowClock(c Clock) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("clock", summarizeSet(c != nil))
+		s.clk = c
+	}
+}
+
+// clock returns the Clock of the Workflow.
+func (s *Workflow) clock() Clock {
+	if s.clk == nil {
+		return realClock{}
+	}
+	return s.clk
+}
+
+// withTimeout is context.WithTimeoutCause driven by the Clock of the Workflow.
+//
+// With a Clock other than the wall clock, the context is canceled with cause once the Clock passes the timeout,
+// so ctx.Err() is context.Canceled, check context.Cause(ctx) instead.
+func (s *Workflow) withTimeout(ctx context.Context, d time.Duration, cause error) (context.Context, context.CancelFunc) {
+	if s.clk == nil {
+		return context.WithTimeoutCause(ctx, d, cause)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	t := s.clk.AfterFunc(d, func() { cancel(cause) })
+	return ctx, func() {
+		t.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// clockTimer is a backoff.Timer driven by Clock.
+type clockTimer struct {
+	clock Clock
+	timer Timer
+	c     chan time.Time
+}
+
+func (t *clockTimer) Start(d time.Duration) {
+	if t.c == nil {
+		t.c = make(chan time.Time, 1)
+	}
+	t.Stop()
+	t.timer = t.clock.AfterFunc(d, func() {
+		select {
+		case t.c <- t.clock.Now():
+		default:
+		}
+	})
+}
+
+func (t *clockTimer) Stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+func (t *clockTimer) C() <-chan time.Time { return t.c }
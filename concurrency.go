@@ -0,0 +1,41 @@
+package pl
+
+import (
+	"slices"
+	"strconv"
+)
+
+// WorkflowConcurrencyGroup limits the Steps in the named group to run at most n at a time,
+// e.g. the Steps calling a rate limited API, see Step ConcurrencyGroup for tagging Steps.
+//
+// A Step holds a slot of every group it belongs to, plus a slot of WorkflowMaxConcurrency if set.
+// Ready Steps of a full group wait in the order of being added, without blocking the Steps of other groups.
+// Like WorkflowMaxConcurrency, the slots are released while sleeping between retry attempts.
+//
+// Steps tagged with a group not set by this option are not limited by it.
+func WorkflowConcurrencyGroup(name string, n int) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("concurrency group "+name, strconv.Itoa(n))
+		if s.groupBuckets == nil {
+			s.groupBuckets = make(map[string]chan struct{})
+		}
+		s.groupBuckets[name] = make(chan struct{}, n)
+	}
+}
+
+// leaseOf creates the lease of a Step, with the buckets in a fixed order to avoid deadlock:
+// the bucket of WorkflowMaxConcurrency first, then the ones of its groups sorted by name.
+func (s *Workflow) leaseOf(step StepDoer) *lease {
+	l := &lease{}
+	if s.leaseBucket != nil {
+		l.buckets = append(l.buckets, s.leaseBucket)
+	}
+	groups := slices.Clone(step.getConcurrencyGroups())
+	slices.Sort(groups)
+	for _, name := range slices.Compact(groups) {
+		if bucket, ok := s.groupBuckets[name]; ok {
+			l.buckets = append(l.buckets, bucket)
+		}
+	}
+	return l
+}
@@ -0,0 +1,93 @@
+package pl_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+)
+
+// gauge tracks the max number of Steps running at the same time per group.
+type gauge struct {
+	mu      sync.Mutex
+	running map[string]int
+	max     map[string]int
+}
+
+func (g *gauge) step(name string, groups ...string) pl.Steper[struct{}, struct{}] {
+	return pl.FuncNoInOut(name, func(context.Context) error {
+		g.mu.Lock()
+		for _, group := range groups {
+			g.running[group]++
+			g.max[group] = max(g.max[group], g.running[group])
+		}
+		g.mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		g.mu.Lock()
+		for _, group := range groups {
+			g.running[group]--
+		}
+		g.mu.Unlock()
+		return nil
+	})
+}
+
+func TestWorkflowConcurrencyGroup(t *testing.T) {
+	g := &gauge{running: map[string]int{}, max: map[string]int{}}
+	w := new(pl.Workflow).WithOptions(
+		pl.WorkflowConcurrencyGroup("api", 2),
+		pl.WorkflowConcurrencyGroup("db", 1),
+	)
+	for i := 0; i < 6; i++ {
+		w.Add(
+			pl.Step(g.step(fmt.Sprintf("api-%d", i), "api")).ConcurrencyGroup("api"),
+			pl.Step(g.step(fmt.Sprintf("db-%d", i), "db")).ConcurrencyGroup("db"),
+			// Steps in both groups acquire the slots in the same order, no deadlock
+			pl.Step(g.step(fmt.Sprintf("both-%d", i), "api", "db")).ConcurrencyGroup("db", "api"),
+			pl.Step(g.step(fmt.Sprintf("free-%d", i), "free")),
+		)
+	}
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for group, want := range map[string]int{"api": 2, "db": 1} {
+		if got := g.max[group]; got != want {
+			t.Errorf("want at most %d %s Steps running, got %d", want, group, got)
+		}
+	}
+	if g.max["free"] < 2 {
+		t.Errorf("want Steps without group not limited, got at most %d running", g.max["free"])
+	}
+}
+
+func TestWorkflowConcurrencyGroupDoesNotBlockOthers(t *testing.T) {
+	started := make(chan struct{})
+	// first holds the only slot of "api" until free starts,
+	// free is added behind second, which waits for the slot
+	first := pl.FuncNoInOut("first", func(ctx context.Context) error {
+		select {
+		case <-started:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	second := succeed("second")
+	free := pl.FuncNoInOut("free", func(context.Context) error {
+		close(started)
+		return nil
+	})
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowConcurrencyGroup("api", 1), pl.WorkflowTimeout(time.Second)).
+		Add(
+			pl.Step(first).ConcurrencyGroup("api"),
+			pl.Step(second).ConcurrencyGroup("api"),
+			pl.Step(free),
+		)
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
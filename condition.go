@@ -2,7 +2,10 @@ package pl
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"reflect"
 )
 
 // StepStatus describes the status of a Step.
@@ -49,8 +52,40 @@ type StepReader interface {
 // Condition is only called when all Dependees are terminated.
 type Condition func(dependees []StepReader) bool
 
+// DefaultCondition is the initial default Condition of every Workflow.
+//
+// Deprecated: mutating DefaultCondition affects all Workflows in the process
+// and races with running Workflows, use WorkflowDefaultCondition instead.
 var DefaultCondition Condition = Succeeded
 
+// conditionPasses evaluates the Condition of a Step over its Dependees,
+// a Dependee with its own Condition (see ConditionFor) is evaluated alone by that one instead.
+func (s *Workflow) conditionPasses(step StepDoer, dependees []StepReader, cond Condition) bool {
+	var overridden map[StepReader]bool
+	for _, l := range s.deps[step] {
+		if l.Cond == nil || l.Dependee == nil || overridden[l.Dependee] {
+			continue
+		}
+		if overridden == nil {
+			overridden = make(map[StepReader]bool)
+		}
+		overridden[l.Dependee] = true
+		if !l.Cond([]StepReader{l.Dependee}) {
+			return false
+		}
+	}
+	if overridden == nil {
+		return cond(dependees)
+	}
+	var rest []StepReader
+	for _, e := range dependees {
+		if !overridden[e] {
+			rest = append(rest, e)
+		}
+	}
+	return cond(rest)
+}
+
 // Always: as long as all Dependees are terminated
 func Always(deps []StepReader) bool {
 	return true
@@ -69,6 +104,57 @@ func Succeeded(dependees []StepReader) bool {
 	return true
 }
 
+// SucceededStrict: all Dependees are Succeeded, Skipped counts as failure
+//
+// The treatment of each Dependee status by the Succeeded variants:
+//
+//	Dependee Status | Succeeded | SucceededStrict | SucceededStrictOn(strict...)
+//	Succeeded       | pass      | pass            | pass
+//	Skipped         | pass      | cancel          | cancel if in strict, otherwise pass
+//	Failed          | cancel    | cancel          | cancel
+//	Canceled        | cancel    | cancel          | cancel
+func SucceededStrict(dependees []StepReader) bool {
+	for _, e := range dependees {
+		if e.GetStatus() != StepStatusSucceeded {
+			return false
+		}
+	}
+	return true
+}
+
+// AllSucceededNoneSkipped: all Dependees are Succeeded, and none of them is Skipped
+//
+// It's the default Condition under WorkflowSkipPropagation,
+// where a Step rejected only because of Skipped Dependees is Skipped instead of Canceled.
+func AllSucceededNoneSkipped(dependees []StepReader) bool {
+	return SucceededStrict(dependees)
+}
+
+// SucceededStrictOn: all Dependees are Succeeded,
+// Skipped counts as failure for the strict Dependees (e.g. the data Dependees whose Output is needed),
+// and as success for others (e.g. the ordering only Dependees).
+func SucceededStrictOn(strict ...StepReader) Condition {
+	isStrict := make(map[StepReader]bool, len(strict))
+	for _, s := range strict {
+		isStrict[s] = true
+	}
+	return func(dependees []StepReader) bool {
+		for _, e := range dependees {
+			switch e.GetStatus() {
+			case StepStatusSucceeded:
+				// do nothing
+			case StepStatusSkipped:
+				if isStrict[e] {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+		return true
+	}
+}
+
 // Failed: at least one Dependee is Failed
 func Failed(dependees []StepReader) bool {
 	hasFailed := false
@@ -103,15 +189,194 @@ func Never(deps []StepReader) bool {
 	return false
 }
 
+// AnySucceeded: at least one Dependee is Succeeded (Skipped is not counted),
+// e.g. for a fan-in Step running as long as one Dependee produced its Output.
+func AnySucceeded(deps []StepReader) bool {
+	return countStatus(deps, StepStatusSucceeded) > 0
+}
+
+// AtLeastNSucceeded: at least n Dependees are Succeeded (Skipped is not counted)
+func AtLeastNSucceeded(n int) Condition {
+	return func(deps []StepReader) bool {
+		return countStatus(deps, StepStatusSucceeded) >= n
+	}
+}
+
+// AtLeastNFailed: at least n Dependees are Failed (Skipped is not counted)
+func AtLeastNFailed(n int) Condition {
+	return func(deps []StepReader) bool {
+		return countStatus(deps, StepStatusFailed) >= n
+	}
+}
+
+func countStatus(deps []StepReader, status StepStatus) int {
+	count := 0
+	for _, dep := range deps {
+		if dep.GetStatus() == status {
+			count++
+		}
+	}
+	return count
+}
+
+// StepResult is a terminated Dependee with the error recorded for it in the Workflow,
+// nil for Succeeded, Skipped, and Canceled by Condition.
+type StepResult struct {
+	StepReader
+	Err error
+}
+
+// ResultsOf returns the results of the Dependees, e.g. in a Condition.
+// The error is only available for Steps embedding StepBase.
+func ResultsOf(dependees []StepReader) []StepResult {
+	results := make([]StepResult, len(dependees))
+	for i, e := range dependees {
+		results[i].StepReader = e
+		if b, ok := e.(interface{ getErr() error }); ok {
+			results[i].Err = b.getErr()
+		}
+	}
+	return results
+}
+
+// ConditionFunc is a Condition deciding on the results of Dependees, including their errors.
+type ConditionFunc func(dependees []StepResult) bool
+
+// Condition adapts ConditionFunc to Condition, so it can be used anywhere a Condition is.
+func (fn ConditionFunc) Condition() Condition {
+	return func(dependees []StepReader) bool {
+		return fn(ResultsOf(dependees))
+	}
+}
+
+// FailedWith: like Failed, at least one Dependee is Failed with an error matching target (errors.Is),
+// and no Dependee is Canceled.
+func FailedWith(target error) Condition {
+	return ConditionFunc(func(results []StepResult) bool {
+		hasFailed := false
+		for _, r := range results {
+			switch r.GetStatus() {
+			case StepStatusFailed:
+				hasFailed = hasFailed || errors.Is(r.Err, target)
+			case StepStatusCanceled:
+				return false
+			}
+		}
+		return hasFailed
+	}).Condition()
+}
+
+// SucceededOrFailedWith: all Dependees are Succeeded (or Skipped),
+// or Failed with an error accepted by pred, e.g. to tolerate ErrAlreadyExists.
+func SucceededOrFailedWith(pred func(error) bool) Condition {
+	return ConditionFunc(func(results []StepResult) bool {
+		for _, r := range results {
+			switch r.GetStatus() {
+			case StepStatusSucceeded, StepStatusSkipped:
+				// do nothing
+			case StepStatusFailed:
+				if !pred(r.Err) {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+		return true
+	}).Condition()
+}
+
+// And: all the Conditions are true, returns false on the first false Condition.
+// And() without Condition is true.
+func And(conds ...Condition) Condition {
+	return func(deps []StepReader) bool {
+		for _, cond := range conds {
+			if !cond(deps) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or: at least one Condition is true, returns true on the first true Condition.
+// Or() without Condition is false.
+func Or(conds ...Condition) Condition {
+	return func(deps []StepReader) bool {
+		for _, cond := range conds {
+			if cond(deps) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not: the Condition is false
+func Not(cond Condition) Condition {
+	return func(deps []StepReader) bool {
+		return !cond(deps)
+	}
+}
+
 // When is a function to determine whether the Step should be Skipped.
 // When makes the decesion according to the context and environment, so it's an arbitrary function.
-// When is called after Condition.
+// When is called after Condition, the context carries the Step being decided, see WhenStep.
 type When func(context.Context) bool
 
+// DefaultWhenFunc is the initial default When of every Workflow.
+//
+// Deprecated: mutating DefaultWhenFunc affects all Workflows in the process
+// and races with running Workflows, use WorkflowDefaultWhen instead.
 var DefaultWhenFunc = When(func(context.Context) bool {
 	return true
 })
 
+// WhenWorkflow adapts a function also receiving the running Workflow to When,
+// e.g. to decide by the statuses of other Steps.
+//
+//	// skip notify if any Step failed so far
+//	Step(notify).When(WhenWorkflow(func(ctx context.Context, w *Workflow) bool {
+//		return w.Err() == nil
+//	}))
+//
+// The Workflow is nil if the context is not from a running Workflow.
+func WhenWorkflow(when func(context.Context, *Workflow) bool) When {
+	return func(ctx context.Context) bool {
+		return when(ctx, workflowFromContext(ctx))
+	}
+}
+
+// WhenStep is a When also receiving the Step being decided,
+// e.g. a predicate shared by Steps to skip the optional ones.
+//
+//	skipOptional := WhenStep(func(ctx context.Context, step StepReader) bool {
+//		return !(*flagSkipOptional && strings.HasPrefix(step.String(), "optional-"))
+//	}).When()
+//	w.Add(Steps(a, b, c).When(skipOptional))
+type WhenStep func(ctx context.Context, step StepReader) bool
+
+// When adapts WhenStep to When, the Step is nil if the context is not from a running Workflow.
+func (w WhenStep) When() When {
+	return func(ctx context.Context) bool {
+		return w(ctx, stepFromContext(ctx))
+	}
+}
+
+// WhenEnv: the Step runs only if the environment variable name equals value
+func WhenEnv(name, value string) When {
+	return func(context.Context) bool {
+		return os.Getenv(name) == value
+	}
+}
+
+// WhenCtxValue: the Step runs only if the context value of key equals want, see context.WithValue
+func WhenCtxValue(key, want any) When {
+	return func(ctx context.Context) bool {
+		return reflect.DeepEqual(ctx.Value(key), want)
+	}
+}
+
 // Skip: this step will always be Skipped
 func Skip(context.Context) bool {
 	return false
@@ -0,0 +1,226 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+type fakeStep struct {
+	name   string
+	status pl.StepStatus
+}
+
+func (f fakeStep) String() string           { return f.name }
+func (f fakeStep) GetStatus() pl.StepStatus { return f.status }
+
+func deps(statuses ...pl.StepStatus) []pl.StepReader {
+	var readers []pl.StepReader
+	for _, status := range statuses {
+		readers = append(readers, fakeStep{name: status.String(), status: status})
+	}
+	return readers
+}
+
+func TestCondition(t *testing.T) {
+	var (
+		succeededData  = fakeStep{"data", pl.StepStatusSucceeded}
+		skippedData    = fakeStep{"data", pl.StepStatusSkipped}
+		succeededOrder = fakeStep{"order", pl.StepStatusSucceeded}
+		skippedOrder   = fakeStep{"order", pl.StepStatusSkipped}
+	)
+	for _, c := range []struct {
+		name string
+		cond pl.Condition
+		deps []pl.StepReader
+		want bool
+	}{
+		{"Always nil", pl.Always, nil, true},
+		{"Always failed", pl.Always, deps(pl.StepStatusFailed, pl.StepStatusCanceled), true},
+		{"Succeeded nil", pl.Succeeded, nil, true},
+		{"Succeeded with skipped", pl.Succeeded, deps(pl.StepStatusSucceeded, pl.StepStatusSkipped), true},
+		{"Succeeded with failed", pl.Succeeded, deps(pl.StepStatusSucceeded, pl.StepStatusFailed), false},
+		{"Succeeded with canceled", pl.Succeeded, deps(pl.StepStatusCanceled), false},
+		{"SucceededStrict nil", pl.SucceededStrict, nil, true},
+		{"SucceededStrict all succeeded", pl.SucceededStrict, deps(pl.StepStatusSucceeded, pl.StepStatusSucceeded), true},
+		{"SucceededStrict with skipped", pl.SucceededStrict, deps(pl.StepStatusSucceeded, pl.StepStatusSkipped), false},
+		{"SucceededStrict with failed", pl.SucceededStrict, deps(pl.StepStatusFailed), false},
+		{"AllSucceededNoneSkipped with skipped", pl.AllSucceededNoneSkipped, deps(pl.StepStatusSucceeded, pl.StepStatusSkipped), false},
+		{"AllSucceededNoneSkipped all succeeded", pl.AllSucceededNoneSkipped, deps(pl.StepStatusSucceeded), true},
+		{"SucceededStrictOn nil", pl.SucceededStrictOn(), nil, true},
+		{"SucceededStrictOn strict skipped", pl.SucceededStrictOn(skippedData), []pl.StepReader{skippedData, succeededOrder}, false},
+		{"SucceededStrictOn non-strict skipped", pl.SucceededStrictOn(succeededData), []pl.StepReader{succeededData, skippedOrder}, true},
+		{"SucceededStrictOn with failed", pl.SucceededStrictOn(), deps(pl.StepStatusFailed), false},
+		{"Failed nil", pl.Failed, nil, false},
+		{"Failed with failed", pl.Failed, deps(pl.StepStatusSucceeded, pl.StepStatusFailed), true},
+		{"Failed with canceled", pl.Failed, deps(pl.StepStatusFailed, pl.StepStatusCanceled), false},
+		{"SucceededOrFailed nil", pl.SucceededOrFailed, nil, true},
+		{"SucceededOrFailed mixed", pl.SucceededOrFailed, deps(pl.StepStatusSucceeded, pl.StepStatusFailed, pl.StepStatusSkipped), true},
+		{"SucceededOrFailed with canceled", pl.SucceededOrFailed, deps(pl.StepStatusCanceled), false},
+		{"Never nil", pl.Never, nil, false},
+		{"AnySucceeded nil", pl.AnySucceeded, nil, false},
+		{"AnySucceeded one succeeded", pl.AnySucceeded, deps(pl.StepStatusFailed, pl.StepStatusCanceled, pl.StepStatusSucceeded), true},
+		{"AnySucceeded skipped not counted", pl.AnySucceeded, deps(pl.StepStatusSkipped, pl.StepStatusFailed), false},
+		{"Not AnySucceeded", pl.Not(pl.AnySucceeded), deps(pl.StepStatusSkipped, pl.StepStatusCanceled), true},
+		{"AnySucceeded or Failed", pl.Or(pl.AnySucceeded, pl.Failed), deps(pl.StepStatusSkipped, pl.StepStatusFailed), true},
+		{"AnySucceeded and none canceled", pl.And(pl.AnySucceeded, pl.SucceededOrFailed), deps(pl.StepStatusSucceeded, pl.StepStatusFailed), true},
+		{"AtLeastNSucceeded nil", pl.AtLeastNSucceeded(1), nil, false},
+		{"AtLeastNSucceeded zero", pl.AtLeastNSucceeded(0), nil, true},
+		{"AtLeastNSucceeded quorum", pl.AtLeastNSucceeded(3), deps(pl.StepStatusSucceeded, pl.StepStatusSucceeded, pl.StepStatusFailed, pl.StepStatusSucceeded, pl.StepStatusCanceled), true},
+		{"AtLeastNSucceeded skipped not counted", pl.AtLeastNSucceeded(2), deps(pl.StepStatusSucceeded, pl.StepStatusSkipped), false},
+		{"AtLeastNSucceeded n over count", pl.AtLeastNSucceeded(3), deps(pl.StepStatusSucceeded, pl.StepStatusSucceeded), false},
+		{"AtLeastNFailed nil", pl.AtLeastNFailed(1), nil, false},
+		{"AtLeastNFailed enough", pl.AtLeastNFailed(2), deps(pl.StepStatusFailed, pl.StepStatusSucceeded, pl.StepStatusFailed), true},
+		{"AtLeastNFailed skipped not counted", pl.AtLeastNFailed(2), deps(pl.StepStatusFailed, pl.StepStatusSkipped), false},
+		{"AtLeastNFailed n over count", pl.AtLeastNFailed(2), deps(pl.StepStatusFailed), false},
+		{"And empty", pl.And(), nil, true},
+		{"And all true", pl.And(pl.Always, pl.Succeeded), deps(pl.StepStatusSucceeded), true},
+		{"And one false", pl.And(pl.Always, pl.Failed), deps(pl.StepStatusSucceeded), false},
+		{"Or empty", pl.Or(), nil, false},
+		{"Or one true", pl.Or(pl.Never, pl.Failed), deps(pl.StepStatusFailed), true},
+		{"Or all false", pl.Or(pl.Never, pl.Failed), deps(pl.StepStatusSucceeded), false},
+		{"Not true", pl.Not(pl.Always), nil, false},
+		{"Not false", pl.Not(pl.Never), nil, true},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.cond(c.deps); got != c.want {
+				t.Errorf("want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestConditionShortCircuit(t *testing.T) {
+	called := false
+	spy := func([]pl.StepReader) bool {
+		called = true
+		return true
+	}
+	pl.And(pl.Never, spy)(nil)
+	pl.Or(pl.Always, spy)(nil)
+	if called {
+		t.Error("want And stop on the first false and Or stop on the first true")
+	}
+}
+
+func TestWhenWorkflow(t *testing.T) {
+	failed, notify := fail("failed"), succeed("notify")
+	var seen *pl.Workflow
+	w := new(pl.Workflow)
+	w.WithOptions(pl.WorkflowWhen(pl.WhenWorkflow(func(_ context.Context, w *pl.Workflow) bool {
+		seen = w
+		return true
+	}))).Add(
+		pl.Step(notify).
+			ExtraDependsOn(failed).
+			Condition(pl.Always).
+			When(pl.WhenWorkflow(func(_ context.Context, w *pl.Workflow) bool {
+				return w.Err() == nil
+			})),
+	)
+	_ = w.Run(context.Background())
+	if seen != w {
+		t.Errorf("want Workflow When receive the Workflow, got %v", seen)
+	}
+	if got := notify.GetStatus(); got != pl.StepStatusSkipped {
+		t.Errorf("want notify Skipped as failed has error, got %s", got)
+	}
+	if got := pl.WhenWorkflow(func(_ context.Context, w *pl.Workflow) bool { return w == nil })(context.Background()); !got {
+		t.Error("want nil Workflow outside a running Workflow")
+	}
+}
+
+func TestConditionWithErrors(t *testing.T) {
+	errAlreadyExists := errors.New("already exists")
+	exists := pl.FuncNoInOut("exists", func(context.Context) error { return errAlreadyExists })
+	broken := fail("broken")
+	tolerant := pl.SucceededOrFailedWith(func(err error) bool { return errors.Is(err, errAlreadyExists) })
+	afterExists, afterBroken := succeed("afterExists"), succeed("afterBroken")
+	onExists, onBroken := succeed("onExists"), succeed("onBroken")
+	w := new(pl.Workflow).Add(
+		pl.Step(afterExists).ExtraDependsOn(exists).Condition(tolerant),
+		pl.Step(afterBroken).ExtraDependsOn(broken).Condition(tolerant),
+		pl.Step(onExists).ExtraDependsOn(exists).Condition(pl.FailedWith(errAlreadyExists)),
+		pl.Step(onBroken).ExtraDependsOn(broken).Condition(pl.FailedWith(errAlreadyExists)),
+	)
+	_ = w.Run(context.Background())
+	for step, want := range map[pl.StepReader]pl.StepStatus{
+		afterExists: pl.StepStatusSucceeded,
+		afterBroken: pl.StepStatusCanceled,
+		onExists:    pl.StepStatusSucceeded,
+		onBroken:    pl.StepStatusCanceled,
+	} {
+		if got := step.GetStatus(); got != want {
+			t.Errorf("want %s %s, got %s", step, want, got)
+		}
+	}
+	results := pl.ResultsOf([]pl.StepReader{exists, afterExists})
+	if !errors.Is(results[0].Err, errAlreadyExists) || results[1].Err != nil {
+		t.Errorf("want results with the recorded errors, got %v", results)
+	}
+}
+
+func TestConditionFor(t *testing.T) {
+	a, logging, broken := succeed("a"), fail("logging"), fail("broken")
+	b, c, d := succeed("b"), succeed("c"), succeed("d")
+	w := new(pl.Workflow).Add(
+		pl.Step(b).ExtraDependsOn(a, logging).ConditionFor(logging, pl.Always),
+		pl.Step(c).ExtraDependsOn(a, logging),
+		pl.Step(d).ExtraDependsOn(broken).ConditionFor(logging, pl.Always),
+	)
+	_ = w.Run(context.Background())
+	for step, want := range map[pl.StepReader]pl.StepStatus{
+		b: pl.StepStatusSucceeded, // logging failed is tolerated
+		c: pl.StepStatusCanceled,  // the default Condition over all Dependees
+		d: pl.StepStatusCanceled,  // other Dependees are still under Condition
+	} {
+		if got := step.GetStatus(); got != want {
+			t.Errorf("want %s %s, got %s", step, want, got)
+		}
+	}
+	if got := w.Dep().UpstreamOf(d); len(got) != 2 {
+		t.Errorf("want ConditionFor declare the dependency, got Dependees %v", got)
+	}
+}
+
+func TestWhenStep(t *testing.T) {
+	type flagKey struct{}
+	t.Setenv("PL_TEST_STAGE", "prod")
+	optionalA, optionalB, required := succeed("optional-a"), succeed("optional-b"), succeed("required")
+	prod, dev, flagged := succeed("prod"), succeed("dev"), succeed("flagged")
+	var decided []string
+	skipOptional := pl.WhenStep(func(_ context.Context, step pl.StepReader) bool {
+		decided = append(decided, step.String())
+		return !strings.HasPrefix(step.String(), "optional-")
+	}).When()
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowMaxConcurrency(1)).
+		Add(
+			pl.Steps(optionalA, optionalB, required).When(skipOptional),
+			pl.Step(prod).When(pl.WhenEnv("PL_TEST_STAGE", "prod")),
+			pl.Step(dev).When(pl.WhenEnv("PL_TEST_STAGE", "dev")),
+			pl.Step(flagged).When(pl.WhenCtxValue(flagKey{}, true)),
+		)
+	if err := w.Run(context.WithValue(context.Background(), flagKey{}, true)); err != nil {
+		t.Fatal(err)
+	}
+	for step, want := range map[pl.StepReader]pl.StepStatus{
+		optionalA: pl.StepStatusSkipped,
+		optionalB: pl.StepStatusSkipped,
+		required:  pl.StepStatusSucceeded,
+		prod:      pl.StepStatusSucceeded,
+		dev:       pl.StepStatusSkipped,
+		flagged:   pl.StepStatusSucceeded,
+	} {
+		if got := step.GetStatus(); got != want {
+			t.Errorf("want %s %s, got %s", step, want, got)
+		}
+	}
+	if want := []string{"optional-a", "optional-b", "required"}; !reflect.DeepEqual(decided, want) {
+		t.Errorf("want WhenStep receive %v, got %v", want, decided)
+	}
+}
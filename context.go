@@ -0,0 +1,37 @@
+package pl
+
+import "context"
+
+type workflowKey struct{}
+
+// workflowFromContext returns the Workflow running the Step,
+// the context passed to Step's Do and Input functions carries it.
+//
+// It returns nil if the context is not from a running Workflow.
+func workflowFromContext(ctx context.Context) *Workflow {
+	w, _ := ctx.Value(workflowKey{}).(*Workflow)
+	return w
+}
+
+type stepKey struct{}
+
+// stepFromContext returns the Step being decided, the context passed to When carries it, see WhenStep.
+//
+// It returns nil if the context is not from a running Workflow.
+func stepFromContext(ctx context.Context) StepReader {
+	step, _ := ctx.Value(stepKey{}).(StepReader)
+	return step
+}
+
+type attemptKey struct{}
+
+// AttemptFromContext returns which attempt of Do the Step is on, starting from 1,
+// the context passed to Step's Do and Input functions carries it,
+// e.g. to log "attempt 3/10", or to make idempotency decisions.
+//
+// Steps without Retry are always on attempt 1.
+// It returns 0 if the context is not from a running Workflow.
+func AttemptFromContext(ctx context.Context) uint64 {
+	attempt, _ := ctx.Value(attemptKey{}).(uint64)
+	return attempt
+}
@@ -0,0 +1,37 @@
+package pl
+
+// DeadLetter is a Step failed permanently in the last run, i.e. after all retry attempts,
+// with its Input at the time it failed, e.g. to be reprocessed later.
+type DeadLetter struct {
+	Name  string // the name of the Step, from String()
+	Step  StepReader
+	Err   error
+	Input any // a copy of the Step's Input, nil if the Step has no Input method
+}
+
+// DeadLetters returns the Steps failed permanently in the last run, in the order of failing.
+//
+// Steps Canceled or Skipped are not dead letters, since they never ran.
+// DeadLetters is safe to call while the Workflow is running.
+func (s *Workflow) DeadLetters() []DeadLetter {
+	s.errsMu.RLock()
+	defer s.errsMu.RUnlock()
+	return append([]DeadLetter(nil), s.deadLetters...)
+}
+
+// recordDeadLetter records a Step failed with err after all attempts.
+// Caller should hold errsMu.
+func (s *Workflow) recordDeadLetter(step StepDoer, err error) {
+	letter := DeadLetter{Name: step.String(), Step: step, Err: err}
+	letter.Input, _ = inputOf(step)
+	s.deadLetters = append(s.deadLetters, letter)
+}
+
+// inputOf returns a copy of the Input of a Step via its `Input() *I` method.
+func inputOf(step StepReader) (any, bool) {
+	in, ok := inputPtrOf(step)
+	if !ok {
+		return nil, false
+	}
+	return in.Elem().Interface(), true
+}
@@ -0,0 +1,39 @@
+package pl_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/xuxife/pl"
+)
+
+func TestDeadLetters(t *testing.T) {
+	produce := pl.FuncOut("produce", func(context.Context) (func(*int), error) {
+		return func(o *int) { *o = 42 }, nil
+	})
+	consume := pl.FuncIn("consume", func(context.Context, int) error {
+		return fmt.Errorf("poison")
+	})
+	canceled := succeed("canceled")
+	w := new(pl.Workflow).Add(
+		pl.Step(consume).
+			DirectDependsOn(produce).
+			Retry(pl.RetryOption{Backoff: &backoff.ZeroBackOff{}, Attempts: 2}),
+		pl.Step(canceled).ExtraDependsOn(consume),
+	)
+	_ = w.Run(context.Background())
+
+	letters := w.DeadLetters()
+	if len(letters) != 1 {
+		t.Fatalf("want only the failed Step as dead letter, got %v", letters)
+	}
+	letter := letters[0]
+	if letter.Name != "consume" || letter.Step != consume || letter.Err == nil {
+		t.Errorf("want consume with its error, got %+v", letter)
+	}
+	if letter.Input != 42 {
+		t.Errorf("want the Input 42, got %v", letter.Input)
+	}
+}
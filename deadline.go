@@ -0,0 +1,87 @@
+package pl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrStartDeadlineExceeded is the error of a Step failed by its StartDeadline.
+type ErrStartDeadlineExceeded struct {
+	Deadline time.Duration
+}
+
+func (e ErrStartDeadlineExceeded) Error() string {
+	return fmt.Sprintf("Step is not started within %s after being ready", e.Deadline)
+}
+
+// startDeadline is set via addStep.StartDeadline.
+type startDeadline struct {
+	d    time.Duration
+	fail bool
+}
+
+// WorkflowStartDeadlineEscalation sets the callback when a Step exceeds its StartDeadline,
+// waited is the StartDeadline of the Step.
+//
+// The callback is called in its own goroutine, it should not block.
+func WorkflowStartDeadlineEscalation(fn func(ctx context.Context, step StepReader, waited time.Duration)) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("start deadline escalation", summarizeSet(fn != nil))
+		s.onStartDeadline = fn
+	}
+}
+
+// readyStep is a Step ready to start (all Dependees terminated, Condition and When passed),
+// waiting in the ready queue for a lease.
+type readyStep struct {
+	step     StepDoer
+	timer    Timer // fires when StartDeadline exceeded, nil without StartDeadline
+	mu       sync.Mutex
+	started  bool // whether the Step has left the ready queue
+	exceeded bool // whether the StartDeadline has been exceeded
+}
+
+// leave marks the Step left the ready queue, returns whether its StartDeadline has been exceeded.
+func (r *readyStep) leave() (exceeded bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.started = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	return r.exceeded
+}
+
+func (r *readyStep) isExceeded() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.exceeded
+}
+
+// watchStartDeadline starts the timer of StartDeadline for a ready Step.
+func (s *Workflow) watchStartDeadline(ctx context.Context, r *readyStep) {
+	sd := r.step.getStartDeadline()
+	if sd.d <= 0 {
+		return
+	}
+	wake := s.wake
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timer = s.clock().AfterFunc(sd.d, func() {
+		r.mu.Lock()
+		if r.started {
+			r.mu.Unlock()
+			return
+		}
+		r.exceeded = true
+		r.mu.Unlock()
+		if s.onStartDeadline != nil {
+			s.onStartDeadline(ctx, r.step, sd.d)
+		}
+		if sd.fail {
+			wakeUp(wake)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+	"github.com/xuxife/pl/pltest"
+)
+
+func TestStartDeadline(t *testing.T) {
+	clock := pltest.NewClock(time.Unix(0, 0))
+	release := make(chan struct{})
+	blocker := pl.FuncNoInOut("blocker", func(context.Context) error {
+		<-release
+		return nil
+	})
+	escalated, failing := succeed("escalated"), succeed("failing")
+	escalations := make(chan pl.StepReader, 2)
+	failed := make(chan struct{})
+	w := new(pl.Workflow).
+		WithOptions(
+			pl.WorkflowMaxConcurrency(1), // saturated by blocker
+			pl.WorkflowClock(clock),
+			pl.WorkflowStartDeadlineEscalation(func(_ context.Context, step pl.StepReader, waited time.Duration) {
+				if waited != time.Minute {
+					t.Errorf("want waited 1m, got %s", waited)
+				}
+				escalations <- step
+			}),
+			pl.WorkflowStepHooks(nil, func(_ context.Context, step pl.StepReader, _ error) {
+				if step == failing {
+					close(failed)
+				}
+			}),
+		).
+		Add(
+			pl.Step(blocker),
+			pl.Step(escalated).StartDeadline(time.Minute, false),
+			pl.Step(failing).StartDeadline(time.Minute, true),
+		)
+
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	clock.BlockUntilTimers(2)
+	clock.Advance(time.Minute)
+	got := map[pl.StepReader]bool{<-escalations: true, <-escalations: true}
+	if !got[escalated] || !got[failing] {
+		t.Errorf("want both waiting Steps escalated, got %v", got)
+	}
+	<-failed // fails while blocker still holds the lease
+	close(release)
+	err := <-done
+
+	var derr pl.ErrStartDeadlineExceeded
+	if !errors.As(err, &derr) || failing.GetStatus() != pl.StepStatusFailed {
+		t.Errorf("want failing Step Failed with ErrStartDeadlineExceeded, got %s: %v", failing.GetStatus(), err)
+	}
+	if got := escalated.GetStatus(); got != pl.StepStatusSucceeded {
+		t.Errorf("want escalated Step still run, got %s", got)
+	}
+	if got := blocker.GetStatus(); got != pl.StepStatusSucceeded {
+		t.Errorf("want blocker Succeeded, got %s", got)
+	}
+}
@@ -0,0 +1,100 @@
+package pl
+
+import (
+	"context"
+	"reflect"
+)
+
+// WorkflowDeepCopyFlow deep-copies the Outputs flowing to the Inputs of Dependers,
+// via DirectDependsOn and Adapt(s), so a Depender mutating its Input can't corrupt
+// the Output of its Dependee, or the Input of other Dependers sharing the same Output.
+//
+// The copy is made by reflection on each flow: pointers, slices, maps, arrays,
+// interfaces and exported struct fields are copied recursively, cycles are kept as cycles.
+// Channels, functions and unexported struct fields are still shared.
+//
+// The cost is proportional to the size of the Outputs, an allocation for each
+// pointer, slice and map, so prefer immutable Outputs for large data on hot paths.
+func WorkflowDeepCopyFlow() WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("deep copy flow", "true")
+		s.deepCopyFlow = true
+	}
+}
+
+// flowed returns the value to flow to a Depender, deep-copied if WorkflowDeepCopyFlow.
+func flowed[T any](ctx context.Context, v T) T {
+	if w := workflowFromContext(ctx); w != nil && w.deepCopyFlow {
+		return deepCopy(v)
+	}
+	return v
+}
+
+// deepCopy returns a deep copy of v, see WorkflowDeepCopyFlow.
+func deepCopy[T any](v T) T {
+	src := reflect.ValueOf(&v).Elem()
+	dst := reflect.New(src.Type()).Elem()
+	copyValue(dst, src, make(map[uintptr]reflect.Value))
+	return *dst.Addr().Interface().(*T)
+}
+
+// copyValue deep-copies src into dst, seen maps the copied pointers to their copies.
+func copyValue(dst, src reflect.Value, seen map[uintptr]reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		if p, ok := seen[src.Pointer()]; ok {
+			dst.Set(p)
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		seen[src.Pointer()] = p
+		copyValue(p.Elem(), src.Elem(), seen)
+		dst.Set(p)
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
+		for i := 0; i < src.Len(); i++ {
+			copyValue(s.Index(i), src.Index(i), seen)
+		}
+		dst.Set(s)
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(src.Type().Key()).Elem()
+			copyValue(k, iter.Key(), seen)
+			v := reflect.New(src.Type().Elem()).Elem()
+			copyValue(v, iter.Value(), seen)
+			m.SetMapIndex(k, v)
+		}
+		dst.Set(m)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			copyValue(dst.Index(i), src.Index(i), seen)
+		}
+	case reflect.Struct:
+		dst.Set(src) // unexported fields are shared
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				copyValue(dst.Field(i), src.Field(i), seen)
+			}
+		}
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		v := reflect.New(src.Elem().Type()).Elem()
+		copyValue(v, src.Elem(), seen)
+		dst.Set(v)
+	default:
+		dst.Set(src)
+	}
+}
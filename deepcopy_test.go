@@ -0,0 +1,87 @@
+package pl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+// produce is a Step keeping its Output, so the Dependers can share it.
+type produce[O any] struct {
+	pl.StepBaseInOut[struct{}, O]
+	make func() O
+}
+
+func (p *produce[O]) String() string { return "produce" }
+
+func (p *produce[O]) Do(context.Context) error {
+	p.Out = p.make()
+	return nil
+}
+
+func TestWorkflowDeepCopyFlow(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		opts   []pl.WorkflowOption
+		intact bool
+	}{
+		{"shared", nil, false},
+		{"deep copied", []pl.WorkflowOption{pl.WorkflowDeepCopyFlow()}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			up := &produce[[]int]{make: func() []int { return []int{1, 2, 3} }}
+			down := pl.FuncIn("down", func(_ context.Context, in []int) error {
+				in[0] = 100
+				return nil
+			})
+			w := new(pl.Workflow).
+				WithOptions(tc.opts...).
+				Add(pl.Step(down).DirectDependsOn(up))
+			if err := w.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got := up.Out[0]; (got == 1) != tc.intact {
+				t.Errorf("want Output of up intact %v, got %d", tc.intact, got)
+			}
+		})
+	}
+}
+
+// node is a cyclic Output with maps and interfaces.
+type node struct {
+	Name  string
+	Next  *node
+	Attrs map[string]any
+}
+
+func TestWorkflowDeepCopyFlowAdapt(t *testing.T) {
+	up := &produce[*node]{make: func() *node {
+		n := &node{Name: "a", Attrs: map[string]any{"tags": []string{"x"}}}
+		n.Next = n
+		return n
+	}}
+	var got node
+	down := pl.FuncIn("down", func(_ context.Context, in node) error {
+		got = in
+		in.Attrs["tags"].([]string)[0] = "y"
+		in.Next.Name = "b"
+		return nil
+	})
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowDeepCopyFlow()).
+		Add(pl.Step(down).DependsOn(pl.Adapt(up, func(_ context.Context, o *node, i *node) error {
+			*i = *o
+			return nil
+		})))
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	o := up.Out
+	if o.Attrs["tags"].([]string)[0] != "x" || o.Next.Name != "a" {
+		t.Errorf("want Output of up intact, got %+v", o)
+	}
+	if got.Next.Next != got.Next {
+		t.Error("want the cycle kept in the copy")
+	}
+}
@@ -0,0 +1,57 @@
+package pl
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// dotStyles are the node attributes of each StepStatus in DOT.
+var dotStyles = map[StepStatus]string{
+	StepStatusRunning:   `style=filled, fillcolor=lightblue`,
+	StepStatusSucceeded: `style=filled, fillcolor=green`,
+	StepStatusFailed:    `style=filled, fillcolor=red`,
+	StepStatusCanceled:  `style=filled, fillcolor=gray`,
+	StepStatusSkipped:   `style=dashed`,
+}
+
+// DOT returns the Workflow as a Graphviz DOT digraph,
+// each Step is a node labeled with String(), each dependency is an edge `Dependee -> Depender`.
+//
+// Edges with data flow (DependsOn, DirectDependsOn) are solid,
+// edges without data flow (ExtraDependsOn) are dashed.
+// Nodes are styled by their current StepStatus,
+// so DOT can be called before, during and after Run.
+func (s *Workflow) DOT() string {
+	builder := new(strings.Builder)
+	_ = s.WriteDOT(builder) // strings.Builder never returns error
+	return builder.String()
+}
+
+// WriteDOT writes the Workflow as a Graphviz DOT digraph into w, see DOT.
+func (s *Workflow) WriteDOT(w io.Writer) error {
+	steps := s.sortedSteps()
+	ids := make(map[StepDoer]string, len(steps))
+	for i, step := range steps {
+		ids[step] = fmt.Sprintf("step%d", i)
+	}
+	lines := []string{"digraph Workflow {"}
+	for _, step := range steps {
+		attrs := "label=" + strconv.Quote(step.String())
+		if style, ok := dotStyles[step.GetStatus()]; ok {
+			attrs += ", " + style
+		}
+		lines = append(lines, fmt.Sprintf("\t%s [%s];", ids[step], attrs))
+	}
+	for _, e := range s.deps.edges(steps) {
+		attrs := ""
+		if !e.Data {
+			attrs = " [style=dashed]"
+		}
+		lines = append(lines, fmt.Sprintf("\t%s -> %s%s;", ids[e.Dependee], ids[e.Depender], attrs))
+	}
+	lines = append(lines, "}\n")
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
@@ -0,0 +1,40 @@
+package pl_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestDOT(t *testing.T) {
+	a := pl.FuncOut("a", func(context.Context) (func(*struct{}), error) { return nil, nil })
+	b, c := pl.FuncIn("b", func(context.Context, struct{}) error { return nil }), fail("c")
+	w := new(pl.Workflow).Add(
+		pl.Step(b).DirectDependsOn(a),
+		pl.Step(c).ExtraDependsOn(b),
+	)
+	dot := w.DOT()
+	for _, want := range []string{
+		"digraph Workflow {",
+		`step0 [label="a"];`,
+		"step0 -> step1;",
+		"step1 -> step2 [style=dashed];",
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("want %q in:\n%s", want, dot)
+		}
+	}
+
+	_ = w.Run(context.Background())
+	dot = w.DOT()
+	for _, want := range []string{
+		`step0 [label="a", style=filled, fillcolor=green];`,
+		`step2 [label="c", style=filled, fillcolor=red];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("want %q in:\n%s", want, dot)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package pl
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sort"
 	"strings"
 )
 
@@ -12,27 +16,90 @@ import (
 //	})
 type ErrFlow struct {
 	Err  error
-	From StepReader
+	From StepReader // nil if the error is from Input function
 }
 
 func (e *ErrFlow) Error() string {
+	if e.From == nil { // from Input function
+		return fmt.Sprintf("ErrFlow(From Input): %s", e.Err.Error())
+	}
 	return fmt.Sprintf("ErrFlow(From %s [%s]): %s", e.From, e.From.GetStatus(), e.Err.Error())
 }
 
+func (e *ErrFlow) Unwrap() error {
+	return e.Err
+}
+
+// ErrPanic is the error converted from a panic in Step, e.g. in Do, Input or hooks.
+// Stack is the stack trace captured where the panic is recovered, it includes the panicking line.
+type ErrPanic struct {
+	Value any // the value passed to panic
+	Stack []byte
+}
+
+func (e *ErrPanic) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it's an error.
+func (e *ErrPanic) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// errWithStep is the error of a Step wrapped with its name, see WorkflowWrapErrors.
+type errWithStep struct {
+	step StepReader
+	err  error
+}
+
+func (e *errWithStep) Error() string { return fmt.Sprintf("%s: %s", e.step, e.err) }
+func (e *errWithStep) Unwrap() error { return e.err }
+
 // ErrWorkflow contains all errors of Steps in a Workflow.
 type ErrWorkflow map[StepReader]error
 
 func (e ErrWorkflow) Error() string {
 	builder := new(strings.Builder)
-	for reporter, err := range e {
+	for _, reporter := range e.failedSteps() {
+		builder.WriteString(fmt.Sprintf(
+			"%s [%s]: %s\n",
+			reporter.String(), reporter.GetStatus().String(), e[reporter].Error(),
+		))
+	}
+	return builder.String()
+}
+
+// failedSteps returns Steps with non-nil error, sorted by name.
+func (e ErrWorkflow) failedSteps() []StepReader {
+	steps := make([]StepReader, 0, len(e))
+	for step, err := range e {
 		if err != nil {
-			builder.WriteString(fmt.Sprintf(
-				"%s [%s]: %s\n",
-				reporter.String(), reporter.GetStatus().String(), err.Error(),
-			))
+			steps = append(steps, step)
 		}
 	}
-	return builder.String()
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].String() < steps[j].String()
+	})
+	return steps
+}
+
+// Unwrap returns all non-nil errors of Steps, sorted by Step name,
+// so errors.Is and errors.As work through ErrWorkflow.
+func (e ErrWorkflow) Unwrap() []error {
+	steps := e.failedSteps()
+	errs := make([]error, 0, len(steps))
+	for _, step := range steps {
+		errs = append(errs, e[step])
+	}
+	return errs
+}
+
+// Of returns the error of a Step, nil if the Step succeeded or has not run.
+func (e ErrWorkflow) Of(step StepReader) error {
+	return e[step]
 }
 
 func (e ErrWorkflow) IsNil() bool {
@@ -44,16 +111,135 @@ func (e ErrWorkflow) IsNil() bool {
 	return true
 }
 
+// FailurePaths returns, for each Failed Step, the path from a root Step (without Dependee) to it.
+// Steps Canceled with an error (e.g. WorkflowFailFast, WorkflowTimeout, Cancel) never ran, they are excluded.
+//
+// The Workflow provides the dependency graph,
+// when a Step has multiple Dependees, the first declared one is followed.
+// Paths are sorted by the name of the failed Step.
+func (e ErrWorkflow) FailurePaths(s *Workflow) [][]StepReader {
+	var failed []StepDoer
+	for step, err := range e {
+		if err == nil || step.GetStatus() != StepStatusFailed {
+			continue
+		}
+		if doer, ok := step.(StepDoer); ok {
+			failed = append(failed, doer)
+		}
+	}
+	sort.SliceStable(failed, func(i, j int) bool {
+		return failed[i].String() < failed[j].String()
+	})
+	var paths [][]StepReader
+	for _, step := range failed {
+		path := []StepReader{step}
+		visited := map[StepDoer]bool{step: true}
+		for cur := step; ; {
+			ups := s.deps.UpstreamOf(cur)
+			if len(ups) == 0 || visited[ups[0]] {
+				break
+			}
+			cur = ups[0]
+			visited[cur] = true
+			path = append(path, cur)
+		}
+		// reverse to root -> failed Step
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// ByType groups the Failed Steps by the concrete type of their errors,
+// Steps Canceled with an error never ran, they are excluded.
+//
+// The wrappers added by Workflow (ErrFlow, ErrPhase, ErrResourceAcquire, ErrJournal, and WorkflowWrapErrors) are unwrapped,
+// so Steps are grouped by the type of the underlying error.
+// Steps in each group are sorted by name.
+func (e ErrWorkflow) ByType() map[reflect.Type][]StepReader {
+	groups := make(map[reflect.Type][]StepReader)
+	for step, err := range e {
+		if err == nil || step.GetStatus() != StepStatusFailed {
+			continue
+		}
+		t := reflect.TypeOf(canonicalError(err))
+		groups[t] = append(groups[t], step)
+	}
+	for _, steps := range groups {
+		sort.SliceStable(steps, func(i, j int) bool {
+			return steps[i].String() < steps[j].String()
+		})
+	}
+	return groups
+}
+
+// canonicalError unwraps the errors wrapped by Workflow.
+func canonicalError(err error) error {
+	for {
+		switch e := err.(type) {
+		case *ErrFlow:
+			err = e.Err
+		case *ErrPhase:
+			err = e.Err
+		case *ErrResourceAcquire:
+			err = e.Err
+		case *ErrJournal:
+			err = e.Err
+		case *errWithStep:
+			err = e.err
+		default:
+			return err
+		}
+	}
+}
+
 var ErrWorkflowIsRunning = fmt.Errorf("Workflow is running, please wait for it terminated")
+var ErrWorkflowCanceled = fmt.Errorf("Workflow is canceled via Cancel()")
 var ErrWorkflowHasRun = fmt.Errorf("Workflow has run, check result error via Err(), or reset the Workflow via Reset()")
 
+// ErrWorkflowTimeout is recorded for the Steps Canceled because WorkflowTimeout exceeded,
+// it wraps context.DeadlineExceeded.
+var ErrWorkflowTimeout = fmt.Errorf("Workflow timeout: %w", context.DeadlineExceeded)
+
+// ErrFailedBeforeResume is recorded for the Steps loaded as Failed by Workflow.LoadStatuses.
+var ErrFailedBeforeResume = fmt.Errorf("Step Failed before resumed")
+
+// ErrNilStep is reported in ErrValidation when nil Steps are added into Workflow.
+var ErrNilStep = fmt.Errorf("nil Step added into Workflow")
+
+// ErrValidation contains all the problems found in the Workflow before running, see Workflow.Validate,
+// e.g. ErrUnexpectStepInitStatus, ErrCycleDependency, ErrNilStep and ErrPolicyBound.
+//
+// Use errors.As or errors.Is to check the specific problems.
+type ErrValidation []error
+
+func (e ErrValidation) Error() string {
+	builder := new(strings.Builder)
+	builder.WriteString("Workflow validation failed:")
+	for _, err := range e {
+		builder.WriteString("\n- ")
+		builder.WriteString(strings.ReplaceAll(err.Error(), "\n", "\n  "))
+	}
+	return builder.String()
+}
+
+func (e ErrValidation) Unwrap() []error {
+	return e
+}
+
 // Only when the Step status is not StepStautsPending when Workflow starts to run.
 type ErrUnexpectStepInitStatus []StepReader
 
 func (e ErrUnexpectStepInitStatus) Error() string {
 	builder := new(strings.Builder)
 	builder.WriteString("Unexpect Step initial status:")
-	for _, j := range e {
+	steps := append([]StepReader(nil), e...)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].String() < steps[j].String()
+	})
+	for _, j := range steps {
 		builder.WriteRune('\n')
 		builder.WriteString(fmt.Sprintf(
 			"%s [%s]",
@@ -63,27 +249,92 @@ func (e ErrUnexpectStepInitStatus) Error() string {
 	return builder.String()
 }
 
+// ErrCycleEdge is returned by Workflow.SafeAdd when the edge from Dependee to Depender forms a cycle.
+type ErrCycleEdge struct {
+	Dependee StepReader
+	Depender StepReader
+}
+
+func (e ErrCycleEdge) Error() string {
+	return fmt.Sprintf("Step %s depending on %s forms a cycle", e.Depender, e.Dependee)
+}
+
 // There is a cycle-dependency in your Workflow!!!
+//
+// It maps each Step unable to run (in a cycle or downstream of a cycle)
+// to its Dependees unable to run, use Cycles for the cycles as chains.
 type ErrCycleDependency map[StepReader][]StepReader
 
 func (e ErrCycleDependency) Error() string {
 	builder := new(strings.Builder)
 	builder.WriteString("Cycle Dependency Error:")
-	for j, deps := range e {
-		depsStr := []string{}
-		for _, dep := range deps {
-			depsStr = append(depsStr, dep.String())
+	for _, cycle := range e.Cycles() {
+		chain := make([]string, 0, len(cycle))
+		for _, step := range cycle {
+			chain = append(chain, step.String())
 		}
 		builder.WriteRune('\n')
-		builder.WriteString(fmt.Sprintf(
-			"%s: [%s]",
-			j, strings.Join(depsStr, ", "),
-		))
+		builder.WriteString(strings.Join(chain, " -> "))
 	}
 	return builder.String()
 }
 
-// catchPanicAsError catches panic from f and return it as error.
+// Cycles walks each cycle as a chain from Dependee to Depender,
+// the first and the last Step of a chain are the same.
+//
+// A Step unable to run always has a Dependee unable to run,
+// so walking upstream from it must end up in a cycle.
+// Each chain starts from the Step with the smallest name, and chains are sorted by their first Step.
+func (e ErrCycleDependency) Cycles() [][]StepReader {
+	steps := make([]StepReader, 0, len(e))
+	for step := range e {
+		steps = append(steps, step)
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].String() < steps[j].String()
+	})
+	var cycles [][]StepReader
+	found := map[StepReader]bool{}
+	for _, step := range steps {
+		if found[step] {
+			continue
+		}
+		var path []StepReader
+		index := map[StepReader]int{}
+		for cur := step; ; {
+			if found[cur] { // reached a known cycle
+				break
+			}
+			if i, ok := index[cur]; ok { // walked back, path[i:] forms a new cycle
+				cycle := make([]StepReader, 0, len(path)-i+1)
+				for j := len(path) - 1; j >= i; j-- { // reverse to Dependee -> Depender
+					cycle = append(cycle, path[j])
+					found[path[j]] = true
+				}
+				min := 0
+				for j := range cycle {
+					if cycle[j].String() < cycle[min].String() {
+						min = j
+					}
+				}
+				cycle = append(cycle[min:], cycle[:min]...)
+				cycles = append(cycles, append(cycle, cycle[0]))
+				break
+			}
+			index[cur] = len(path)
+			path = append(path, cur)
+			if ups := e[cur]; len(ups) > 0 {
+				cur = ups[0]
+			}
+		}
+	}
+	sort.SliceStable(cycles, func(i, j int) bool {
+		return cycles[i][0].String() < cycles[j][0].String()
+	})
+	return cycles
+}
+
+// catchPanicAsError catches panic from f and return it as *ErrPanic with the stack trace.
 // recoverFunc => func(recover()) (error)
 func catchPanicAsError(f func() error, extractErrs ...func(any) error) error {
 	var returnErr error
@@ -97,7 +348,7 @@ func catchPanicAsError(f func() error, extractErrs ...func(any) error) error {
 					}
 				}
 				// otherwise, return the panic as error
-				*err = fmt.Errorf("%s", r)
+				*err = &ErrPanic{Value: r, Stack: debug.Stack()}
 			}
 		}()
 		*err = f()
@@ -0,0 +1,223 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestFailurePaths(t *testing.T) {
+	a, b, c := succeed("a"), succeed("b"), fail("c")
+	canceled := succeed("canceled")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowFailFast()). // cancels the Step after c with context.Canceled
+		Add(
+			pl.Step(b).ExtraDependsOn(a),
+			pl.Step(c).ExtraDependsOn(b),
+			pl.Step(canceled).ExtraDependsOn(c).Condition(pl.Always),
+		)
+	err := w.Run(context.Background())
+	var werr pl.ErrWorkflow
+	if !errors.As(err, &werr) {
+		t.Fatalf("want ErrWorkflow, got %v", err)
+	}
+	paths := werr.FailurePaths(w)
+	if len(paths) != 1 {
+		t.Fatalf("want 1 path, got %v", paths)
+	}
+	want := []pl.StepReader{a, b, c}
+	if len(paths[0]) != len(want) {
+		t.Fatalf("want path %v, got %v", want, paths[0])
+	}
+	for i := range want {
+		if paths[0][i] != want[i] {
+			t.Errorf("want path %v, got %v", want, paths[0])
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "timeout" }
+
+func TestErrWorkflowByType(t *testing.T) {
+	a := pl.FuncNoInOut("a", func(context.Context) error { return timeoutError{} })
+	b := pl.FuncNoInOut("b", func(context.Context) error { return errors.New("b") })
+	c := pl.FuncIn("c", func(context.Context, struct{}) error { return nil })
+	canceled := succeed("canceled")
+	w := new(pl.Workflow)
+	w.Add(
+		pl.Steps(a, b),
+		pl.Step(c).Input(func(context.Context, *struct{}) error {
+			w.Cancel() // Pending Steps are Canceled with ErrWorkflowCanceled
+			return timeoutError{}
+		}),
+		pl.Step(canceled).ExtraDependsOn(a, b, c),
+	)
+	_ = w.Run(context.Background())
+
+	groups := w.Err().ByType()
+	if len(groups) != 2 {
+		t.Fatalf("want 2 groups, got %v", groups)
+	}
+	timeouts := groups[reflect.TypeOf(timeoutError{})]
+	if len(timeouts) != 2 || timeouts[0] != a || timeouts[1] != c {
+		t.Errorf("want a and c (unwrapped from ErrFlow) grouped, got %v", timeouts)
+	}
+	if others := groups[reflect.TypeOf(errors.New(""))]; len(others) != 1 || others[0] != b {
+		t.Errorf("want b grouped alone, got %v", others)
+	}
+}
+
+var errSentinel = errors.New("sentinel")
+
+func TestErrWorkflowUnwrap(t *testing.T) {
+	nested := pl.FuncNoInOut("nested", func(context.Context) error {
+		return fmt.Errorf("wrapped: %w", errSentinel)
+	})
+	stage := &pl.Stage[struct{}, struct{}]{
+		Name:     "stage",
+		Workflow: new(pl.Workflow).Add(pl.Step(nested)),
+	}
+	flow := pl.FuncIn("flow", func(context.Context, struct{}) error { return nil })
+	w := new(pl.Workflow).Add(
+		pl.Step(stage),
+		pl.Step(flow).Input(func(context.Context, *struct{}) error { return timeoutError{} }),
+	)
+	err := w.Run(context.Background())
+	if !errors.Is(err, errSentinel) {
+		t.Errorf("want errors.Is find the sentinel from nested Step, got %v", err)
+	}
+	var terr timeoutError
+	if !errors.As(err, &terr) {
+		t.Errorf("want errors.As pierce ErrFlow, got %v", err)
+	}
+	var werr pl.ErrWorkflow
+	if !errors.As(err, &werr) || !errors.Is(werr.Of(stage), errSentinel) || werr.Of(nested) != nil {
+		t.Errorf("want Of return the error of a Step, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "ErrFlow(From Input)") {
+		t.Errorf("want ErrFlow from Input in message, got %s", err)
+	}
+}
+
+func TestErrorSorted(t *testing.T) {
+	var steps []pl.StepDoer
+	for _, name := range []string{"c", "a", "b"} {
+		steps = append(steps, fail(name))
+	}
+	for i := 0; i < 5; i++ {
+		err := pl.ErrWorkflow{steps[0]: errors.New("c"), steps[1]: errors.New("a"), steps[2]: errors.New("b")}
+		if got, want := err.Error(), "a [Pending]: a\nb [Pending]: b\nc [Pending]: c\n"; got != want {
+			t.Fatalf("want %q, got %q", want, got)
+		}
+		unexpect := pl.ErrUnexpectStepInitStatus{steps[0], steps[1], steps[2]}
+		if got, want := unexpect.Error(), "Unexpect Step initial status:\na [Pending]\nb [Pending]\nc [Pending]"; got != want {
+			t.Fatalf("want %q, got %q", want, got)
+		}
+	}
+}
+
+func TestErrCycleDependency(t *testing.T) {
+	a, b, c := succeed("a"), succeed("b"), succeed("c")
+	x, y := succeed("x"), succeed("y")
+	down := succeed("down")
+	w := new(pl.Workflow).Add(
+		pl.Step(c).ExtraDependsOn(b),
+		pl.Step(a).ExtraDependsOn(c),
+		pl.Step(b).ExtraDependsOn(a),
+		pl.Step(down).ExtraDependsOn(b),
+		pl.Step(y).ExtraDependsOn(x),
+		pl.Step(x).ExtraDependsOn(y),
+	)
+	err := w.Run(context.Background())
+	var cerr pl.ErrCycleDependency
+	if !errors.As(err, &cerr) {
+		t.Fatalf("want ErrCycleDependency, got %v", err)
+	}
+	if got, want := cerr.Error(), "Cycle Dependency Error:\na -> b -> c -> a\nx -> y -> x"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if len(cerr) != 6 || len(cerr[down]) != 1 || cerr[down][0] != b {
+		t.Errorf("want every Step unable to run mapped to its blocked Dependees, got %v", map[pl.StepReader][]pl.StepReader(cerr))
+	}
+}
+
+func TestErrPanic(t *testing.T) {
+	inDo := pl.FuncNoInOut("inDo", func(context.Context) error { panic("do boom") })
+	inFlow := succeed("inFlow")
+	w := new(pl.Workflow).Add(
+		pl.Step(inDo),
+		pl.Step(inFlow).Input(func(context.Context, *struct{}) error { panic("flow boom") }),
+	)
+	_ = w.Run(context.Background())
+
+	var perr *pl.ErrPanic
+	if err := w.Err()[inDo]; !errors.As(err, &perr) || perr.Value != "do boom" {
+		t.Fatalf("want ErrPanic from Do, got %v", err)
+	}
+	if !strings.Contains(string(perr.Stack), "error_test.go") {
+		t.Errorf("want stack contains the panicking line, got %s", perr.Stack)
+	}
+
+	err := w.Err()[inFlow]
+	var ferr *pl.ErrFlow
+	if !errors.As(err, &ferr) || !errors.As(ferr.Err, &perr) || perr.Value != "flow boom" {
+		t.Errorf("want ErrPanic wrapped by ErrFlow, got %v", err)
+	}
+}
+
+func TestErrWorkflowByTypeWrapErrors(t *testing.T) {
+	step := fail("step")
+	w := new(pl.Workflow).WithOptions(pl.WorkflowWrapErrors()).Add(pl.Step(step))
+	_ = w.Run(context.Background())
+	var werr pl.ErrWorkflow
+	if !errors.As(w.Err(), &werr) {
+		t.Fatalf("want ErrWorkflow, got %v", w.Err())
+	}
+	groups := werr.ByType()
+	if got := groups[reflect.TypeOf(fmt.Errorf(""))]; len(got) != 1 {
+		t.Errorf("want the wrapping by Step name unwrapped in ByType, got %v", groups)
+	}
+}
+
+func TestErrValidation(t *testing.T) {
+	done, x, y := succeed("done"), succeed("x"), succeed("y")
+	w := new(pl.Workflow).Add(
+		pl.Step(done),
+		pl.Step(x).ExtraDependsOn(y),
+		pl.Step(y).ExtraDependsOn(x),
+	)
+	if err := new(pl.Workflow).Add(pl.Step(done)).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err := w.Validate()
+	var verr pl.ErrValidation
+	if !errors.As(err, &verr) || len(verr) != 2 {
+		t.Fatalf("want ErrValidation with 2 problems, got %v", err)
+	}
+	var serr pl.ErrUnexpectStepInitStatus
+	var cerr pl.ErrCycleDependency
+	if !errors.As(err, &serr) || !errors.As(err, &cerr) {
+		t.Errorf("want both init status and cycle reported, got %v", err)
+	}
+	want := "Workflow validation failed:\n" +
+		"- Unexpect Step initial status:\n  done [Succeeded]\n" +
+		"- Cycle Dependency Error:\n  x -> y -> x"
+	if got := err.Error(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if err := w.Run(context.Background()); !errors.As(err, &verr) {
+		t.Errorf("want Run fail with ErrValidation, got %v", err)
+	}
+
+	var nilStep *provision
+	if err := new(pl.Workflow).Add(pl.Steps(nilStep)).Validate(); !errors.Is(err, pl.ErrNilStep) {
+		t.Errorf("want ErrNilStep, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package pl
+
+import "time"
+
+// StepEvent is a status transition of a Step, see Workflow.Events.
+type StepEvent struct {
+	Step StepReader
+	From StepStatus
+	To   StepStatus
+	At   time.Time
+	Err  error // the error of the Step if To is terminated
+}
+
+// Events returns the channel of the status transitions of Steps in the current run,
+// or in the next run if the Workflow is not running, e.g. to stream the progress to a UI.
+//
+// Each transition made by the Workflow publishes exactly one event:
+// Running, and terminated as Succeeded, Failed, Canceled or Skipped.
+// The channel is closed when Run returns, call Events again for the next run.
+//
+// Events are delivered from the hook queue, in the order of transitions,
+// the channel is buffered by the size of the hook queue, see WorkflowHookQueue.
+// With HookOverflowBlock, a consumer not keeping up delays the end of Run but never the Steps,
+// with the dropping policies, the events not fitting in the channel are dropped and counted in DroppedHooks.
+func (s *Workflow) Events() <-chan StepEvent {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	if s.events == nil {
+		size := s.hookQueueSize
+		if size == 0 {
+			size = DefaultHookQueueSize
+		}
+		s.events = make(chan StepEvent, size)
+	}
+	return s.events
+}
+
+// publish sends the event of a transition to the Events channel via the hook queue, if any,
+// and wakes up the Steps awaiting a termination, see AwaitStep.
+func (s *Workflow) publish(step StepReader, from, to StepStatus, err error) {
+	if to.IsTerminated() {
+		s.notifyTerminated()
+	}
+	s.eventsMu.Lock()
+	ch := s.events
+	s.eventsMu.Unlock()
+	if ch == nil {
+		return
+	}
+	event := StepEvent{Step: step, From: from, To: to, At: s.clock().Now(), Err: err}
+	s.dispatchHook(func() {
+		if s.hookOverflow == HookOverflowBlock {
+			ch <- event
+			return
+		}
+		select {
+		case ch <- event:
+		default:
+			s.hooks.Load().drop()
+		}
+	})
+}
+
+// closeEvents closes the Events channel at the end of a run.
+func (s *Workflow) closeEvents() {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	if s.events != nil {
+		close(s.events)
+		s.events = nil
+	}
+}
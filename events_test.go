@@ -0,0 +1,46 @@
+package pl_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestWorkflowEvents(t *testing.T) {
+	a, b, c := fail("a"), succeed("b"), succeed("c")
+	w := new(pl.Workflow).Add(
+		pl.Step(b).ExtraDependsOn(a),
+		pl.Step(c).When(pl.Skip),
+	)
+	events := w.Events()
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+
+	got := map[pl.StepReader][]pl.StepStatus{}
+	for event := range events { // closed when Run returns
+		if event.At.IsZero() {
+			t.Errorf("want timestamp of event %v", event)
+		}
+		if (event.To == pl.StepStatusFailed) != (event.Err != nil) {
+			t.Errorf("want error only in the Failed event, got %v", event)
+		}
+		if prev := got[event.Step]; len(prev) > 0 && prev[len(prev)-1] != event.From {
+			t.Errorf("want event from %s, got %v", prev[len(prev)-1], event)
+		}
+		got[event.Step] = append(got[event.Step], event.From, event.To)
+	}
+	<-done
+	want := map[pl.StepReader][]pl.StepStatus{
+		a: {pl.StepStatusPending, pl.StepStatusRunning, pl.StepStatusRunning, pl.StepStatusFailed},
+		b: {pl.StepStatusPending, pl.StepStatusCanceled},
+		c: {pl.StepStatusPending, pl.StepStatusSkipped},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want transitions %v, got %v", want, got)
+	}
+	if next := w.Events(); next == events {
+		t.Error("want a new channel for the next run")
+	}
+}
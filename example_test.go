@@ -2,8 +2,10 @@ package pl_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -14,7 +16,7 @@ func ExampleWorkflow() {
 	suite := new(pl.Workflow)
 
 	{
-		// create jobs
+		// create Steps
 		createResourceGroup := new(CreateResourceGroup)
 		createAKSCluster := new(CreateAKSCluster)
 		getKubeConfig := new(GetAKSClusterCredential)
@@ -22,7 +24,7 @@ func ExampleWorkflow() {
 		// connect steps into Workflow
 		suite.Add(
 			pl.Step(createResourceGroup).
-				// use Input to set the Input of a Job.
+				// use Input to set the Input of a Step.
 				Input(func(ctx context.Context, i *CreateResourceGroupInput) error {
 					i.Name = "rg"
 					i.Region = "eastus"
@@ -41,7 +43,7 @@ func ExampleWorkflow() {
 					i.Name = "aks-cluster"
 					return nil
 				}).
-				// use DependsOn to connects two Jobs with an adapter function.
+				// use DependsOn to connect two Steps with an adapter function.
 				DependsOn(
 					pl.Adapt(createResourceGroup, func(_ context.Context, o CreateResourceGroupOutput, i *CreateAKSClusterInput) error {
 						i.ResourceGroupName = o.Name
@@ -60,7 +62,7 @@ func ExampleWorkflow() {
 				),
 		)
 
-		// use Func to create a Job from a function.
+		// use Func to create a Step from a function.
 		preCheck := pl.Func("precheck", func(ctx context.Context, in string) (func(*string), error) {
 			return func(s *string) {
 				// set the Output in this callback function
@@ -75,13 +77,13 @@ func ExampleWorkflow() {
 				}),
 			).DirectDependsOn(preCheck),
 		)
-		// use Stage to wrap a Workflow into a Job.
+		// use Stage to wrap a Workflow into a Step.
 		preStage := &pl.Stage[PreCheckInput, PreCheckOutput]{
 			Name:     "PreStage",
 			Workflow: preWorkflow,
 			SetInput: func(pci PreCheckInput) {
 				// PreCheckInput is already be filled,
-				// use the input to fill the Input of Jobs inside your Workflow.
+				// use the input to fill the Input of Steps inside your Workflow.
 				*preCheck.Input() = pci.BuildID
 			},
 			SetOutput: func(pco *PreCheckOutput) {
@@ -90,7 +92,7 @@ func ExampleWorkflow() {
 			},
 		}
 
-		// Stage can be used as a Job in a Workflow
+		// Stage can be used as a Step in a Workflow
 		suite.Add(
 			pl.Step(preStage).
 				Input(func(_ context.Context, in *PreCheckInput) error {
@@ -101,26 +103,26 @@ func ExampleWorkflow() {
 				ExtraDependsOn(preStage),
 		)
 
-		// still able to modify the Workflow if still hold reference to jobs.
+		// still able to modify the Workflow if still hold reference to Steps.
 		passRegion := pl.Func("forget to pass region", func(_ context.Context, o CreateResourceGroupOutput) (func(i *CreateAKSClusterInput), error) {
 			return func(i *CreateAKSClusterInput) {
 				i.Region = o.Region
 			}, nil
 		})
 		suite.Add(
-			// use DirectDependsOn to connect two jobs with matched Input and Output
+			// use DirectDependsOn to connect two Steps with matched Input and Output
 			pl.Step(createAKSCluster).DirectDependsOn(passRegion),
 			pl.Step(passRegion).DirectDependsOn(createResourceGroup),
 		)
 	}
 
 	var getKubeConfig *GetAKSClusterCredential
-	// if already lose reference to the original jobs,
-	// use w.Dep() to get the Dependency and jobs inside.
+	// if already lose reference to the original Steps,
+	// use w.Dep() to get the Dependency and Steps inside.
 	for step := range suite.Dep() {
 		switch typedStep := step.(type) {
 		case *CreateAKSCluster:
-			// still able to inject jobs between createAKSCluster and its Dependers.
+			// still able to inject Steps between createAKSCluster and its Dependers.
 			createAKSCluster := typedStep
 			patchCVE := pl.FuncIn("PatchCVE", func(ctx context.Context, o CreateAKSClusterOutput) error {
 				// update the aks cluster with CVE patch
@@ -129,12 +131,12 @@ func ExampleWorkflow() {
 			})
 			suite.Add(
 				// in this case, dependers of createAKSCluster will wait for patchCVE to finish.
-				pl.Steps(suite.Dep().DownstreamOf(createAKSCluster)...).DependsOn(patchCVE),
+				pl.Steps(suite.DownstreamOf(createAKSCluster)...).DependsOn(patchCVE),
 				pl.Step(patchCVE).DirectDependsOn(createAKSCluster),
 			)
 		case *GetAKSClusterCredential:
 			getKubeConfig = typedStep
-			// use Input() to add a dependency that modifies the Input of a Job.
+			// use Input() to add a dependency that modifies the Input of a Step.
 			suite.Add(
 				pl.Step(getKubeConfig).
 					Input(func(_ context.Context, i *GetKubeConfigInput) error {
@@ -162,7 +164,7 @@ func ExampleWorkflow() {
 	suiteErr := suite.Err()
 	fmt.Println(suiteErr.IsNil())
 
-	// get the output from the original jobs.
+	// get the output from the original Steps.
 	fmt.Println(pl.GetOutput(getKubeConfig))
 
 	// Output:
@@ -299,3 +301,367 @@ type PreCheckInput struct {
 type PreCheckOutput struct {
 	Message string
 }
+
+// printStep returns a Step printing its name.
+func printStep(name string) pl.Steper[struct{}, struct{}] {
+	return pl.FuncNoInOut(name, func(context.Context) error {
+		fmt.Println(name)
+		return nil
+	})
+}
+
+func ExampleStep() {
+	hello := pl.FuncOut("hello", func(context.Context) (func(*string), error) {
+		return func(o *string) { *o = "hello" }, nil
+	})
+	length := pl.FuncIn("length", func(_ context.Context, n int) error {
+		fmt.Println(n)
+		return nil
+	})
+	w := new(pl.Workflow).Add(
+		// use DependsOn with Adapt to flow the Output of hello into the Input of length,
+		// when their types don't match.
+		pl.Step(length).DependsOn(
+			pl.Adapt(hello, func(_ context.Context, o string, i *int) error {
+				*i = len(o)
+				return nil
+			}),
+		),
+	)
+	fmt.Println(w.Run(context.Background()))
+	// Output:
+	// 5
+	// <nil>
+}
+
+func ExampleStep_directDependsOn() {
+	hello := pl.FuncOut("hello", func(context.Context) (func(*string), error) {
+		return func(o *string) { *o = "hello" }, nil
+	})
+	greet := pl.FuncIn("greet", func(_ context.Context, s string) error {
+		fmt.Println(s, "world")
+		return nil
+	})
+	// the Output type of hello is the same as the Input type of greet
+	w := new(pl.Workflow).Add(pl.Step(greet).DirectDependsOn(hello))
+	_ = w.Run(context.Background())
+	// Output:
+	// hello world
+}
+
+func ExampleStep_extraDependsOn() {
+	first, second := printStep("first"), printStep("second")
+	// second runs after first, without data flow
+	w := new(pl.Workflow).Add(pl.Step(second).ExtraDependsOn(first))
+	_ = w.Run(context.Background())
+	// Output:
+	// first
+	// second
+}
+
+func ExampleStep_input() {
+	greet := pl.FuncIn("greet", func(_ context.Context, s string) error {
+		fmt.Println("hello", s)
+		return nil
+	})
+	w := new(pl.Workflow).Add(
+		pl.Step(greet).Input(func(_ context.Context, s *string) error {
+			*s = "world"
+			return nil
+		}),
+	)
+	_ = w.Run(context.Background())
+	// Output:
+	// hello world
+}
+
+func ExampleStep_timeout() {
+	slow := pl.FuncNoInOut("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	w := new(pl.Workflow).Add(pl.Step(slow).Timeout(time.Millisecond))
+	_ = w.Run(context.Background())
+	fmt.Println(errors.Is(w.Err()[slow], context.DeadlineExceeded))
+	// Output:
+	// true
+}
+
+func ExampleSteps() {
+	setup := printStep("setup")
+	a, b := printStep("a"), printStep("b")
+	w := new(pl.Workflow).
+		// run one Step at a time, so the output is deterministic
+		WithOptions(pl.WorkflowMaxConcurrency(1)).
+		Add(pl.Steps(a, b).DependsOn(setup))
+	_ = w.Run(context.Background())
+	// Output:
+	// setup
+	// a
+	// b
+}
+
+func ExampleTSteps() {
+	source := pl.FuncOut("source", func(context.Context) (func(*int), error) {
+		return func(o *int) { *o = 21 }, nil
+	})
+	double := pl.FuncIn("double", func(_ context.Context, i int) error {
+		fmt.Println("double", i*2)
+		return nil
+	})
+	square := pl.FuncIn("square", func(_ context.Context, i int) error {
+		fmt.Println("square", i*i)
+		return nil
+	})
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowMaxConcurrency(1)).
+		// double and square share the Input type, so they can depend on source together
+		Add(pl.TSteps(double, square).DirectDependsOn(source))
+	_ = w.Run(context.Background())
+	// Output:
+	// double 42
+	// square 441
+}
+
+func ExampleRetryOption() {
+	errPermanent := errors.New("permanent")
+	attempts := 0
+	flaky := pl.FuncNoInOut("flaky", func(ctx context.Context) error {
+		attempts++
+		fmt.Println("attempt", pl.AttemptFromContext(ctx))
+		if attempts < 3 {
+			return fmt.Errorf("transient")
+		}
+		return errPermanent
+	})
+	w := new(pl.Workflow).Add(
+		pl.Step(flaky).Retry(pl.RetryOption{
+			Attempts: 10,
+			Backoff:  backoff.NewConstantBackOff(time.Hour),
+			Timer:    new(testTimer), // fires immediately instead of waiting an hour
+			StopIf: func(_ context.Context, _ uint64, _ time.Duration, err error) bool {
+				return errors.Is(err, errPermanent)
+			},
+		}),
+	)
+	_ = w.Run(context.Background())
+	// Output:
+	// attempt 1
+	// attempt 2
+	// attempt 3
+}
+
+func ExampleCondition() {
+	failing := pl.FuncNoInOut("failing", func(context.Context) error {
+		return fmt.Errorf("failing")
+	})
+	cleanup, notify, next := printStep("cleanup"), printStep("notify"), printStep("next")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowMaxConcurrency(1)).
+		Add(
+			pl.Step(cleanup).ExtraDependsOn(failing).Condition(pl.Always),
+			pl.Step(notify).ExtraDependsOn(failing).Condition(pl.Failed),
+			pl.Step(next).ExtraDependsOn(failing), // the default Condition is Succeeded
+		)
+	_ = w.Run(context.Background())
+	fmt.Println(next.GetStatus())
+	// Output:
+	// cleanup
+	// notify
+	// Canceled
+}
+
+func ExampleWhen() {
+	ci := false
+	deploy := printStep("deploy")
+	w := new(pl.Workflow).Add(
+		pl.Step(deploy).When(func(context.Context) bool { return ci }),
+	)
+	_ = w.Run(context.Background())
+	fmt.Println(deploy.GetStatus())
+	// Output:
+	// Skipped
+}
+
+func ExampleWorkflowWhen() {
+	step := printStep("step")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowWhen(pl.Skip)).
+		Add(pl.Step(step))
+	_ = w.Run(context.Background())
+	fmt.Println(step.GetStatus())
+	// Output:
+	// Skipped
+}
+
+func ExampleStage() {
+	greet := pl.FuncIn("greet", func(_ context.Context, name string) error {
+		fmt.Println("hello", name)
+		return nil
+	})
+	stage := &pl.Stage[string, struct{}]{
+		Name:     "stage",
+		Workflow: new(pl.Workflow).Add(pl.Step(greet)),
+		// pass the Stage Input into the Steps inside
+		SetInput: func(name string) { *greet.Input() = name },
+	}
+	w := new(pl.Workflow).Add(
+		pl.Step(stage).Input(func(_ context.Context, name *string) error {
+			*name = "stage"
+			return nil
+		}),
+	)
+	_ = w.Run(context.Background())
+	// Output:
+	// hello stage
+}
+
+func ExampleWorkflowMaxConcurrency() {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	steps := []pl.StepDoer{}
+	for i := 0; i < 5; i++ {
+		steps = append(steps, pl.FuncNoInOut(fmt.Sprint(i), func(context.Context) error {
+			mu.Lock()
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		}))
+	}
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowMaxConcurrency(2)).
+		Add(pl.Steps(steps...))
+	_ = w.Run(context.Background())
+	fmt.Println(maxRunning <= 2)
+	// Output:
+	// true
+}
+
+func ExampleWorkflowFailFast() {
+	failing := pl.FuncNoInOut("failing", func(context.Context) error {
+		return fmt.Errorf("failing")
+	})
+	next := printStep("next")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowFailFast()).
+		Add(pl.Step(next).ExtraDependsOn(failing).Condition(pl.Always))
+	_ = w.Run(context.Background())
+	fmt.Println(next.GetStatus(), errors.Is(w.Err()[next], context.Canceled))
+	// Output:
+	// Canceled true
+}
+
+func ExampleWorkflowContinueOnError() {
+	failing := pl.FuncNoInOut("failing", func(context.Context) error {
+		return fmt.Errorf("failing")
+	})
+	next := printStep("next")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowContinueOnError()).
+		Add(pl.Step(next).ExtraDependsOn(failing))
+	err := w.Run(context.Background())
+	fmt.Println(err != nil)
+	// Output:
+	// next
+	// true
+}
+
+func ExampleWorkflow_Reset() {
+	runs := 0
+	step := pl.FuncNoInOut("step", func(context.Context) error {
+		runs++
+		return nil
+	})
+	w := new(pl.Workflow).Add(pl.Step(step))
+	_ = w.Run(context.Background())
+	fmt.Println(w.HasRun(), errors.Is(w.Run(context.Background()), pl.ErrWorkflowHasRun))
+
+	// reset the Steps to Pending to run again
+	_ = w.Reset()
+	_ = w.Run(context.Background())
+	fmt.Println(runs)
+	// Output:
+	// true true
+	// 2
+}
+
+func ExampleWorkflow_DryRun() {
+	a, b, c := printStep("a"), printStep("b"), printStep("c")
+	w := new(pl.Workflow).Add(
+		pl.Step(c).ExtraDependsOn(a, b),
+	)
+	plan, _ := w.DryRun(context.Background())
+	for i, batch := range plan {
+		fmt.Println(i, batch)
+	}
+	// Output:
+	// 0 [a b]
+	// 1 [c]
+}
+
+func ExampleWorkflow_DeadLetters() {
+	consume := pl.FuncIn("consume", func(context.Context, int) error {
+		return fmt.Errorf("poison")
+	})
+	w := new(pl.Workflow).Add(
+		pl.Step(consume).Input(func(_ context.Context, i *int) error {
+			*i = 42
+			return nil
+		}),
+	)
+	_ = w.Run(context.Background())
+	for _, letter := range w.DeadLetters() {
+		fmt.Println(letter.Name, letter.Input)
+	}
+	// Output:
+	// consume 42
+}
+
+func ExampleWorkflow_Snapshot() {
+	ok := printStep("ok")
+	skipped := printStep("skipped")
+	w := new(pl.Workflow).Add(pl.Step(ok), pl.Step(skipped).When(pl.Skip))
+	_ = w.Run(context.Background())
+	for _, s := range w.Snapshot() {
+		fmt.Println(s.Name, s.Status, s.StartedAt != nil)
+	}
+	// Output:
+	// ok
+	// ok Succeeded true
+	// skipped Skipped false
+}
+
+func ExampleNewGroup() {
+	g := pl.NewGroup(context.Background())
+	fetch := pl.Go(g, "fetch", func(context.Context) (string, error) {
+		return "data", nil
+	})
+	pl.GoAfter(g, "process", func(_ context.Context, data string) error {
+		fmt.Println("process", data)
+		return nil
+	}, fetch)
+	fmt.Println(g.Wait())
+	// Output:
+	// process data
+	// <nil>
+}
+
+func ExampleTemplate() {
+	deploy := &pl.Template[string]{
+		Name: "deploy",
+		Build: func(region string) *pl.Workflow {
+			return new(pl.Workflow).Add(pl.Step(printStep("deploy " + region)))
+		},
+	}
+	var registry pl.TemplateRegistry
+	_ = pl.RegisterTemplate(&registry, deploy)
+	w, _ := pl.NewFromTemplate(&registry, "deploy", "eastus")
+	_ = w.Run(context.Background())
+	// Output:
+	// deploy eastus
+}
@@ -0,0 +1,40 @@
+package pl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fingerprint returns a hash of the definition of the Workflow,
+// i.e. the names and types of the Steps, the edges between them,
+// and the settings deciding how a Step runs (Timeout, Retry attempts, StartDeadline).
+//
+// Two Workflows built by the same code have the same Fingerprint,
+// regardless of the order Steps are added, so it can be stored along with the persisted state
+// (e.g. Checkpoint, compared by Restore) to detect the definition has changed since.
+//
+// Functions (Condition, When, Input, ...) can't be compared, they are not part of the Fingerprint.
+func (s *Workflow) Fingerprint() string {
+	var lines []string
+	for _, step := range s.sortedSteps() {
+		line := fmt.Sprintf("step %q %T timeout=%s", step.String(), step, step.getTimeout())
+		if retry := step.getRetry(); retry != nil {
+			line += fmt.Sprintf(" retry=%d", retry.Attempts)
+		}
+		if sd := step.getStartDeadline(); sd.d > 0 {
+			line += fmt.Sprintf(" startDeadline=%s,%t", sd.d, sd.fail)
+		}
+		lines = append(lines, line)
+	}
+	var edges []string
+	for _, e := range s.deps.edges(s.steps) {
+		edges = append(edges, fmt.Sprintf("edge %q -> %q data=%t", e.Dependee.String(), e.Depender.String(), e.Data))
+	}
+	sort.Strings(edges)
+	lines = append(lines, edges...)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,36 @@
+package pl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+)
+
+func TestFingerprint(t *testing.T) {
+	build := func(names ...string) *pl.Workflow {
+		a, b, c := succeed(names[0]), succeed(names[1]), succeed(names[2])
+		return new(pl.Workflow).Add(pl.Step(c).ExtraDependsOn(a, b))
+	}
+	base := build("a", "b", "c").Fingerprint()
+
+	a, b, c := succeed("a"), succeed("b"), succeed("c")
+	reordered := new(pl.Workflow).Add(pl.Step(b), pl.Step(a), pl.Step(c).ExtraDependsOn(b, a))
+	if got := reordered.Fingerprint(); got != base {
+		t.Errorf("want the same Fingerprint regardless of adding order, got %s and %s", got, base)
+	}
+
+	for name, w := range map[string]*pl.Workflow{
+		"renamed": build("a", "renamed", "c"),
+		"added":   build("a", "b", "c").Add(pl.Step(succeed("d"))),
+		"removed": new(pl.Workflow).Add(pl.Step(succeed("c")).ExtraDependsOn(succeed("a"))),
+		"edge":    new(pl.Workflow).Add(pl.Steps(succeed("a"), succeed("b"), succeed("c"))),
+		"timeout": new(pl.Workflow).Add(
+			pl.Step(succeed("c")).ExtraDependsOn(succeed("a"), succeed("b")).Timeout(time.Second),
+		),
+	} {
+		if got := w.Fingerprint(); got == base {
+			t.Errorf("%s: want Fingerprint changed", name)
+		}
+	}
+}
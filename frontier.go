@@ -0,0 +1,74 @@
+package pl
+
+import "sort"
+
+// frontier is the Pending Steps to be visited in next tick,
+// so tick only visits the Steps whose Dependees just terminated,
+// instead of all Steps in Workflow.
+//
+// frontier is only accessed by the Run goroutine.
+type frontier struct {
+	steps      []StepDoer
+	has        map[StepDoer]bool
+	index      map[StepDoer]int        // the order of Steps being added into Workflow
+	downstream map[StepDoer][]StepDoer // reverse index of dependency, see Workflow.downstreamIndex
+	ready      []*readyStep            // the ready queue, Steps waiting for a lease to start
+	held       []StepDoer              // the Steps held by the barrier, see WorkflowBarrier
+	swept      bool                    // whether all Pending Steps are Canceled after Workflow stopped
+}
+
+// newFrontier starts with the Steps without Dependee.
+func newFrontier(steps []StepDoer, d dependency, downstream map[StepDoer][]StepDoer) *frontier {
+	f := &frontier{
+		has:        make(map[StepDoer]bool),
+		index:      make(map[StepDoer]int, len(steps)),
+		downstream: downstream,
+	}
+	for i, step := range steps {
+		f.index[step] = i
+	}
+	for _, step := range steps {
+		if len(d.UpstreamOf(step)) == 0 {
+			f.push(step)
+		}
+	}
+	return f
+}
+
+// newFrontierFromPlan starts with the roots of the compiled Plan.
+func newFrontierFromPlan(p *Plan) *frontier {
+	f := &frontier{
+		has:        make(map[StepDoer]bool),
+		index:      p.index,
+		downstream: p.downstream,
+	}
+	for _, step := range p.roots {
+		f.push(step)
+	}
+	return f
+}
+
+func (f *frontier) push(step StepDoer) {
+	if !f.has[step] {
+		f.has[step] = true
+		f.steps = append(f.steps, step)
+	}
+}
+
+// pushDownstreamOf pushes the Dependers of a terminated Step.
+func (f *frontier) pushDownstreamOf(step StepDoer) {
+	for _, r := range f.downstream[step] {
+		f.push(r)
+	}
+}
+
+// pop returns the Steps in the order of being added into Workflow, and empties the frontier.
+func (f *frontier) pop() []StepDoer {
+	steps := f.steps
+	sort.Slice(steps, func(i, j int) bool {
+		return f.index[steps[i]] < f.index[steps[j]]
+	})
+	f.steps = nil
+	clear(f.has)
+	return steps
+}
@@ -0,0 +1,167 @@
+package pl
+
+import "sort"
+
+// edge is a dependency between two Steps for rendering the graph.
+type edge struct {
+	Dependee StepDoer
+	Depender StepDoer
+	Data     bool // whether data flows through the edge, false for ExtraDependsOn
+}
+
+// sortedSteps returns all Steps sorted by name.
+func (d dependency) sortedSteps() []StepDoer {
+	steps := d.Steps()
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].String() < steps[j].String()
+	})
+	return steps
+}
+
+// sortedSteps returns all Steps in Workflow sorted by name,
+// Steps with the same name are in the order of being added.
+func (s *Workflow) sortedSteps() []StepDoer {
+	steps := append([]StepDoer(nil), s.steps...)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].String() < steps[j].String()
+	})
+	return steps
+}
+
+// edges returns the deduplicated edges to the Dependers, in the order of dependers and links.
+// An edge is Data if any link between the two Steps has data flow.
+func (d dependency) edges(dependers []StepDoer) []edge {
+	var edges []edge
+	for _, r := range dependers {
+		index := map[StepDoer]int{}
+		for _, l := range d[r] {
+			if l.Dependee == nil {
+				continue
+			}
+			if i, ok := index[l.Dependee]; ok {
+				edges[i].Data = edges[i].Data || l.Flow != nil
+				continue
+			}
+			index[l.Dependee] = len(edges)
+			edges = append(edges, edge{
+				Dependee: l.Dependee,
+				Depender: r,
+				Data:     l.Flow != nil,
+			})
+		}
+	}
+	return edges
+}
+
+// topologicalOrder groups the Steps by execution level (Kahn's algorithm) without touching their status.
+// Within a level, Steps are in the given order, a Step is in the level right after its last Dependee's.
+//
+// Steps in or downstream of a cycle can't be leveled, they are returned in ErrCycleDependency,
+// each with its Dependees not leveled.
+func topologicalOrder(steps []StepDoer, d dependency) ([][]StepDoer, error) {
+	level := make(map[StepDoer]int, len(steps))
+	for placed := true; placed; {
+		placed = false
+	next:
+		for _, step := range steps {
+			if _, ok := level[step]; ok {
+				continue
+			}
+			l := 0
+			for _, e := range d.UpstreamOf(step) {
+				el, ok := level[e]
+				if !ok {
+					continue next
+				}
+				l = max(l, el+1)
+			}
+			level[step] = l
+			placed = true
+		}
+	}
+	var levels [][]StepDoer
+	inCycle := ErrCycleDependency{}
+	for _, step := range steps {
+		l, ok := level[step]
+		if !ok {
+			inCycle[step] = []StepReader{}
+			for _, e := range d.listUpstreamReporterOf(step) {
+				if _, ok := level[e.(StepDoer)]; !ok {
+					inCycle[step] = append(inCycle[step], e)
+				}
+			}
+			continue
+		}
+		for len(levels) <= l {
+			levels = append(levels, nil)
+		}
+		levels[l] = append(levels[l], step)
+	}
+	if len(inCycle) > 0 {
+		return nil, inCycle
+	}
+	return levels, nil
+}
+
+// TopologicalOrder returns the Steps grouped by execution level, sorted by name within a level,
+// Steps in a level can run in parallel, and a Step is in the level right after its last Dependee's.
+//
+// It returns ErrCycleDependency if the dependency has a cycle.
+func (d dependency) TopologicalOrder() ([][]StepDoer, error) {
+	return topologicalOrder(d.sortedSteps(), d)
+}
+
+// TopologicalOrder returns the Steps grouped by execution level, in the order of being added within a level,
+// Steps in a level can run in parallel, and a Step is in the level right after its last Dependee's.
+//
+// It returns ErrCycleDependency if the Workflow has a cycle, the same check Run does before starting Steps.
+// Unlike DryRun, it doesn't check the status of Steps, and is safe to call while the Workflow is running.
+func (s *Workflow) TopologicalOrder() ([][]StepDoer, error) {
+	return topologicalOrder(s.steps, s.deps)
+}
+
+// SafeAdd adds the Steps as Add does, but checks the dependency edge by edge first,
+// and returns ErrCycleEdge naming the first edge forming a cycle, without adding any of the Steps,
+// e.g. to get the feedback at the Add introducing the cycle, rather than at Run.
+//
+// Each edge is checked against the Workflow and the edges checked before it,
+// by searching whether the Dependee already depends on the Depender.
+func (s *Workflow) SafeAdd(dbs ...WorkflowStep) error {
+	scratch := make(dependency)
+	scratch.merge(s.deps)
+	for _, db := range dbs {
+		d := db.Done()
+		for _, depender := range d.sortedSteps() {
+			for _, l := range d[depender] {
+				if l.Dependee != nil && scratch.dependsOn(l.Dependee, depender) {
+					return ErrCycleEdge{Dependee: l.Dependee, Depender: depender}
+				}
+				scratch.merge(dependency{depender: {l}})
+			}
+		}
+	}
+	s.Add(dbs...)
+	return nil
+}
+
+// dependsOn returns whether the Depender is or depends on the Dependee, directly or transitively.
+func (d dependency) dependsOn(depender, dependee StepDoer) bool {
+	seen := map[StepDoer]bool{}
+	var visit func(StepDoer) bool
+	visit = func(step StepDoer) bool {
+		if step == dependee {
+			return true
+		}
+		if seen[step] {
+			return false
+		}
+		seen[step] = true
+		for _, l := range d[step] {
+			if l.Dependee != nil && visit(l.Dependee) {
+				return true
+			}
+		}
+		return false
+	}
+	return visit(depender)
+}
@@ -0,0 +1,113 @@
+package pl
+
+import (
+	"context"
+	"sync"
+)
+
+// Group is an errgroup-style facade of Workflow,
+// for running dependent functions, getting the first error, and canceling the rest.
+//
+// Usage:
+//
+//	g := pl.NewGroup(ctx)
+//	fetch := pl.Go(g, "fetch", func(ctx context.Context) (string, error) {
+//		return "data", nil
+//	})
+//	pl.GoAfter(g, "process", func(ctx context.Context, data string) error {
+//		return process(data)
+//	}, fetch)
+//	err := g.Wait()
+//
+// Unlike errgroup, functions start when Wait is called, in the order of their dependencies.
+// Group is backed by a Workflow with WorkflowFailFast,
+// once a function returns error, the context of the others is canceled,
+// and the functions not started yet are Canceled.
+type Group struct {
+	ctx      context.Context
+	w        *Workflow
+	errOnce  sync.Once
+	firstErr error // the first error returned by the functions
+}
+
+// NewGroup creates a Group running with ctx.
+func NewGroup(ctx context.Context) *Group {
+	return &Group{
+		ctx: ctx,
+		w:   new(Workflow).WithOptions(WorkflowFailFast()),
+	}
+}
+
+// Handle is the result of a function in Group, it's also used to declare dependency via GoAfter.
+type Handle[O any] struct {
+	g     *Group
+	step  dependee[O]
+	value O
+}
+
+// Result returns the output of the function, and the error recorded for it in the Group.
+// It should be called after Wait.
+func (h *Handle[O]) Result() (O, error) {
+	return h.value, h.g.w.Err()[h.step]
+}
+
+// call calls fn, records its output and the first error of the Group.
+func (h *Handle[O]) call(fn func() (O, error)) (func(*O), error) {
+	o, err := fn()
+	h.value = o
+	if err != nil {
+		h.g.errOnce.Do(func() { h.g.firstErr = err })
+	}
+	return func(out *O) { *out = o }, err
+}
+
+// Go adds a function without dependency into the Group.
+func Go[O any](g *Group, name string, fn func(context.Context) (O, error)) *Handle[O] {
+	h := &Handle[O]{g: g}
+	step := FuncOut(name, func(ctx context.Context) (func(*O), error) {
+		return h.call(func() (O, error) { return fn(ctx) })
+	})
+	h.step = step
+	g.w.Add(Step(step))
+	return h
+}
+
+// GoAfter adds a function receiving the output of another function in the Group,
+// it starts after the other one succeeded.
+func GoAfter[I any](g *Group, name string, fn func(context.Context, I) error, after *Handle[I]) *Handle[struct{}] {
+	return GoThen(g, name, func(ctx context.Context, i I) (struct{}, error) {
+		return struct{}{}, fn(ctx, i)
+	}, after)
+}
+
+// GoThen is GoAfter with output, so that other functions can depend on it.
+func GoThen[I, O any](g *Group, name string, fn func(context.Context, I) (O, error), after *Handle[I]) *Handle[O] {
+	h := &Handle[O]{g: g}
+	step := Func(name, func(ctx context.Context, i I) (func(*O), error) {
+		return h.call(func() (O, error) { return fn(ctx, i) })
+	})
+	h.step = step
+	g.w.Add(Step(step).DirectDependsOn(after.step))
+	return h
+}
+
+// Wait runs all functions in the Group and waits for them terminated,
+// returns the first error returned by the functions, or nil.
+//
+// Wait returns the error of Workflow if no function returned error,
+// e.g. ErrCycleDependency, or ErrWorkflow of Canceled functions when ctx is done before they started.
+func (g *Group) Wait() error {
+	err := g.w.Run(g.ctx)
+	if err == nil {
+		return nil
+	}
+	if g.firstErr != nil {
+		return g.firstErr
+	}
+	return err
+}
+
+// Workflow returns the Workflow backing the Group, e.g. to render it via Mermaid.
+func (g *Group) Workflow() *Workflow {
+	return g.w
+}
@@ -0,0 +1,61 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestGroup(t *testing.T) {
+	g := pl.NewGroup(context.Background())
+	fetch := pl.Go(g, "fetch", func(context.Context) (int, error) { return 21, nil })
+	double := pl.GoThen(g, "double", func(_ context.Context, n int) (string, error) {
+		return fmt.Sprint(n * 2), nil
+	}, fetch)
+	var got string
+	pl.GoAfter(g, "print", func(_ context.Context, s string) error {
+		got = s
+		return nil
+	}, double)
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got != "42" {
+		t.Errorf("want output flowed to GoAfter, got %q", got)
+	}
+	if n, err := fetch.Result(); n != 21 || err != nil {
+		t.Errorf("want Result of fetch, got %d, %v", n, err)
+	}
+}
+
+func TestGroupFirstError(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+	started := make(chan struct{})
+	g := pl.NewGroup(context.Background())
+	fetch := pl.Go(g, "fetch", func(context.Context) (int, error) {
+		<-started
+		return 0, errFetch
+	})
+	slow := pl.Go(g, "slow", func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	called := false
+	pl.GoAfter(g, "process", func(context.Context, int) error {
+		called = true
+		return nil
+	}, fetch)
+	if err := g.Wait(); err != errFetch {
+		t.Errorf("want the first error as is, got %v", err)
+	}
+	if _, err := slow.Result(); !errors.Is(err, context.Canceled) {
+		t.Errorf("want the others canceled, got %v", err)
+	}
+	if called {
+		t.Error("want the Depender of the failed function not called")
+	}
+}
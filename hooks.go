@@ -0,0 +1,192 @@
+package pl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// HookOverflow decides what happens when the hook queue is full, see WorkflowHookQueue.
+type HookOverflow int
+
+const (
+	// HookOverflowBlock makes the terminating Step wait for room in the queue, no hook call is lost.
+	HookOverflowBlock HookOverflow = iota
+	// HookOverflowDropOldest drops the oldest queued hook call, counted in DroppedHooks.
+	HookOverflowDropOldest
+	// HookOverflowDropNewest drops the hook call being queued, counted in DroppedHooks.
+	HookOverflowDropNewest
+)
+
+func (o HookOverflow) String() string {
+	switch o {
+	case HookOverflowBlock:
+		return "block"
+	case HookOverflowDropOldest:
+		return "drop-oldest"
+	case HookOverflowDropNewest:
+		return "drop-newest"
+	}
+	return fmt.Sprintf("HookOverflow(%d)", int(o))
+}
+
+// DefaultHookQueueSize is the size of the hook queue if WorkflowHookQueue is not set.
+const DefaultHookQueueSize = 1024
+
+// WorkflowHookQueue sets the size of the hook queue and what to do when it's full.
+//
+// The hooks observing terminated Steps, e.g. the after hook of WorkflowStepHooks,
+// are called from a dedicated goroutine fed by the queue, in the order the Steps terminated,
+// so a slow hook doesn't delay the Steps nor the scheduling.
+// Run waits for the queued hook calls before returning, see FlushHooks.
+//
+// The default is DefaultHookQueueSize with HookOverflowBlock.
+func WorkflowHookQueue(size int, overflow HookOverflow) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("hook queue", fmt.Sprintf("%d/%s", max(size, 1), overflow))
+		s.hookQueueSize = max(size, 1)
+		s.hookOverflow = overflow
+	}
+}
+
+// FlushHooks waits until all hook calls queued so far are delivered, or ctx is done.
+// It returns nil immediately if the Workflow is not running.
+func (s *Workflow) FlushHooks(ctx context.Context) error {
+	if d := s.hooks.Load(); d != nil {
+		return d.flush(ctx)
+	}
+	return nil
+}
+
+// DroppedHooks returns the number of hook calls dropped by the overflow policy in the current or last run.
+func (s *Workflow) DroppedHooks() uint64 {
+	if d := s.hooks.Load(); d != nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.dropped
+	}
+	return 0
+}
+
+// startHooks starts the hook dispatcher of a run.
+func (s *Workflow) startHooks() {
+	size := s.hookQueueSize
+	if size == 0 {
+		size = DefaultHookQueueSize
+	}
+	d := &hookDispatcher{size: size, overflow: s.hookOverflow, done: make(chan struct{})}
+	d.cond = sync.NewCond(&d.mu)
+	s.hooks.Store(d)
+	go d.loop()
+}
+
+// stopHooks delivers all queued hook calls, then stops the dispatcher.
+func (s *Workflow) stopHooks() {
+	if d := s.hooks.Load(); d != nil {
+		d.close()
+	}
+}
+
+// dispatchHook queues a hook call to the dispatcher,
+// or calls it directly if the dispatcher is absent.
+func (s *Workflow) dispatchHook(fn func()) {
+	if d := s.hooks.Load(); d != nil {
+		d.dispatch(fn)
+		return
+	}
+	fn()
+}
+
+// hookDispatcher calls the queued hooks one by one in a dedicated goroutine.
+type hookDispatcher struct {
+	mu       sync.Mutex
+	cond     *sync.Cond // signals on any change of queue, busy and closed
+	queue    []func()
+	size     int
+	overflow HookOverflow
+	dropped  uint64
+	busy     bool // whether a hook is being called
+	closed   bool
+	done     chan struct{} // closed when loop returns
+}
+
+func (d *hookDispatcher) dispatch(fn func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for len(d.queue) >= d.size {
+		switch d.overflow {
+		case HookOverflowDropOldest:
+			d.queue[0] = nil
+			d.queue = d.queue[1:]
+			d.dropped++
+		case HookOverflowDropNewest:
+			d.dropped++
+			return
+		default:
+			d.cond.Wait()
+		}
+	}
+	d.queue = append(d.queue, fn)
+	d.cond.Broadcast()
+}
+
+// drop counts a hook call dropped by the overflow policy.
+func (d *hookDispatcher) drop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dropped++
+}
+
+func (d *hookDispatcher) loop() {
+	defer close(d.done)
+	d.mu.Lock()
+	for {
+		for len(d.queue) == 0 && !d.closed {
+			d.cond.Wait()
+		}
+		if len(d.queue) == 0 { // closed and drained
+			d.mu.Unlock()
+			return
+		}
+		fn := d.queue[0]
+		d.queue[0] = nil
+		d.queue = d.queue[1:]
+		d.busy = true
+		d.cond.Broadcast()
+		d.mu.Unlock()
+		// a panic in hook can only be dropped, since the Step has terminated
+		_ = catchPanicAsError(func() error {
+			fn()
+			return nil
+		})
+		d.mu.Lock()
+		d.busy = false
+		d.cond.Broadcast()
+	}
+}
+
+func (d *hookDispatcher) flush(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.cond.Broadcast()
+	})
+	defer stop()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for len(d.queue) > 0 || d.busy {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		d.cond.Wait()
+	}
+	return nil
+}
+
+func (d *hookDispatcher) close() {
+	d.mu.Lock()
+	d.closed = true
+	d.cond.Broadcast()
+	d.mu.Unlock()
+	<-d.done
+}
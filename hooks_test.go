@@ -0,0 +1,108 @@
+package pl_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+)
+
+func TestWorkflowHookQueueSlowHook(t *testing.T) {
+	const slow = 50 * time.Millisecond
+	var (
+		mu    sync.Mutex
+		order []pl.StepReader
+	)
+	a, b, c, d := succeed("a"), succeed("b"), succeed("c"), succeed("d")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowStepHooks(nil, func(_ context.Context, step pl.StepReader, _ error) {
+			time.Sleep(slow)
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, step)
+		})).
+		Add(
+			pl.Step(b).ExtraDependsOn(a),
+			pl.Step(c).ExtraDependsOn(b),
+			pl.Step(d).ExtraDependsOn(c),
+		)
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Run waits for the hooks
+	if want := []pl.StepReader{a, b, c, d}; !reflect.DeepEqual(order, want) {
+		t.Errorf("want hooks called in order %v, got %v", want, order)
+	}
+	// the chain doesn't wait for the slow hook of the previous Step
+	states := w.States()
+	first, last := states[0].StartedAt, states[0].FinishedAt
+	for _, state := range states {
+		if state.StartedAt.Before(first) {
+			first = state.StartedAt
+		}
+		if state.FinishedAt.After(last) {
+			last = state.FinishedAt
+		}
+	}
+	if elapsed := last.Sub(first); elapsed >= slow {
+		t.Errorf("want Steps unaffected by the slow hook, took %s", elapsed)
+	}
+}
+
+func TestWorkflowHookQueueOverflow(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		overflow pl.HookOverflow
+		want     []string
+	}{
+		{"drop newest", pl.HookOverflowDropNewest, []string{"a", "b"}},
+		{"drop oldest", pl.HookOverflowDropOldest, []string{"a", "d"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			entered, release := make(chan struct{}), make(chan struct{})
+			var called []string
+			a := succeed("a")
+			// b starts after the hook of a blocks the dispatcher
+			b := pl.FuncNoInOut("b", func(context.Context) error {
+				<-entered
+				return nil
+			})
+			c, d := succeed("c"), succeed("d")
+			w := new(pl.Workflow).
+				WithOptions(
+					pl.WorkflowHookQueue(1, tc.overflow),
+					pl.WorkflowStepHooks(nil, func(_ context.Context, step pl.StepReader, _ error) {
+						if step == a {
+							close(entered)
+							<-release
+						}
+						called = append(called, step.String())
+					}),
+				).
+				Add(
+					pl.Step(b).ExtraDependsOn(a),
+					pl.Step(c).ExtraDependsOn(b),
+					pl.Step(d).ExtraDependsOn(c),
+				)
+			done := make(chan error)
+			go func() { done <- w.Run(context.Background()) }()
+			// c and d overflow the queue while a's hook blocks
+			for w.DroppedHooks() < 2 {
+				time.Sleep(time.Millisecond)
+			}
+			close(release)
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(called, tc.want) {
+				t.Errorf("want hooks called %v, got %v", tc.want, called)
+			}
+			if got := w.DroppedHooks(); got != 2 {
+				t.Errorf("want 2 dropped hook calls, got %d", got)
+			}
+		})
+	}
+}
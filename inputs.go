@@ -0,0 +1,177 @@
+package pl
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// SetInputs sets the Input of Steps by their names (String()),
+// e.g. to seed the Inputs in CLI or test harnesses without holding the Steps.
+//
+// The value must be assignable to the Input type of the Step.
+// It returns error for unknown or ambiguous names, type mismatch, or Steps without Input,
+// and sets nothing in such case.
+//
+// SetInputs sets the Input before running, the data flowing from Dependees in run is applied after it.
+// It returns ErrWorkflowIsRunning if the Workflow is running.
+func (s *Workflow) SetInputs(inputs map[string]any) error {
+	if !s.isRunning.TryLock() {
+		return ErrWorkflowIsRunning
+	}
+	defer s.isRunning.Unlock()
+
+	byName := make(map[string][]StepDoer)
+	for _, step := range s.steps {
+		byName[step.String()] = append(byName[step.String()], step)
+	}
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	type assign struct{ in, v reflect.Value }
+	assigns := make([]assign, 0, len(names))
+	for _, name := range names {
+		steps := byName[name]
+		switch len(steps) {
+		case 0:
+			return fmt.Errorf("SetInputs: no Step named %q", name)
+		case 1:
+		default:
+			return fmt.Errorf("SetInputs: %d Steps named %q", len(steps), name)
+		}
+		in, ok := inputPtrOf(steps[0])
+		if !ok {
+			return fmt.Errorf("SetInputs: Step %q has no Input", name)
+		}
+		v := reflect.ValueOf(inputs[name])
+		if !v.IsValid() {
+			v = reflect.Zero(in.Elem().Type())
+		}
+		if !v.Type().AssignableTo(in.Elem().Type()) {
+			return fmt.Errorf("SetInputs: Step %q wants Input %s, got %s", name, in.Elem().Type(), v.Type())
+		}
+		assigns = append(assigns, assign{in, v})
+	}
+	for _, a := range assigns {
+		a.in.Elem().Set(a.v)
+	}
+	return nil
+}
+
+// InputMarshaler is implemented by Steps encoding their Input themselves in SnapshotInputs and RestoreInputs,
+// otherwise the Input is encoded as JSON.
+type InputMarshaler interface {
+	MarshalInput() ([]byte, error)
+	UnmarshalInput([]byte) error
+}
+
+// SnapshotInputs returns the current Input of all Steps with Input by their names (String()),
+// e.g. to restore them via RestoreInputs later for a parameter sweep.
+//
+// It returns error for ambiguous names or an Input failed to encode.
+// It returns ErrWorkflowIsRunning if the Workflow is running.
+func (s *Workflow) SnapshotInputs() (map[string][]byte, error) {
+	if !s.isRunning.TryLock() {
+		return nil, ErrWorkflowIsRunning
+	}
+	defer s.isRunning.Unlock()
+
+	snapshot := make(map[string][]byte)
+	for _, step := range s.steps {
+		in, ok := inputPtrOf(step)
+		if !ok {
+			continue
+		}
+		name := step.String()
+		if _, ok := snapshot[name]; ok {
+			return nil, fmt.Errorf("SnapshotInputs: more than one Step named %q", name)
+		}
+		var data []byte
+		var err error
+		if m, ok := step.(InputMarshaler); ok {
+			data, err = m.MarshalInput()
+		} else {
+			data, err = marshalIO(in.Interface())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("SnapshotInputs: Step %q: %w", name, err)
+		}
+		snapshot[name] = data
+	}
+	return snapshot, nil
+}
+
+// RestoreInputs sets the Input of Steps by their names (String()) from the snapshot of SnapshotInputs.
+//
+// Like SetInputs, it returns error for unknown or ambiguous names, Steps without Input,
+// or an Input failed to decode, and sets nothing in such case,
+// except the Steps implementing InputMarshaler, which decode into their Input directly.
+// It returns ErrWorkflowIsRunning if the Workflow is running.
+func (s *Workflow) RestoreInputs(snapshot map[string][]byte) error {
+	if !s.isRunning.TryLock() {
+		return ErrWorkflowIsRunning
+	}
+	defer s.isRunning.Unlock()
+
+	byName := make(map[string][]StepDoer)
+	for _, step := range s.steps {
+		byName[step.String()] = append(byName[step.String()], step)
+	}
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	type assign struct{ in, v reflect.Value }
+	assigns := make([]assign, 0, len(names))
+	var custom []func() error
+	for _, name := range names {
+		steps := byName[name]
+		switch len(steps) {
+		case 0:
+			return fmt.Errorf("RestoreInputs: no Step named %q", name)
+		case 1:
+		default:
+			return fmt.Errorf("RestoreInputs: %d Steps named %q", len(steps), name)
+		}
+		in, ok := inputPtrOf(steps[0])
+		if !ok {
+			return fmt.Errorf("RestoreInputs: Step %q has no Input", name)
+		}
+		data := snapshot[name]
+		if m, ok := steps[0].(InputMarshaler); ok {
+			custom = append(custom, func() error { return m.UnmarshalInput(data) })
+			continue
+		}
+		v := reflect.New(in.Elem().Type())
+		if err := json.Unmarshal(data, v.Interface()); err != nil {
+			return fmt.Errorf("RestoreInputs: Step %q: %w", name, err)
+		}
+		assigns = append(assigns, assign{in, v.Elem()})
+	}
+	for _, a := range assigns {
+		a.in.Elem().Set(a.v)
+	}
+	for _, unmarshal := range custom {
+		if err := unmarshal(); err != nil {
+			return fmt.Errorf("RestoreInputs: %w", err)
+		}
+	}
+	return nil
+}
+
+// inputPtrOf returns the `Input() *I` pointer of a Step.
+func inputPtrOf(step StepReader) (reflect.Value, bool) {
+	m := reflect.ValueOf(step).MethodByName("Input")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 || m.Type().Out(0).Kind() != reflect.Pointer {
+		return reflect.Value{}, false
+	}
+	in := m.Call(nil)[0]
+	if in.IsNil() {
+		return reflect.Value{}, false
+	}
+	return in, true
+}
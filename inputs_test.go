@@ -0,0 +1,98 @@
+package pl_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestWorkflowSetInputs(t *testing.T) {
+	var gotCount int
+	var gotName string
+	count := pl.FuncIn("count", func(_ context.Context, i int) error {
+		gotCount = i
+		return nil
+	})
+	name := pl.FuncIn("name", func(_ context.Context, i string) error {
+		gotName = i
+		return nil
+	})
+	w := new(pl.Workflow).Add(pl.Steps(count, name))
+
+	for _, bad := range []map[string]any{
+		{"unknown": 1},
+		{"count": "not int"},
+		{"count": 1, "name": 2}, // nothing is set if any fails
+	} {
+		if err := w.SetInputs(bad); err == nil {
+			t.Errorf("want error for %v", bad)
+		}
+	}
+	if *count.Input() != 0 {
+		t.Errorf("want Input untouched by failed SetInputs, got %d", *count.Input())
+	}
+
+	if err := w.SetInputs(map[string]any{"count": 3, "name": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if gotCount != 3 || gotName != "x" {
+		t.Errorf("want Steps run with seeded Inputs, got %d %q", gotCount, gotName)
+	}
+}
+
+// csvStep encodes its Input itself, see pl.InputMarshaler.
+type csvStep struct {
+	pl.StepBaseIn[[]string]
+}
+
+func (c *csvStep) String() string           { return "csv" }
+func (c *csvStep) Do(context.Context) error { return nil }
+
+func (c *csvStep) MarshalInput() ([]byte, error) { return []byte(strings.Join(c.In, ",")), nil }
+
+func (c *csvStep) UnmarshalInput(data []byte) error {
+	c.In = strings.Split(string(data), ",")
+	return nil
+}
+
+func TestWorkflowSnapshotInputs(t *testing.T) {
+	type params struct {
+		Rate  float64
+		Label string
+	}
+	sweep := pl.FuncIn("sweep", func(context.Context, params) error { return nil })
+	csv := new(csvStep)
+	w := new(pl.Workflow).Add(pl.Steps(sweep, csv, succeed("noInput")))
+	*sweep.Input() = params{Rate: 0.5, Label: "base"}
+	csv.In = []string{"a", "b"}
+
+	snapshot, err := w.SnapshotInputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(snapshot["csv"]); got != "a,b" {
+		t.Errorf("want csv Input encoded by itself, got %q", got)
+	}
+
+	*sweep.Input() = params{Rate: 0.9, Label: "changed"}
+	csv.In = []string{"c"}
+	if err := w.RestoreInputs(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := *sweep.Input(), (params{Rate: 0.5, Label: "base"}); got != want {
+		t.Errorf("want Input restored %+v, got %+v", want, got)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(csv.In, want) {
+		t.Errorf("want csv Input restored %v, got %v", want, csv.In)
+	}
+
+	if err := w.RestoreInputs(map[string][]byte{"sweep": []byte("not json")}); err == nil {
+		t.Error("want error for invalid snapshot")
+	}
+}
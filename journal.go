@@ -0,0 +1,269 @@
+package pl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Journal records the side effects of a Step for auditing,
+// Begin is called right before Do in each attempt, End is called right after Do, even Do panics.
+//
+// If Begin fails, the Step fails with ErrJournal without calling Do.
+type Journal interface {
+	Begin(ctx context.Context, step StepReader, attempt uint64) (token string, err error)
+	End(ctx context.Context, token string, err error)
+}
+
+// ErrJournal indicates the Step failed to Begin its Journal, Do is not called.
+type ErrJournal struct {
+	Err error
+}
+
+func (e *ErrJournal) Error() string {
+	return fmt.Sprintf("ErrJournal: %s", e.Err.Error())
+}
+
+func (e *ErrJournal) Unwrap() error {
+	return e.Err
+}
+
+// IndeterminateJournal is a Journal able to report the Steps having Begin without End,
+// e.g. the process crashed during Do.
+//
+// Indeterminate is called once per run for each IndeterminateJournal, before any Step begins,
+// so the Steps begun in the run are not reported.
+// Before the first attempt, a Step whose name is reported fails with ErrIndeterminate,
+// without calling Begin or Do, since its side effects may or may not have happened.
+type IndeterminateJournal interface {
+	Journal
+	Indeterminate() ([]string, error)
+}
+
+// ErrIndeterminate indicates the Step has Begin without End in its Journal from a previous run,
+// resolve it (e.g. FileJournal.Resolve) after checking the side effects to run the Step again.
+type ErrIndeterminate struct {
+	Step string
+}
+
+func (e *ErrIndeterminate) Error() string {
+	return fmt.Sprintf("ErrIndeterminate: Step %s has begun without end in Journal", e.Step)
+}
+
+// indeterminateSteps is the names of Steps reported by an IndeterminateJournal.
+type indeterminateSteps struct {
+	names map[string]bool
+	err   error
+}
+
+// loadIndeterminate loads the indeterminate Steps of the IndeterminateJournals when the run starts,
+// each Journal is loaded once, however many Steps share it.
+func (s *Workflow) loadIndeterminate() {
+	s.indeterminate = make(map[StepDoer]indeterminateSteps)
+	loaded := make(map[IndeterminateJournal]indeterminateSteps)
+	for _, step := range s.steps {
+		ij, ok := step.getJournal().(IndeterminateJournal)
+		if !ok {
+			continue
+		}
+		comparable := reflect.TypeOf(ij).Comparable() // to be a map key
+		set, ok := indeterminateSteps{}, false
+		if comparable {
+			set, ok = loaded[ij]
+		}
+		if !ok {
+			names, err := ij.Indeterminate()
+			set = indeterminateSteps{names: make(map[string]bool, len(names)), err: err}
+			for _, name := range names {
+				set.names[name] = true
+			}
+			if comparable {
+				loaded[ij] = set
+			}
+		}
+		s.indeterminate[step] = set
+	}
+}
+
+// checkIndeterminate returns ErrIndeterminate if the Step is left indeterminate in its Journal by a previous run.
+func (s *Workflow) checkIndeterminate(step StepDoer) error {
+	set, ok := s.indeterminate[step]
+	switch {
+	case !ok:
+		return nil
+	case set.err != nil:
+		return &ErrJournal{Err: set.err}
+	case set.names[step.String()]:
+		return &ErrIndeterminate{Step: step.String()}
+	}
+	return nil
+}
+
+// journaled calls do between the Journal's Begin and End.
+func journaled(ctx context.Context, j Journal, step StepReader, attempt uint64, do func(context.Context) error) error {
+	if j == nil {
+		return do(ctx)
+	}
+	token, err := j.Begin(ctx, step, attempt)
+	if err != nil {
+		return &ErrJournal{Err: err}
+	}
+	err = catchPanicAsError(func() error {
+		return do(ctx)
+	})
+	j.End(ctx, token, err)
+	return err
+}
+
+// FileJournal is a Journal appending JSON lines to a file.
+//
+// A Begin entry without End entry means the process crashed during Do,
+// such Steps fail with ErrIndeterminate in the next run (see IndeterminateJournal),
+// until they are resolved via Resolve.
+type FileJournal struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewFileJournal creates a FileJournal writing to path.
+func NewFileJournal(path string) *FileJournal {
+	return &FileJournal{Path: path}
+}
+
+// JournalEntry is one line in FileJournal.
+type JournalEntry struct {
+	Token   string    `json:"token"`
+	Event   string    `json:"event"` // begin | end
+	Step    string    `json:"step,omitempty"`
+	Attempt uint64    `json:"attempt,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+func (j *FileJournal) Begin(ctx context.Context, step StepReader, attempt uint64) (string, error) {
+	now := time.Now()
+	token := fmt.Sprintf("%s#%d@%d", step, attempt, now.UnixNano())
+	return token, j.append(JournalEntry{
+		Token:   token,
+		Event:   "begin",
+		Step:    step.String(),
+		Attempt: attempt,
+		Time:    now,
+	})
+}
+
+func (j *FileJournal) End(ctx context.Context, token string, err error) {
+	entry := JournalEntry{
+		Token: token,
+		Event: "end",
+		Time:  time.Now(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	_ = j.append(entry) // End has no way to report error
+}
+
+func (j *FileJournal) append(entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f, err := os.OpenFile(j.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	// sync to survive crashes
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Indeterminate returns the names of Steps having Begin entries without End,
+// their side effects may or may not have happened, so they should not be blindly re-run.
+func (j *FileJournal) Indeterminate() ([]string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	begun, err := j.begun()
+	if err != nil {
+		return nil, err
+	}
+	steps := []string{}
+	seen := map[string]bool{}
+	for _, step := range begun {
+		if !seen[step] {
+			seen[step] = true
+			steps = append(steps, step)
+		}
+	}
+	sort.Strings(steps)
+	return steps, nil
+}
+
+// Resolve appends End entries for the Begin entries without End of the Step,
+// call it after the side effects are checked, then the Step can run again.
+func (j *FileJournal) Resolve(step string) error {
+	j.mu.Lock()
+	begun, err := j.begun()
+	j.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tokens := []string{}
+	for token, name := range begun {
+		if name == step {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Strings(tokens)
+	for _, token := range tokens {
+		if err := j.append(JournalEntry{Token: token, Event: "end", Error: "resolved", Time: time.Now()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// begun returns the tokens of Begin entries without End, mapping to the Step names.
+// Caller should hold j.mu.
+func (j *FileJournal) begun() (map[string]string, error) {
+	f, err := os.Open(j.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	begun := map[string]string{} // token -> step
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // skip the partial line written when crashed
+		}
+		switch entry.Event {
+		case "begin":
+			begun[entry.Token] = entry.Step
+		case "end":
+			delete(begun, entry.Token)
+		}
+	}
+	return begun, scanner.Err()
+}
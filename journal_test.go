@@ -0,0 +1,141 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+)
+
+func TestFileJournal(t *testing.T) {
+	j := pl.NewFileJournal(filepath.Join(t.TempDir(), "journal"))
+	ok := succeed("ok")
+	panicking := pl.FuncNoInOut("panicking", func(context.Context) error { panic("boom") })
+	w := new(pl.Workflow).Add(
+		pl.Step(ok).Journal(j),
+		pl.Step(panicking).Journal(j),
+	)
+	_ = w.Run(context.Background())
+
+	steps, err := j.Indeterminate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("want End recorded even on panic, got indeterminate %v", steps)
+	}
+
+	// simulate a crash during Do
+	if _, err := j.Begin(context.Background(), ok, 1); err != nil {
+		t.Fatal(err)
+	}
+	steps, err = j.Indeterminate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ok"}; !reflect.DeepEqual(steps, want) {
+		t.Errorf("want indeterminate %v, got %v", want, steps)
+	}
+}
+
+type failingJournal struct{}
+
+func (failingJournal) Begin(context.Context, pl.StepReader, uint64) (string, error) {
+	return "", fmt.Errorf("disk full")
+}
+func (failingJournal) End(context.Context, string, error) {}
+
+func TestJournalBeginFailed(t *testing.T) {
+	called := false
+	step := pl.FuncNoInOut("step", func(context.Context) error {
+		called = true
+		return nil
+	})
+	w := new(pl.Workflow).Add(pl.Step(step).Journal(failingJournal{}))
+	_ = w.Run(context.Background())
+	var jerr *pl.ErrJournal
+	if !errors.As(w.Err()[step], &jerr) {
+		t.Errorf("want ErrJournal, got %v", w.Err()[step])
+	}
+	if called {
+		t.Error("want Do not called")
+	}
+}
+
+func TestJournalIndeterminate(t *testing.T) {
+	j := pl.NewFileJournal(filepath.Join(t.TempDir(), "journal"))
+	called := false
+	newStep := func() pl.Steper[struct{}, struct{}] {
+		return pl.FuncNoInOut("charge", func(context.Context) error {
+			called = true
+			return nil
+		})
+	}
+	// simulate a crash during Do in a previous run
+	if _, err := j.Begin(context.Background(), newStep(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	err := new(pl.Workflow).Add(pl.Step(newStep()).Journal(j)).Run(context.Background())
+	var ierr *pl.ErrIndeterminate
+	if !errors.As(err, &ierr) || ierr.Step != "charge" {
+		t.Errorf("want ErrIndeterminate, got %v", err)
+	}
+	if called {
+		t.Error("want Do of indeterminate Step not called")
+	}
+
+	if err := j.Resolve("charge"); err != nil {
+		t.Fatal(err)
+	}
+	if err := new(pl.Workflow).Add(pl.Step(newStep()).Journal(j)).Run(context.Background()); err != nil || !called {
+		t.Errorf("want resolved Step run, got %v", err)
+	}
+}
+
+// countingJournal counts the calls of Indeterminate.
+type countingJournal struct {
+	*pl.FileJournal
+	calls atomic.Int32
+}
+
+func (j *countingJournal) Indeterminate() ([]string, error) {
+	j.calls.Add(1)
+	return j.FileJournal.Indeterminate()
+}
+
+func TestJournalIndeterminateOncePerRun(t *testing.T) {
+	j := &countingJournal{FileJournal: pl.NewFileJournal(filepath.Join(t.TempDir(), "journal"))}
+	var entered atomic.Int32
+	bothEntered := make(chan struct{})
+	same := func() pl.Steper[struct{}, struct{}] {
+		return pl.FuncNoInOut("same", func(context.Context) error {
+			if entered.Add(1) == 2 {
+				close(bothEntered)
+			}
+			select {
+			case <-bothEntered: // both have begun in the Journal
+				return nil
+			case <-time.After(time.Second):
+				return errors.New("the other Step didn't start")
+			}
+		})
+	}
+	w := new(pl.Workflow).Add(
+		pl.Step(same()).Journal(j),
+		pl.Step(same()).Journal(j),
+		pl.Step(succeed("other")).Journal(j),
+	)
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("want Steps begun in this run not indeterminate, got %v", err)
+	}
+	if got := j.calls.Load(); got != 1 {
+		t.Errorf("want Indeterminate called once per run, got %d", got)
+	}
+}
@@ -0,0 +1,81 @@
+package pl
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"time"
+)
+
+// leakLabel is the pprof label of goroutines started by a Step, see WorkflowLeakCheck.
+const leakLabel = "pl.step"
+
+// GoroutineLeak is the goroutines outliving Run, see WorkflowLeakCheck.
+type GoroutineLeak struct {
+	Before, After int          // the number of goroutines before and after Run
+	Steps         []StepReader // the Steps that started the leaked goroutines, sorted by name
+}
+
+// WorkflowLeakCheck makes the Workflow check whether the number of goroutines grew after Run,
+// e.g. a Step starts a goroutine ignoring the context, check it via Leak.
+//
+// It's a debugging aid: the goroutines started by Steps are labeled with pprof labels,
+// to find the Steps that leaked; and Run waits a while for the exiting goroutines to settle.
+func WorkflowLeakCheck() WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("leak check", "true")
+		s.leakCheck = true
+	}
+}
+
+// Leak returns the goroutines leaked in the last run, nil if none or WorkflowLeakCheck is not set.
+func (s *Workflow) Leak() *GoroutineLeak {
+	return s.leak
+}
+
+// labelStep labels the goroutine running the Step,
+// goroutines started from it inherit the label.
+func (s *Workflow) labelStep(ctx context.Context, step StepDoer) context.Context {
+	if !s.leakCheck {
+		return ctx
+	}
+	ctx = pprof.WithLabels(ctx, pprof.Labels(leakLabel, step.String()))
+	pprof.SetGoroutineLabels(ctx)
+	return ctx
+}
+
+// leakSettle is how long checkLeak waits for the exiting goroutines.
+const leakSettle = 100 * time.Millisecond
+
+var leakLabelRegexp = regexp.MustCompile(`"` + regexp.QuoteMeta(leakLabel) + `":("(?:[^"\\]|\\.)*")`)
+
+// checkLeak records a GoroutineLeak if the number of goroutines grew since before.
+func (s *Workflow) checkLeak(before int) {
+	s.leak = nil
+	after := runtime.NumGoroutine()
+	for deadline := time.Now().Add(leakSettle); after > before && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+		after = runtime.NumGoroutine()
+	}
+	if after <= before {
+		return
+	}
+	leak := &GoroutineLeak{Before: before, After: after}
+	var profile bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&profile, 1)
+	names := map[string]bool{}
+	for _, m := range leakLabelRegexp.FindAllSubmatch(profile.Bytes(), -1) {
+		if name, err := strconv.Unquote(string(m[1])); err == nil {
+			names[name] = true
+		}
+	}
+	for _, step := range s.sortedSteps() {
+		if names[step.String()] {
+			leak.Steps = append(leak.Steps, step)
+		}
+	}
+	s.leak = leak
+}
@@ -0,0 +1,46 @@
+package pl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestWorkflowLeakCheck(t *testing.T) {
+	release, exited := make(chan struct{}), make(chan struct{})
+	defer func() {
+		close(release)
+		<-exited
+	}()
+	leaky := pl.FuncNoInOut("leaky", func(context.Context) error {
+		go func() { // ignores the context
+			<-release
+			close(exited)
+		}()
+		return nil
+	})
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowLeakCheck()).
+		Add(pl.Steps(leaky, succeed("clean")))
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	leak := w.Leak()
+	if leak == nil || leak.After <= leak.Before {
+		t.Fatalf("want leak reported, got %+v", leak)
+	}
+	if len(leak.Steps) != 1 || leak.Steps[0] != leaky {
+		t.Errorf("want only leaky reported, got %v", leak.Steps)
+	}
+
+	clean := new(pl.Workflow).
+		WithOptions(pl.WorkflowLeakCheck()).
+		Add(pl.Steps(succeed("clean")))
+	if err := clean.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if leak := clean.Leak(); leak != nil {
+		t.Errorf("want no leak, got %+v", leak)
+	}
+}
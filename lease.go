@@ -0,0 +1,85 @@
+package pl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// lease is a Step's slot in the Workflow's leaseBucket, see WorkflowMaxConcurrency,
+// and in the buckets of its concurrency groups, see WorkflowConcurrencyGroup.
+//
+// A Step holds its lease while running,
+// and releases it while sleeping between retry attempts,
+// so sleeping Steps don't occupy concurrency slots.
+//
+// The buckets are always acquired in the same order (the global one, then groups by name),
+// so Steps in multiple groups don't deadlock each other.
+type lease struct {
+	buckets []chan struct{} // empty means no limit
+	held    bool
+}
+
+// acquire blocks until a slot in every bucket is available, ctx is done, or stop is closed.
+func (l *lease) acquire(ctx context.Context, stop <-chan struct{}) error {
+	if l.held {
+		return nil
+	}
+	for i, bucket := range l.buckets {
+		select {
+		case bucket <- struct{}{}:
+		case <-ctx.Done():
+			l.drain(i)
+			return ctx.Err()
+		case <-stop:
+			l.drain(i)
+			return errStopped
+		}
+	}
+	l.held = true
+	return nil
+}
+
+var errStopped = errors.New("Workflow stopped")
+
+// errLeaseWait is returned by a Step interrupted while waiting for a lease between retry attempts,
+// because the Workflow stopped, the Step is Canceled with the cause instead of Failed.
+type errLeaseWait struct {
+	cause error
+}
+
+func (e errLeaseWait) Error() string {
+	return fmt.Sprintf("canceled while waiting for a lease: %s", e.cause)
+}
+
+// tryAcquire acquires a slot in every bucket without blocking,
+// returns the first bucket without slot, or nil if the lease is held.
+func (l *lease) tryAcquire() chan struct{} {
+	if l.held {
+		return nil
+	}
+	for i, bucket := range l.buckets {
+		select {
+		case bucket <- struct{}{}:
+		default:
+			l.drain(i)
+			return bucket
+		}
+	}
+	l.held = true
+	return nil
+}
+
+func (l *lease) release() {
+	if l.held {
+		l.drain(len(l.buckets))
+		l.held = false
+	}
+}
+
+// drain frees the slots of the first n buckets, in reverse order.
+func (l *lease) drain(n int) {
+	for i := n - 1; i >= 0; i-- {
+		<-l.buckets[i]
+	}
+}
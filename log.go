@@ -0,0 +1,144 @@
+package pl
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WorkflowLogger sets the logger of the Workflow,
+// each Step gets a logger derived from it with the "step" attribute, see Logger.
+//
+// The Workflow logs the transitions of Steps at the moment they happen:
+// Running, and terminated as Succeeded, Failed (at error level), Canceled or Skipped,
+// with the attributes status, duration, attempts and error.
+//
+// A nil logger means no logging, unless the Workflow runs in a Step of another Workflow, e.g. Stage,
+// then it inherits the logger of that Workflow.
+func WorkflowLogger(l *slog.Logger) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("logger", summarizeSet(l != nil))
+		s.logger = l
+	}
+}
+
+// StepLogger receives the transitions of Steps, see WorkflowStepLogger.
+type StepLogger interface {
+	// StepStart is called when the Step starts Running.
+	StepStart(step StepReader)
+	// StepEnd is called when the Step terminated, including the Steps Skipped or Canceled without starting,
+	// with the terminated status and the error recorded for the Step.
+	StepEnd(step StepReader, status StepStatus, err error)
+}
+
+// WorkflowStepLogger sets a StepLogger to log the Steps without logging in each Do,
+// it's called from the hook queue in the order of transitions, see WorkflowHookQueue.
+//
+// It's an alternative of WorkflowLogger for the loggers other than slog.
+func WorkflowStepLogger(l StepLogger) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("step logger", summarizeSet(l != nil))
+		s.stepLogHook = l
+	}
+}
+
+type loggerKey struct{}
+
+// Logger returns the logger of the Step, with the "step" attribute and the level set by LogLevel,
+// the context passed to Step's Do and Input functions carries it.
+//
+// The Workflow logs its own records about the Step with it as well, e.g. the failed attempts.
+// It returns a logger discarding all records if the context is not from a running Workflow,
+// or the Workflow has no logger, see WorkflowLogger.
+func Logger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return discardLogger
+}
+
+var discardLogger = slog.New(discardHandler{})
+
+// stepLogger derives the logger of a Step from the Workflow logger.
+func (s *Workflow) stepLogger(step StepDoer) *slog.Logger {
+	if s.runLogger == nil {
+		return discardLogger
+	}
+	l := s.runLogger
+	if level := step.getLogLevel(); level != nil {
+		l = slog.New(&levelHandler{level: *level, Handler: l.Handler()})
+	}
+	return l.With("step", step.String())
+}
+
+// inheritLogger decides the logger of a run,
+// a Workflow without logger inherits the one of the Workflow running it, e.g. Stage.
+func (s *Workflow) inheritLogger(ctx context.Context) {
+	s.runLogger = s.logger
+	if s.runLogger == nil {
+		if parent := workflowFromContext(ctx); parent != nil && parent != s {
+			s.runLogger = parent.runLogger
+		}
+	}
+}
+
+// logTransition logs the Step transitioned to status.
+func (s *Workflow) logTransition(ctx context.Context, step StepDoer, status StepStatus, err error) {
+	if l := s.stepLogHook; l != nil {
+		if status == StepStatusRunning {
+			s.dispatchHook(func() { l.StepStart(step) })
+		} else {
+			s.dispatchHook(func() { l.StepEnd(step, status, err) })
+		}
+	}
+	if s.runLogger == nil {
+		return
+	}
+	level := slog.LevelInfo
+	if status == StepStatusFailed {
+		level = slog.LevelError
+	}
+	logger := s.stepLogger(step)
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{slog.String("status", status.String())}
+	s.errsMu.RLock()
+	if r, ok := s.records[step]; ok {
+		if !r.StartedAt.IsZero() && !r.FinishedAt.IsZero() {
+			attrs = append(attrs, slog.Duration("duration", r.FinishedAt.Sub(r.StartedAt)))
+		}
+		if r.Attempts > 0 {
+			attrs = append(attrs, slog.Uint64("attempts", r.Attempts))
+		}
+	}
+	s.errsMu.RUnlock()
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	logger.LogAttrs(ctx, level, "Step "+status.String(), attrs...)
+}
+
+// levelHandler replaces the level of its Handler, so it can be lower or higher.
+type levelHandler struct {
+	level slog.Level
+	slog.Handler
+}
+
+func (h *levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, Handler: h.Handler.WithGroup(name)}
+}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,177 @@
+package pl_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/xuxife/pl"
+)
+
+// recordHandler records the messages and levels by the "step" attribute.
+type recordHandler struct {
+	mu      *sync.Mutex
+	level   slog.Level
+	step    string
+	records map[string][]string // step -> "LEVEL message"
+	attrs   map[string][]string // step -> attribute keys of the last record
+}
+
+func newRecordHandler(level slog.Level) *recordHandler {
+	return &recordHandler{mu: new(sync.Mutex), level: level, records: map[string][]string{}, attrs: map[string][]string{}}
+}
+
+func (h *recordHandler) Enabled(_ context.Context, level slog.Level) bool { return level >= h.level }
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records[h.step] = append(h.records[h.step], fmt.Sprintf("%s %s", r.Level, r.Message))
+	var keys []string
+	r.Attrs(func(a slog.Attr) bool {
+		keys = append(keys, a.Key)
+		return true
+	})
+	h.attrs[h.step] = keys
+	return nil
+}
+
+func (h *recordHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	for _, a := range attrs {
+		if a.Key == "step" {
+			c.step = a.Value.String()
+		}
+	}
+	return &c
+}
+
+func (h *recordHandler) WithGroup(string) slog.Handler { return h }
+
+func (h *recordHandler) of(step string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.records[step]...)
+}
+
+func TestStepLogLevel(t *testing.T) {
+	h := newRecordHandler(slog.LevelInfo)
+	logs := func(ctx context.Context) {
+		pl.Logger(ctx).Debug("debug")
+		pl.Logger(ctx).Info("info")
+	}
+	noisy := pl.FuncNoInOut("noisy", func(ctx context.Context) error { logs(ctx); return nil })
+	debugged := pl.FuncNoInOut("debugged", func(ctx context.Context) error { logs(ctx); return nil })
+	normal := pl.FuncNoInOut("normal", func(ctx context.Context) error { logs(ctx); return nil })
+	newQuiet := func() pl.Steper[struct{}, struct{}] {
+		attempts := 0
+		return pl.FuncNoInOut("quiet", func(ctx context.Context) error {
+			if attempts++; attempts == 1 {
+				return fmt.Errorf("retry")
+			}
+			return nil
+		})
+	}
+	retry := pl.RetryOption{Backoff: &backoff.ZeroBackOff{}, Attempts: 2}
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowLogger(slog.New(h))).
+		Add(
+			pl.Step(noisy).LogLevel(slog.LevelWarn),
+			pl.Step(debugged).LogLevel(slog.LevelDebug),
+			pl.Step(normal),
+			pl.Step(newQuiet()).LogLevel(slog.LevelError).Retry(retry),
+		)
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for step, want := range map[string]string{
+		"noisy":    "[]",
+		"debugged": "[INFO Step Running DEBUG debug INFO info INFO Step Succeeded]",
+		"normal":   "[INFO Step Running INFO info INFO Step Succeeded]",
+		"quiet":    "[]", // the Workflow's records follow the Step level too
+	} {
+		if got := fmt.Sprint(h.of(step)); got != want {
+			t.Errorf("want %s logs %s, got %s", step, want, got)
+		}
+	}
+
+	// without LogLevel, the failed attempt is logged as warning
+	w = new(pl.Workflow).
+		WithOptions(pl.WorkflowLogger(slog.New(h))).
+		Add(pl.Step(newQuiet()).Retry(retry))
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(h.of("quiet")), "[INFO Step Running WARN Step attempt failed, will retry INFO Step Succeeded]"; got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+
+	if pl.Logger(context.Background()).Enabled(context.Background(), slog.LevelError) {
+		t.Error("want discard logger outside a Workflow")
+	}
+}
+
+func TestWorkflowLoggerTransitions(t *testing.T) {
+	h := newRecordHandler(slog.LevelDebug)
+	failed, canceled, skipped := fail("failed"), succeed("canceled"), succeed("skipped")
+	inner := succeed("inner")
+	stage := &pl.Stage[struct{}, struct{}]{Name: "stage", Workflow: new(pl.Workflow).Add(pl.Step(inner))}
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowLogger(slog.New(h))).
+		Add(
+			pl.Step(canceled).ExtraDependsOn(failed),
+			pl.Step(skipped).When(pl.Skip),
+			pl.Step(stage),
+		)
+	_ = w.Run(context.Background())
+	for step, want := range map[string]string{
+		"failed":   "[INFO Step Running ERROR Step Failed]",
+		"canceled": "[INFO Step Canceled]",
+		"skipped":  "[INFO Step Skipped]",
+		"inner":    "[INFO Step Running INFO Step Succeeded]", // inherited by the Stage
+	} {
+		if got := fmt.Sprint(h.of(step)); got != want {
+			t.Errorf("want %s logs %s, got %s", step, want, got)
+		}
+	}
+	if got, want := fmt.Sprint(h.attrs["failed"]), "[status duration attempts error]"; got != want {
+		t.Errorf("want attributes %s, got %s", want, got)
+	}
+}
+
+// lineLogger records the StepLogger calls as lines, called from the hook queue one by one.
+type lineLogger []string
+
+func (l *lineLogger) StepStart(step pl.StepReader) {
+	*l = append(*l, step.String()+" started")
+}
+
+func (l *lineLogger) StepEnd(step pl.StepReader, status pl.StepStatus, err error) {
+	*l = append(*l, fmt.Sprintf("%s %s: %v", step, status, err))
+}
+
+func TestWorkflowStepLogger(t *testing.T) {
+	var lines lineLogger
+	a, b, c := fail("a"), succeed("b"), succeed("c")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowStepLogger(&lines)).
+		Add(
+			pl.Step(b).ExtraDependsOn(a),
+			pl.Step(c).ExtraDependsOn(b).Condition(pl.Always),
+		)
+	_ = w.Run(context.Background())
+	want := lineLogger{
+		"a started",
+		"a Failed: a failed",
+		"b Canceled: <nil>",
+		"c started",
+		"c Succeeded: <nil>",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("want lines %q, got %q", want, lines)
+	}
+}
@@ -0,0 +1,83 @@
+package pl
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// mermaidClassDefs is the legend mapping StepStatus to Mermaid classDef styles.
+var mermaidClassDefs = []struct {
+	Status StepStatus
+	Style  string
+}{
+	{StepStatusPending, "fill:#ffffff,stroke:#999999"},
+	{StepStatusRunning, "fill:#cce5ff,stroke:#0066cc"},
+	{StepStatusSucceeded, "fill:#d4edda,stroke:#28a745"},
+	{StepStatusFailed, "fill:#f8d7da,stroke:#dc3545"},
+	{StepStatusCanceled, "fill:#e2e3e5,stroke:#6c757d"},
+	{StepStatusSkipped, "fill:#fff3cd,stroke:#ffc107,stroke-dasharray:5 5"},
+}
+
+// Mermaid returns the Workflow as a Mermaid `flowchart TD` graph,
+// each Step is a node labeled with String(), each dependency is an edge `Dependee --> Depender`.
+//
+// Node ids are sanitized from String() and disambiguated by suffix for duplicated names.
+// Edges with data flow (DependsOn, DirectDependsOn) are solid `-->`,
+// edges without data flow (ExtraDependsOn) are dotted `-.->`.
+// Nodes are styled by their current StepStatus,
+// so Mermaid can be called before, during and after Run.
+func (s *Workflow) Mermaid() string {
+	steps := s.sortedSteps()
+	ids := make(map[StepDoer]string, len(steps))
+	used := map[string]bool{}
+	for _, step := range steps {
+		id := mermaidID(step.String())
+		for i := 2; used[id]; i++ {
+			id = fmt.Sprintf("%s_%d", mermaidID(step.String()), i)
+		}
+		used[id] = true
+		ids[step] = id
+	}
+
+	builder := new(strings.Builder)
+	builder.WriteString("flowchart TD\n")
+	for _, def := range mermaidClassDefs {
+		builder.WriteString(fmt.Sprintf("\tclassDef %s %s\n", mermaidClass(def.Status), def.Style))
+	}
+	for _, step := range steps {
+		builder.WriteString(fmt.Sprintf("\t%s[\"%s\"]:::%s\n",
+			ids[step], mermaidLabel(step.String()), mermaidClass(step.GetStatus()),
+		))
+	}
+	for _, e := range s.deps.edges(steps) {
+		arrow := "-->"
+		if !e.Data {
+			arrow = "-.->"
+		}
+		builder.WriteString(fmt.Sprintf("\t%s %s %s\n", ids[e.Dependee], arrow, ids[e.Depender]))
+	}
+	return builder.String()
+}
+
+// mermaidID sanitizes a Step name into a valid Mermaid node id.
+func mermaidID(name string) string {
+	id := []rune{}
+	for _, r := range name {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			id = append(id, r)
+		} else {
+			id = append(id, '_')
+		}
+	}
+	// prefix to avoid empty id and mermaid keywords like "end"
+	return "step_" + string(id)
+}
+
+func mermaidClass(status StepStatus) string {
+	return strings.ToLower(status.String())
+}
+
+func mermaidLabel(label string) string {
+	return strings.ReplaceAll(label, `"`, "#quot;")
+}
@@ -0,0 +1,91 @@
+package pl_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestMermaid(t *testing.T) {
+	a, b := succeed("a"), fail("b")
+	w := new(pl.Workflow).Add(pl.Step(b).ExtraDependsOn(a))
+
+	before := w.Mermaid()
+	if !strings.HasPrefix(before, "flowchart TD\n") {
+		t.Errorf("want flowchart TD graph, got:\n%s", before)
+	}
+	if strings.Count(before, ":::pending") != 2 || strings.Count(before, " -.-> ") != 1 {
+		t.Errorf("want 2 pending nodes and 1 edge, got:\n%s", before)
+	}
+
+	_ = w.Run(context.Background())
+	after := w.Mermaid()
+	if !strings.Contains(after, `step_a["a"]:::succeeded`) || !strings.Contains(after, `step_b["b"]:::failed`) {
+		t.Errorf("want nodes styled by status, got:\n%s", after)
+	}
+}
+
+func TestMermaidDiamond(t *testing.T) {
+	out := func(name string) pl.Steper[struct{}, struct{}] {
+		return pl.Func(name, func(context.Context, struct{}) (func(*struct{}), error) { return nil, nil })
+	}
+	a, b, c, d := out("a"), out("b"), out("c"), out("d")
+	w := new(pl.Workflow).Add(
+		pl.Step(b).DirectDependsOn(a),
+		pl.Step(c).DirectDependsOn(a),
+		pl.Step(d).DirectDependsOn(b).ExtraDependsOn(c),
+	)
+	got := w.Mermaid()
+	for _, want := range []string{
+		"step_a --> step_b",
+		"step_a --> step_c",
+		"step_b --> step_d",
+		"step_c -.-> step_d",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestMermaidSanitize(t *testing.T) {
+	w := new(pl.Workflow).Add(
+		pl.Step(succeed("Create (resource group)")),
+		pl.Step(succeed("Create (resource group)")),
+	)
+	got := w.Mermaid()
+	for _, want := range []string{
+		`step_Create__resource_group_["Create (resource group)"]`,
+		`step_Create__resource_group__2["Create (resource group)"]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestGraphDuplicatedNamesStable(t *testing.T) {
+	var mermaid, dot string
+	for i := 0; i < 20; i++ {
+		first, second, y := succeed("x"), succeed("x"), succeed("y")
+		w := new(pl.Workflow).Add(
+			pl.Step(first),
+			pl.Step(second).ExtraDependsOn(y),
+		)
+		if i == 0 {
+			mermaid, dot = w.Mermaid(), w.DOT()
+			if !strings.Contains(mermaid, "step_y -.-> step_x_2") {
+				t.Fatalf("want the second added x suffixed, got:\n%s", mermaid)
+			}
+			continue
+		}
+		if got := w.Mermaid(); got != mermaid {
+			t.Fatalf("want stable Mermaid, got:\n%s\nthen:\n%s", mermaid, got)
+		}
+		if got := w.DOT(); got != dot {
+			t.Fatalf("want stable DOT, got:\n%s\nthen:\n%s", dot, got)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package pl
+
+// startNotBefore wakes the scheduler when the Steps reach their NotBefore in this run,
+// returns the function to stop the timers.
+func (s *Workflow) startNotBefore() (stop func()) {
+	now, wake := s.clock().Now(), s.wake
+	var timers []Timer
+	for _, step := range s.steps {
+		if t := step.getNotBefore(); t.After(now) && !step.GetStatus().IsTerminated() {
+			timers = append(timers, s.clock().AfterFunc(t.Sub(now), func() { wakeUp(wake) }))
+		}
+	}
+	return func() {
+		for _, t := range timers {
+			t.Stop()
+		}
+	}
+}
+
+// tooEarly returns whether the Step is waiting for its NotBefore.
+func (s *Workflow) tooEarly(step StepDoer) bool {
+	t := step.getNotBefore()
+	return !t.IsZero() && s.clock().Now().Before(t)
+}
@@ -0,0 +1,65 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+	"github.com/xuxife/pl/pltest"
+)
+
+func TestStepNotBefore(t *testing.T) {
+	start := time.Date(2024, 1, 1, 1, 59, 0, 0, time.UTC)
+	at := start.Add(time.Minute)
+	clock := pltest.NewClock(start)
+	a, scheduled, b := succeed("a"), succeed("scheduled"), succeed("b")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowClock(clock)).
+		Add(
+			pl.Step(scheduled).ExtraDependsOn(a).NotBefore(at),
+			pl.Step(b).ExtraDependsOn(a),
+		)
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	clock.BlockUntilTimers(1)
+	for deadline := time.Now().Add(time.Second); b.GetStatus() != pl.StepStatusSucceeded; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("want b Succeeded, got %s", b.GetStatus())
+		}
+	}
+	if got := scheduled.GetStatus(); got != pl.StepStatusPending {
+		t.Errorf("want scheduled Pending before NotBefore, got %s", got)
+	}
+
+	clock.Advance(time.Minute)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := scheduled.(pl.StepTimer).GetTimes(); s.Before(at) {
+		t.Errorf("want scheduled started after %s, got %s", at, s)
+	}
+}
+
+func TestStepNotBeforeCanceled(t *testing.T) {
+	clock := pltest.NewClock(time.Unix(0, 0))
+	scheduled := succeed("scheduled")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowClock(clock)).
+		Add(pl.Step(scheduled).NotBefore(time.Unix(3600, 0)))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	clock.BlockUntilTimers(1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+	if got := scheduled.GetStatus(); got != pl.StepStatusCanceled {
+		t.Errorf("want scheduled Canceled, got %s", got)
+	}
+	if got := clock.Timers(); got != 0 {
+		t.Errorf("want the timers stopped after Run, got %d", got)
+	}
+}
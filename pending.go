@@ -0,0 +1,59 @@
+package pl
+
+// PendingReason is why a Pending Step is not running yet, see Workflow.PendingSummary.
+type PendingReason string
+
+const (
+	PendingOnDependees PendingReason = "waiting-on-dependees"  // some Dependees have not terminated
+	PendingForLease    PendingReason = "waiting-for-lease"     // ready, but all slots are occupied, see WorkflowMaxConcurrency
+	PendingAdmission   PendingReason = "deferred-by-admission" // ready, but denied by WorkflowAdmissionControl
+	PendingPaused      PendingReason = "paused"                // held by a barrier until Continue, see WorkflowBarrier
+	PendingReady       PendingReason = "ready"                 // ready, but not yet scheduled
+)
+
+// PendingStep is a Pending Step with why it's not running yet, see Workflow.PendingSummary.
+type PendingStep struct {
+	Step   StepReader    `json:"-"`
+	Name   string        `json:"name"`
+	Reason PendingReason `json:"reason"`
+	// Dependees are the names of the Dependees not terminated, if Reason is PendingOnDependees.
+	Dependees []string `json:"dependees,omitempty"`
+}
+
+// PendingSummary returns the Pending Steps and why each is not running yet, in the order of being added,
+// e.g. to answer "what's left and why isn't it running" in the middle of a run.
+//
+// The reason is the one recorded when the scheduler last passed over the Step,
+// kept until the Step is visited again, except waiting-on-dependees which is always up to date.
+// It's safe to call while the Workflow is running.
+func (s *Workflow) PendingSummary() []PendingStep {
+	var pending []PendingStep
+	for _, step := range s.steps {
+		if step.GetStatus() != StepStatusPending {
+			continue
+		}
+		p := PendingStep{Step: step, Name: step.String(), Reason: PendingReady}
+		for _, e := range s.deps.listUpstreamReporterOf(step) {
+			if !e.GetStatus().IsTerminated() {
+				p.Reason = PendingOnDependees
+				p.Dependees = append(p.Dependees, e.String())
+			}
+		}
+		if p.Reason == PendingReady {
+			s.errsMu.RLock()
+			if r, ok := s.records[step]; ok && r.pending != "" {
+				p.Reason = r.pending
+			}
+			s.errsMu.RUnlock()
+		}
+		pending = append(pending, p)
+	}
+	return pending
+}
+
+// recordPending records why the scheduler passed over a Pending Step, see PendingSummary.
+func (s *Workflow) recordPending(step StepDoer, reason PendingReason) {
+	s.errsMu.Lock()
+	defer s.errsMu.Unlock()
+	s.recordOf(step).pending = reason
+}
@@ -0,0 +1,66 @@
+package pl_test
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+)
+
+func TestWorkflowPendingSummary(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var released atomic.Bool
+	denied, queued, dependent := succeed("denied"), succeed("queued"), succeed("dependent")
+	blocker := pl.FuncNoInOut("blocker", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	w := new(pl.Workflow).
+		WithOptions(
+			pl.WorkflowMaxConcurrency(1),
+			pl.WorkflowAdmissionControl(func(_ context.Context, step pl.StepReader) bool {
+				return step != denied || released.Load()
+			}),
+		).
+		Add(
+			pl.Step(denied),
+			pl.Step(blocker),
+			pl.Step(queued),
+			pl.Step(dependent).ExtraDependsOn(blocker),
+		)
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	<-started
+
+	want := []pl.PendingStep{
+		{Step: denied, Name: "denied", Reason: pl.PendingAdmission},
+		{Step: queued, Name: "queued", Reason: pl.PendingForLease},
+		{Step: dependent, Name: "dependent", Reason: pl.PendingOnDependees, Dependees: []string{"blocker"}},
+	}
+	var got []pl.PendingStep
+	// the scheduler may still be passing over the Steps after blocker started
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if got = w.PendingSummary(); reflect.DeepEqual(got, want) {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want pending %+v, got %+v", want, got)
+	}
+	if report := w.Report(); !reflect.DeepEqual(report.Pending, want) {
+		t.Errorf("want pending in Report %+v, got %+v", want, report.Pending)
+	}
+
+	released.Store(true)
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := w.PendingSummary(); len(got) != 0 {
+		t.Errorf("want no pending Step after Run, got %+v", got)
+	}
+}
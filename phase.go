@@ -0,0 +1,188 @@
+package pl
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Phase is a named part of running a Step, in the order of:
+//
+//	PhaseFlow -> PhaseValidate -> PhaseDo -> PhaseOutput
+//
+// Each retry attempt goes through all the phases again.
+type Phase string
+
+const (
+	PhaseFlow     Phase = "Flow"     // flow Dependees' Output and Input functions into the Step's Input
+	PhaseValidate Phase = "Validate" // validate the Step's Input, see InputValidator
+	PhaseDo       Phase = "Do"       // the Step's Do
+	PhaseOutput   Phase = "Output"   // validate and snapshot the Step's Output, see OutputValidator and StepState.Output
+)
+
+// InputValidator can be implemented by a Step to validate its Input in PhaseValidate,
+// after all Input flowed and before Do.
+type InputValidator interface {
+	ValidateInput(context.Context) error
+}
+
+// OutputValidator can be implemented by a Step to validate its Output in PhaseOutput,
+// after Do succeeded.
+type OutputValidator interface {
+	ValidateOutput(context.Context) error
+}
+
+// ErrPhase indicates the error happens in PhaseValidate or PhaseOutput.
+//
+// Errors in PhaseFlow are ErrFlow, errors in PhaseDo are returned as is.
+// The Phase where a Step failed is recorded in StepState.Phase either way.
+type ErrPhase struct {
+	Phase Phase
+	Err   error
+}
+
+func (e *ErrPhase) Error() string {
+	return fmt.Sprintf("ErrPhase(%s): %s", e.Phase, e.Err.Error())
+}
+
+func (e *ErrPhase) Unwrap() error {
+	return e.Err
+}
+
+// StepTimings records the time spent in each Phase of a Step,
+// durations are accumulated across retry attempts.
+type StepTimings map[Phase]time.Duration
+
+// PhaseSpan is when a Phase of a Step ran, one per attempt.
+type PhaseSpan struct {
+	Phase      Phase     `json:"phase"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// phase is one named part of makeDoForStep.
+type phase struct {
+	Name Phase
+	Do   func(context.Context) error
+}
+
+// phasesOf returns the phases of running a Step in an attempt (starts from 1), in order.
+func (s *Workflow) phasesOf(step StepDoer, attempt uint64) []phase {
+	return []phase{
+		{PhaseFlow, func(ctx context.Context) error {
+			// apply dependee's output to current Step's input
+			//
+			// the same Dependee wired by DirectDependsOn more than once (e.g. by helpers in different Add)
+			// flows only once, so an Output appending to the Input isn't duplicated.
+			direct := map[StepDoer]bool{}
+			for _, l := range s.deps[step] {
+				if l.Direct {
+					if direct[l.Dependee] {
+						continue
+					}
+					direct[l.Dependee] = true
+				}
+				flow := l.Flow
+				if l.Dependee != nil {
+					// only flow data from succeeded or failed Step,
+					// or any terminated Step with WorkflowFlowFromAllTerminated
+					//
+					// The status is checked right before each flow in every attempt, not when scheduled,
+					// so a retried attempt sees the status the Dependee has then.
+					if !flowsOutput(ctx, l.Dependee) {
+						flow = l.Fallback // nil unless AdaptOr
+					}
+				} // or flow data from Dependee == nil (it's Input)
+				if flow != nil {
+					if ferr := catchPanicAsError(func() error {
+						return flow(ctx)
+					}); ferr != nil {
+						return &ErrFlow{
+							Err:  ferr,
+							From: l.Dependee,
+						}
+					}
+				}
+			}
+			return nil
+		}},
+		{PhaseValidate, func(ctx context.Context) error {
+			if err := s.recordInput(ctx, step); err != nil {
+				return &ErrPhase{Phase: PhaseValidate, Err: err}
+			}
+			if v, ok := step.(InputValidator); ok {
+				if err := v.ValidateInput(ctx); err != nil {
+					return &ErrPhase{Phase: PhaseValidate, Err: err}
+				}
+			}
+			return nil
+		}},
+		{PhaseDo, func(ctx context.Context) error {
+			if attempt == 1 {
+				if err := s.checkIndeterminate(step); err != nil {
+					return err
+				}
+			}
+			return journaled(ctx, step.getJournal(), step, attempt, step.Do)
+		}},
+		{PhaseOutput, func(ctx context.Context) error {
+			if v, ok := step.(OutputValidator); ok {
+				if err := v.ValidateOutput(ctx); err != nil {
+					return &ErrPhase{Phase: PhaseOutput, Err: err}
+				}
+			}
+			// snapshot the Output when Do succeeded,
+			// Dependers pull the Output later, after it may have changed
+			out, ok := outputOf(step)
+			if !ok {
+				return nil
+			}
+			s.recordOutputSnapshot(step, out)
+			if err := s.recordOutput(ctx, step, out); err != nil {
+				return &ErrPhase{Phase: PhaseOutput, Err: err}
+			}
+			return nil
+		}},
+	}
+}
+
+// recordPhase records when a Phase of a Step ran, and accumulates its duration.
+func (s *Workflow) recordPhase(step StepDoer, p Phase, start, end time.Time) {
+	s.errsMu.Lock()
+	defer s.errsMu.Unlock()
+	r := s.recordOf(step)
+	r.Timings[p] += end.Sub(start)
+	r.Phases = append(r.Phases, PhaseSpan{Phase: p, StartedAt: start, FinishedAt: end})
+}
+
+// recordFailedPhase records the Phase where the attempt of a Step failed.
+func (s *Workflow) recordFailedPhase(step StepDoer, p Phase) {
+	s.errsMu.Lock()
+	defer s.errsMu.Unlock()
+	s.recordOf(step).Phase = p
+}
+
+// recordOutputSnapshot records the Output of a Step snapshotted in PhaseOutput.
+func (s *Workflow) recordOutputSnapshot(step StepDoer, out any) {
+	s.errsMu.Lock()
+	defer s.errsMu.Unlock()
+	s.recordOf(step).Output = out
+}
+
+// Timings returns the time spent in each Phase of the Steps that have run.
+func (s *Workflow) Timings() map[StepReader]StepTimings {
+	s.errsMu.RLock()
+	defer s.errsMu.RUnlock()
+	timings := make(map[StepReader]StepTimings, len(s.records))
+	for step, r := range s.records {
+		if len(r.Timings) == 0 {
+			continue
+		}
+		copied := make(StepTimings, len(r.Timings))
+		for p, d := range r.Timings {
+			copied[p] = d
+		}
+		timings[step] = copied
+	}
+	return timings
+}
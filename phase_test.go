@@ -0,0 +1,219 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+type phaseStep struct {
+	pl.StepBaseIn[[]string]
+	invalidOutput bool
+}
+
+func (s *phaseStep) String() string { return "phaseStep" }
+
+func (s *phaseStep) Output(*struct{}) {}
+
+func (s *phaseStep) ValidateInput(context.Context) error {
+	s.In = append(s.In, "validate")
+	return nil
+}
+
+func (s *phaseStep) Do(context.Context) error {
+	s.In = append(s.In, "do")
+	return nil
+}
+
+func (s *phaseStep) ValidateOutput(context.Context) error {
+	s.In = append(s.In, "output")
+	if s.invalidOutput {
+		return fmt.Errorf("invalid output")
+	}
+	return nil
+}
+
+func TestPhaseOrdering(t *testing.T) {
+	step := new(phaseStep)
+	w := new(pl.Workflow).Add(
+		pl.Step(step).Input(func(_ context.Context, i *[]string) error {
+			*i = append(*i, "flow")
+			return nil
+		}),
+	)
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"flow", "validate", "do", "output"}; !reflect.DeepEqual(step.In, want) {
+		t.Errorf("want phases %v, got %v", want, step.In)
+	}
+	timings := w.Timings()[step]
+	for _, p := range []pl.Phase{pl.PhaseFlow, pl.PhaseValidate, pl.PhaseDo, pl.PhaseOutput} {
+		if _, ok := timings[p]; !ok {
+			t.Errorf("missing timing of phase %s", p)
+		}
+	}
+}
+
+func TestPhaseError(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	invalid := &phaseStep{invalidOutput: true}
+	failing := pl.FuncNoInOut("failing", func(context.Context) error { return sentinel })
+	flow := &phaseStep{}
+	w := new(pl.Workflow).Add(
+		pl.Step(invalid),
+		pl.Step(failing),
+		pl.Step(flow).Input(func(context.Context, *[]string) error { return errors.New("bad input") }),
+	)
+	if err := w.Run(context.Background()); err == nil {
+		t.Fatal("expect error")
+	}
+	var perr *pl.ErrPhase
+	if !errors.As(w.Err()[invalid], &perr) || perr.Phase != pl.PhaseOutput {
+		t.Errorf("want ErrPhase in %s, got %v", pl.PhaseOutput, w.Err()[invalid])
+	}
+	if err := w.Err()[failing]; err != sentinel {
+		t.Errorf("want the error of Do returned as is, got %v", err)
+	}
+	var ferr *pl.ErrFlow
+	if !errors.As(w.Err()[flow], &ferr) {
+		t.Errorf("want ErrFlow, got %v", w.Err()[flow])
+	}
+	want := map[pl.StepReader]pl.Phase{
+		invalid: pl.PhaseOutput,
+		failing: pl.PhaseDo,
+		flow:    pl.PhaseFlow,
+	}
+	for _, state := range w.States() {
+		if state.Phase != want[state.Step] {
+			t.Errorf("want %s failed in %s, got %q", state.Step, want[state.Step], state.Phase)
+		}
+	}
+}
+
+type snapshotStep struct {
+	pl.StepBaseIn[struct{}]
+	n int
+}
+
+func (s *snapshotStep) String() string { return "snapshot" }
+
+func (s *snapshotStep) Output(o *int) { *o = s.n }
+
+func (s *snapshotStep) Do(context.Context) error {
+	s.n++
+	return nil
+}
+
+func TestPhaseOutputSnapshot(t *testing.T) {
+	step := new(snapshotStep)
+	w := new(pl.Workflow).Add(pl.Step(step))
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	step.n = 10 // changed after Do succeeded
+	if got := w.States()[0].Output; got != 1 {
+		t.Errorf("want Output snapshotted when Do succeeded, got %v", got)
+	}
+}
+
+func TestFlowSkipNotFlowableDependee(t *testing.T) {
+	upstream := fail("upstream")
+	canceled := pl.FuncOut("canceled", func(context.Context) (func(*int), error) {
+		return func(o *int) { *o = 1 }, nil
+	})
+	var got int
+	depender := pl.FuncIn("depender", func(_ context.Context, i int) error {
+		got = i
+		return nil
+	})
+	w := new(pl.Workflow).Add(
+		pl.Step(canceled).ExtraDependsOn(upstream),
+		pl.Step(depender).
+			Input(func(_ context.Context, i *int) error {
+				*i = -1
+				return nil
+			}).
+			DirectDependsOn(canceled).
+			Condition(pl.Always),
+	)
+	_ = w.Run(context.Background())
+	if canceled.GetStatus() != pl.StepStatusCanceled || depender.GetStatus() != pl.StepStatusSucceeded {
+		t.Fatalf("want canceled Canceled and depender Succeeded, got %s %s", canceled.GetStatus(), depender.GetStatus())
+	}
+	if got != -1 {
+		t.Errorf("want the flow from Canceled Dependee skipped, got Input %d", got)
+	}
+}
+
+// appender is a Step whose Output appends to the Input of Depender.
+type appender struct {
+	pl.StepBaseNoInOut
+}
+
+func (a *appender) String() string           { return "appender" }
+func (a *appender) Do(context.Context) error { return nil }
+func (a *appender) Output(o *[]string)       { *o = append(*o, "a") }
+
+func TestFlowDirectOncePerDependee(t *testing.T) {
+	a := new(appender)
+	var got []string
+	d := pl.FuncIn("d", func(_ context.Context, in []string) error {
+		got = in
+		return nil
+	})
+	// wired twice by helpers in different Add
+	wire := func() pl.WorkflowStep { return pl.Step(d).DirectDependsOn(a) }
+	w := new(pl.Workflow).Add(wire()).Add(wire())
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want Output flowed once %v, got %v", want, got)
+	}
+}
+
+func TestWorkflowFlowFromAllTerminated(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []pl.WorkflowOption
+		want int
+	}{
+		{"default", nil, -1},
+		{"all terminated", []pl.WorkflowOption{pl.WorkflowFlowFromAllTerminated()}, 7},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			upstream := fail("upstream")
+			canceled := &produce[int]{make: func() int { return 1 }}
+			canceled.Out = 7 // the default Output
+			var got int
+			depender := pl.FuncIn("depender", func(_ context.Context, i int) error {
+				got = i
+				return nil
+			})
+			w := new(pl.Workflow).
+				WithOptions(tc.opts...).
+				Add(
+					pl.Step(canceled).ExtraDependsOn(upstream),
+					pl.Step(depender).
+						Input(func(_ context.Context, i *int) error {
+							*i = -1
+							return nil
+						}).
+						DirectDependsOn(canceled).
+						Condition(pl.Always),
+				)
+			_ = w.Run(context.Background())
+			if canceled.GetStatus() != pl.StepStatusCanceled {
+				t.Fatalf("want canceled Canceled, got %s", canceled.GetStatus())
+			}
+			if got != tc.want {
+				t.Errorf("want Input %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package pl
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrPlanStale is returned by Plan.NewRun if the Workflow has been mutated (e.g. Add) after Compile.
+var ErrPlanStale = fmt.Errorf("Workflow has been mutated after Compile, compile it again")
+
+// Plan is the compiled scheduling metadata of a Workflow, see Workflow.Compile.
+//
+// A Plan is immutable, it's computed once from the dependency of the Workflow,
+// and reused by every run until the Workflow is mutated.
+type Plan struct {
+	w          *Workflow
+	generation uint64
+	layers     [][]StepDoer
+	index      map[StepDoer]int          // the order of Steps being added into Workflow
+	roots      []StepDoer                // the Steps without Dependee
+	upstream   map[StepDoer][]StepReader // the Dependees of each Step, in the order of links
+	downstream map[StepDoer][]StepDoer   // reverse index of dependency
+	indegree   map[StepDoer]int          // the number of distinct Dependees of each Step
+}
+
+// Compile validates the dependency of Workflow and precomputes the scheduling metadata,
+// including the topological layers, the reverse index, the indegrees and the Dependees of each Step.
+//
+// Runs after Compile reuse the Plan instead of recomputing them from the dependency,
+// until the Workflow is mutated by Add, then Compile is required again.
+// Compile doesn't check the status of Steps, the Workflow can be compiled before or between runs.
+func (s *Workflow) Compile() (*Plan, error) {
+	var errs ErrValidation
+	for _, step := range s.steps {
+		if isNilStep(step) {
+			errs = append(errs, ErrNilStep)
+			return nil, errs
+		}
+	}
+	layers, err := topologicalOrder(s.steps, s.deps)
+	if err != nil {
+		errs = append(errs, err)
+		return nil, errs
+	}
+	p := &Plan{
+		w:          s,
+		generation: s.generation,
+		layers:     layers,
+		index:      make(map[StepDoer]int, len(s.steps)),
+		upstream:   make(map[StepDoer][]StepReader, len(s.steps)),
+		downstream: s.downstreamIndex(),
+		indegree:   make(map[StepDoer]int, len(s.steps)),
+	}
+	for i, step := range s.steps {
+		p.index[step] = i
+		up := s.deps.listUpstreamReporterOf(step)
+		p.upstream[step] = up
+		if len(up) == 0 {
+			p.roots = append(p.roots, step)
+		}
+		seen := make(map[StepReader]bool, len(up))
+		for _, e := range up {
+			if !seen[e] {
+				seen[e] = true
+				p.indegree[step]++
+			}
+		}
+	}
+	s.plan.Store(p)
+	return p, nil
+}
+
+// Layers returns the Steps grouped by execution level, see Workflow.TopologicalOrder.
+func (p *Plan) Layers() [][]StepDoer {
+	layers := make([][]StepDoer, len(p.layers))
+	for i, l := range p.layers {
+		layers[i] = append([]StepDoer(nil), l...)
+	}
+	return layers
+}
+
+// Indegree returns the number of distinct Dependees of the Step.
+func (p *Plan) Indegree(step StepDoer) int {
+	return p.indegree[step]
+}
+
+// NewRun runs the compiled Workflow, see Workflow.Run.
+//
+// Steps hold their own status and Input / Output, so a run is still the Workflow itself,
+// reset it via Reset before running it again.
+// NewRun returns ErrPlanStale if the Workflow has been mutated after Compile.
+func (p *Plan) NewRun(ctx context.Context) error {
+	if p.stale() {
+		return ErrPlanStale
+	}
+	return p.w.Run(ctx)
+}
+
+// stale returns whether the Workflow has been mutated after the Plan compiled.
+func (p *Plan) stale() bool {
+	return p.generation != p.w.generation
+}
+
+// compiled returns the Plan if it's still valid, or nil.
+func (s *Workflow) compiled() *Plan {
+	if p := s.plan.Load(); p != nil && !p.stale() {
+		return p
+	}
+	return nil
+}
+
+// upstreamOf returns the Dependees of the Step, from the Plan if compiled.
+func (s *Workflow) upstreamOf(step StepDoer) []StepReader {
+	if s.runPlan != nil {
+		return s.runPlan.upstream[step]
+	}
+	return s.deps.listUpstreamReporterOf(step)
+}
@@ -0,0 +1,69 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestWorkflowCompile(t *testing.T) {
+	a, b, c, d := succeed("a"), succeed("b"), succeed("c"), succeed("d")
+	w := new(pl.Workflow).Add(
+		pl.Step(d).ExtraDependsOn(b, c),
+		pl.Step(c).ExtraDependsOn(a),
+		pl.Step(b).ExtraDependsOn(a),
+	)
+	plan, err := w.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]pl.StepDoer{{a}, {b, c}, {d}}; !reflect.DeepEqual(plan.Layers(), want) {
+		t.Errorf("want layers %v, got %v", want, plan.Layers())
+	}
+	if got := plan.Indegree(d); got != 2 {
+		t.Errorf("want indegree of d 2, got %d", got)
+	}
+	for i := 0; i < 2; i++ {
+		if err := plan.NewRun(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		for _, step := range []pl.StepReader{a, b, c, d} {
+			if step.GetStatus() != pl.StepStatusSucceeded {
+				t.Errorf("run %d: want %s Succeeded, got %s", i, step, step.GetStatus())
+			}
+		}
+		if err := w.Reset(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("stale after Add", func(t *testing.T) {
+		e := succeed("e")
+		w.Add(pl.Step(e).ExtraDependsOn(d))
+		if err := plan.NewRun(context.Background()); !errors.Is(err, pl.ErrPlanStale) {
+			t.Fatalf("want ErrPlanStale, got %v", err)
+		}
+		// the Workflow still runs without the stale Plan
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if e.GetStatus() != pl.StepStatusSucceeded {
+			t.Errorf("want e Succeeded, got %s", e.GetStatus())
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		x, y := succeed("x"), succeed("y")
+		cyclic := new(pl.Workflow).Add(
+			pl.Step(x).ExtraDependsOn(y),
+			pl.Step(y).ExtraDependsOn(x),
+		)
+		var cycle pl.ErrCycleDependency
+		if _, err := cyclic.Compile(); !errors.As(err, &cycle) {
+			t.Errorf("want ErrCycleDependency, got %v", err)
+		}
+	})
+}
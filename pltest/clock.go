@@ -0,0 +1,109 @@
+// Package pltest provides helpers for testing Workflows.
+package pltest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xuxife/pl"
+)
+
+// Clock is a fake pl.Clock for tests, its time only moves forward by Advance,
+// so tests of timeouts, retry backoff and deadlines don't rely on wall-clock sleeps.
+//
+//	clock := pltest.NewClock(time.Now())
+//	w := new(pl.Workflow).WithOptions(pl.WorkflowClock(clock)) ...
+//	go w.Run(ctx)
+//	clock.BlockUntilTimers(1) // e.g. the Step is sleeping between retry attempts
+//	clock.Advance(time.Minute)
+type Clock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*timer // waiting timers
+}
+
+type timer struct {
+	c  *Clock
+	at time.Time
+	f  func()
+}
+
+// NewClock returns a Clock starting at now.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the current time of the Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc calls f in its own goroutine once the Clock is advanced by d.
+func (c *Clock) AfterFunc(d time.Duration, f func()) pl.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &timer{c: c, at: c.now.Add(d), f: f}
+	if d <= 0 {
+		go f()
+		return t
+	}
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	return t
+}
+
+// Stop prevents the timer from firing, returns false if the timer has already fired or been stopped.
+func (t *timer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	for i, w := range t.c.timers {
+		if w == t {
+			t.c.timers = append(t.c.timers[:i], t.c.timers[i+1:]...)
+			t.c.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the Clock forward by d, and fires the due timers, each in its own goroutine.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	var due, waiting []*timer
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			waiting = append(waiting, t)
+		} else {
+			due = append(due, t)
+		}
+	}
+	c.timers = waiting
+	c.cond.Broadcast()
+	for _, t := range due {
+		go t.f()
+	}
+}
+
+// Timers returns the number of timers waiting to fire.
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntilTimers blocks until at least n timers are waiting to fire,
+// e.g. until the code under test starts waiting on the Clock, before Advance.
+func (c *Clock) BlockUntilTimers(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
@@ -0,0 +1,38 @@
+package pltest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl/pltest"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := pltest.NewClock(start)
+	fired := make(chan time.Duration, 2)
+	go func() {
+		clock.AfterFunc(time.Second, func() { fired <- time.Second })
+		clock.AfterFunc(time.Minute, func() { fired <- time.Minute })
+	}()
+	clock.BlockUntilTimers(2)
+	stopped := clock.AfterFunc(time.Second, func() { t.Error("want stopped timer not fired") })
+	if !stopped.Stop() || stopped.Stop() {
+		t.Error("want Stop return true only the first time")
+	}
+
+	clock.Advance(time.Second)
+	if got := <-fired; got != time.Second {
+		t.Errorf("want the 1s timer fired, got %s", got)
+	}
+	if got := clock.Timers(); got != 1 {
+		t.Errorf("want 1 timer waiting, got %d", got)
+	}
+	clock.Advance(time.Minute)
+	if got := <-fired; got != time.Minute {
+		t.Errorf("want the 1m timer fired, got %s", got)
+	}
+	if got := clock.Now(); !got.Equal(start.Add(time.Minute + time.Second)) {
+		t.Errorf("want now advanced, got %s", got)
+	}
+}
@@ -0,0 +1,136 @@
+package pl
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// PolicyBounds bounds the timeout and retry policy of Steps, see WorkflowPolicyBounds,
+// e.g. to catch Timeout(30 * time.Millisecond) on a Step taking seconds,
+// or 1000000 attempts with 1 minute backoff, before running in production.
+//
+// The zero value of each field means no bound.
+// The policy of a Step is its own Timeout / Retry, or the default of the Workflow,
+// a Step without timeout or retry is not checked by the related bounds.
+type PolicyBounds struct {
+	MinTimeout         time.Duration
+	MaxTimeout         time.Duration
+	MaxAttempts        uint64
+	MaxBackoffInterval time.Duration // the max interval between attempts
+	MaxRetryElapsed    time.Duration // the max total time of retrying, unbounded retrying exceeds it
+}
+
+// WorkflowPolicyBounds enforces the PolicyBounds on Steps,
+// violations are reported in ErrValidation as ErrPolicyBound by Validate, DryRun and Run.
+//
+// Steps with AllowPolicyException are not checked, so intentional outliers are visible in code.
+func WorkflowPolicyBounds(b PolicyBounds) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("policy bounds", fmt.Sprintf("%+v", b))
+		s.policyBounds = &b
+	}
+}
+
+// WorkflowSoftPolicyBounds is the lint level of WorkflowPolicyBounds,
+// violations are logged as warnings by the Workflow logger (see WorkflowLogger) instead of failing.
+func WorkflowSoftPolicyBounds(b PolicyBounds) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("soft policy bounds", fmt.Sprintf("%+v", b))
+		s.softPolicyBounds = &b
+	}
+}
+
+// ErrPolicyBound is reported when the policy of a Step violates a bound, see WorkflowPolicyBounds.
+type ErrPolicyBound struct {
+	Step  StepReader
+	Bound string // e.g. "min timeout"
+	Limit any    // the bound
+	Value any    // the policy of the Step
+}
+
+func (e ErrPolicyBound) Error() string {
+	return fmt.Sprintf("Step %s violates %s %v: got %v", e.Step, e.Bound, e.Limit, e.Value)
+}
+
+// checkPolicy checks the Steps against the policy bounds,
+// returns the violations of the hard bounds, and logs the ones of the soft bounds.
+func (s *Workflow) checkPolicy(steps []StepDoer) []error {
+	var errs []error
+	for _, step := range steps {
+		if step.getPolicyException() {
+			continue
+		}
+		if s.policyBounds != nil {
+			for _, err := range s.policyBounds.check(s, step) {
+				errs = append(errs, err)
+			}
+		}
+		if s.softPolicyBounds != nil && s.logger != nil {
+			for _, err := range s.softPolicyBounds.check(s, step) {
+				s.logger.Warn("Step violates soft policy bound",
+					slog.String("step", step.String()), slog.String("bound", err.Bound),
+					slog.Any("limit", err.Limit), slog.Any("value", err.Value))
+			}
+		}
+	}
+	return errs
+}
+
+func (b *PolicyBounds) check(s *Workflow, step StepDoer) []ErrPolicyBound {
+	var errs []ErrPolicyBound
+	violate := func(bound string, limit, value any) {
+		errs = append(errs, ErrPolicyBound{Step: step, Bound: bound, Limit: limit, Value: value})
+	}
+	timeout := step.getTimeout()
+	if timeout == 0 {
+		timeout = s.defaultTimeout
+	}
+	if timeout > 0 {
+		if b.MinTimeout > 0 && timeout < b.MinTimeout {
+			violate("min timeout", b.MinTimeout, timeout)
+		}
+		if b.MaxTimeout > 0 && timeout > b.MaxTimeout {
+			violate("max timeout", b.MaxTimeout, timeout)
+		}
+	}
+	opt := step.getRetry()
+	if opt == nil {
+		opt = s.defaultRetry
+	}
+	if opt == nil {
+		return errs
+	}
+	// the same defaults as RetryOption.Default
+	attempts := opt.Attempts
+	if attempts == 0 {
+		attempts = DefaultRetryOption.Attempts
+	}
+	bo := opt.Backoff
+	if bo == nil {
+		bo = DefaultRetryOption.Backoff
+	}
+	var interval, elapsed time.Duration // 0 elapsed means unbounded
+	switch bo := bo.(type) {
+	case *backoff.ExponentialBackOff:
+		interval, elapsed = bo.MaxInterval, bo.MaxElapsedTime
+	case *backoff.ConstantBackOff:
+		interval, elapsed = bo.Interval, bo.Interval*time.Duration(attempts)
+	}
+	if b.MaxAttempts > 0 && attempts > b.MaxAttempts {
+		violate("max attempts", b.MaxAttempts, attempts)
+	}
+	if b.MaxBackoffInterval > 0 && interval > b.MaxBackoffInterval {
+		violate("max backoff interval", b.MaxBackoffInterval, interval)
+	}
+	if b.MaxRetryElapsed > 0 && (elapsed == 0 || elapsed > b.MaxRetryElapsed) {
+		value := any(elapsed)
+		if elapsed == 0 {
+			value = "unbounded"
+		}
+		violate("max retry elapsed", b.MaxRetryElapsed, value)
+	}
+	return errs
+}
@@ -0,0 +1,103 @@
+package pl_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/xuxife/pl"
+)
+
+func TestWorkflowPolicyBounds(t *testing.T) {
+	bounds := pl.PolicyBounds{
+		MinTimeout:         time.Second,
+		MaxTimeout:         time.Hour,
+		MaxAttempts:        5,
+		MaxBackoffInterval: time.Minute,
+		MaxRetryElapsed:    10 * time.Minute,
+	}
+	short, long, hammer, slow, fine, outlier := succeed("short"), succeed("long"), succeed("hammer"), succeed("slow"), succeed("fine"), succeed("outlier")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowPolicyBounds(bounds)).
+		Add(
+			pl.Step(short).Timeout(30*time.Millisecond),
+			pl.Step(long).Timeout(2*time.Hour),
+			pl.Step(hammer).Retry(pl.RetryOption{Attempts: 1000000, Backoff: backoff.NewConstantBackOff(time.Second)}),
+			pl.Step(slow).Retry(pl.RetryOption{Attempts: 3, Backoff: backoff.NewConstantBackOff(2 * time.Minute)}),
+			pl.Step(fine).Timeout(time.Minute).Retry(pl.RetryOption{Attempts: 3, Backoff: backoff.NewConstantBackOff(time.Second)}),
+			pl.Step(outlier).Timeout(time.Millisecond).AllowPolicyException(),
+		)
+	err := w.Validate()
+	got := map[string][]string{}
+	var verr pl.ErrValidation
+	if !errors.As(err, &verr) {
+		t.Fatalf("want ErrValidation, got %v", err)
+	}
+	for _, e := range verr {
+		var perr pl.ErrPolicyBound
+		if errors.As(e, &perr) {
+			got[perr.Step.String()] = append(got[perr.Step.String()], perr.Bound)
+		}
+	}
+	want := map[string][]string{
+		"short":  {"min timeout"},
+		"long":   {"max timeout"},
+		"hammer": {"max attempts", "max retry elapsed"},
+		"slow":   {"max backoff interval"},
+	}
+	if len(got) != len(want) {
+		t.Errorf("want violations %v, got %v", want, got)
+	}
+	for step, bounds := range want {
+		if strings.Join(got[step], ",") != strings.Join(bounds, ",") {
+			t.Errorf("want %s violates %v, got %v", step, bounds, got[step])
+		}
+	}
+	if !strings.Contains(err.Error(), "Step short violates min timeout 1s: got 30ms") {
+		t.Errorf("want the error naming the Step and the bound, got %v", err)
+	}
+}
+
+func TestWorkflowPolicyBoundsRunTwice(t *testing.T) {
+	attempts := 0
+	flaky := pl.FuncNoInOut("flaky", func(context.Context) error {
+		attempts++
+		if attempts%2 == 1 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowPolicyBounds(pl.PolicyBounds{MaxRetryElapsed: time.Hour})).
+		Add(pl.Step(flaky).Retry(pl.RetryOption{Attempts: 3, Backoff: backoff.NewConstantBackOff(time.Millisecond)}))
+	for run := 1; run <= 2; run++ {
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatalf("run %d: want the retried Step within the bounds, got %v", run, err)
+		}
+		if err := w.Reset(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWorkflowSoftPolicyBounds(t *testing.T) {
+	buf := new(bytes.Buffer)
+	short := succeed("short")
+	w := new(pl.Workflow).
+		WithOptions(
+			pl.WorkflowLogger(slog.New(slog.NewTextHandler(buf, nil))),
+			pl.WorkflowSoftPolicyBounds(pl.PolicyBounds{MinTimeout: time.Second}),
+		).
+		Add(pl.Step(short).Timeout(30 * time.Millisecond))
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("want soft bounds not failing Run, got %v", err)
+	}
+	if log := buf.String(); !strings.Contains(log, "level=WARN") || !strings.Contains(log, "bound=\"min timeout\"") {
+		t.Errorf("want a warning of the violation, got %q", log)
+	}
+}
@@ -0,0 +1,59 @@
+package pl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrRaceLost is the cause of the context canceled for the alternatives losing a Race.
+var ErrRaceLost = fmt.Errorf("another alternative of Race succeeded")
+
+// ErrRaceNoAlternative is returned by a Race without alternatives.
+var ErrRaceNoAlternative = fmt.Errorf("Race has no alternative")
+
+// Race constructs a Step running the alternatives in parallel, e.g. for hedging requests to redundant backends,
+// its Output is the Output of the first alternative succeeded.
+//
+// Once an alternative succeeds, the context of the others is canceled with ErrRaceLost,
+// and Race waits for them to return before it succeeds.
+// If all alternatives fail, Race fails with the joined errors of them.
+//
+// The alternatives are run by Race, don't add them into Workflow.
+func Race[O any](name string, alts ...dependee[O]) Steper[struct{}, O] {
+	return FuncOut(name, func(ctx context.Context) (func(*O), error) {
+		if len(alts) == 0 {
+			return nil, ErrRaceNoAlternative
+		}
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+		type result struct {
+			alt dependee[O]
+			err error
+		}
+		results := make(chan result, len(alts))
+		for _, alt := range alts {
+			go func(alt dependee[O]) {
+				results <- result{alt, catchPanicAsError(func() error { return alt.Do(ctx) })}
+			}(alt)
+		}
+		var winner dependee[O]
+		var errs []error
+		for range alts {
+			r := <-results
+			switch {
+			case r.err != nil:
+				errs = append(errs, fmt.Errorf("%s: %w", r.alt, r.err))
+			case winner == nil:
+				winner = r.alt
+				cancel(ErrRaceLost)
+			}
+		}
+		if winner == nil {
+			return nil, errors.Join(errs...)
+		}
+		var o O
+		winner.Output(&o)
+		return func(out *O) { *out = o }, nil
+	})
+}
@@ -0,0 +1,72 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+)
+
+func TestRace(t *testing.T) {
+	var mu sync.Mutex
+	causes := map[string]error{}
+	backend := func(name string, delay time.Duration) pl.Steper[struct{}, string] {
+		return pl.FuncOut(name, func(ctx context.Context) (func(*string), error) {
+			select {
+			case <-time.After(delay):
+				return func(o *string) { *o = name }, nil
+			case <-ctx.Done():
+				mu.Lock()
+				causes[name] = context.Cause(ctx)
+				mu.Unlock()
+				return nil, ctx.Err()
+			}
+		})
+	}
+	race := pl.Race("fetch",
+		backend("slow", time.Minute),
+		backend("fast", time.Millisecond),
+		backend("slower", time.Hour),
+	)
+	var got string
+	use := pl.FuncIn("use", func(_ context.Context, in string) error {
+		got = in
+		return nil
+	})
+	w := new(pl.Workflow).Add(
+		pl.Step(use).DependsOn(pl.Adapt(race, func(_ context.Context, o string, i *string) error {
+			*i = o
+			return nil
+		})),
+	)
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got != "fast" {
+		t.Errorf("want the fastest alternative win, got %q", got)
+	}
+	for _, name := range []string{"slow", "slower"} {
+		if !errors.Is(causes[name], pl.ErrRaceLost) {
+			t.Errorf("want %s canceled with ErrRaceLost, got %v", name, causes[name])
+		}
+	}
+}
+
+func TestRaceAllFailed(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	alt := func(name string, err error) pl.Steper[struct{}, int] {
+		return pl.FuncOut(name, func(context.Context) (func(*int), error) { return nil, err })
+	}
+	race := pl.Race("race", alt("a", errA), alt("b", errB))
+	w := new(pl.Workflow).Add(pl.Step(race))
+	err := w.Run(context.Background())
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("want the joined errors of all alternatives, got %v", err)
+	}
+	if race.GetStatus() != pl.StepStatusFailed {
+		t.Errorf("want Race Failed, got %s", race.GetStatus())
+	}
+}
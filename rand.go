@@ -0,0 +1,82 @@
+package pl
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WorkflowSeed sets the seed of the Workflow, Rand derives the randomness of each Step from it.
+//
+// By default, each run uses a securely random seed, check it via Seed to replay the run.
+func WorkflowSeed(seed int64) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("seed", strconv.FormatInt(seed, 10))
+		s.seedOpt = &seed
+	}
+}
+
+// Seed returns the seed of the current or last run, see WorkflowSeed.
+//
+//	replay := new(Workflow).WithOptions(WorkflowSeed(w.Seed())) ...
+func (s *Workflow) Seed() int64 {
+	return s.seed
+}
+
+// newSeed decides the seed for a run.
+func (s *Workflow) newSeed() {
+	if s.seedOpt != nil {
+		s.seed = *s.seedOpt
+		return
+	}
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		s.seed = time.Now().UnixNano()
+		return
+	}
+	s.seed = int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+type randKey struct{}
+
+// lazyRand creates the *rand.Rand of a Step on the first use.
+type lazyRand struct {
+	once sync.Once
+	seed int64
+	r    *rand.Rand
+}
+
+func (l *lazyRand) get() *rand.Rand {
+	l.once.Do(func() {
+		l.r = rand.New(rand.NewSource(l.seed))
+	})
+	return l.r
+}
+
+// randFor returns the lazyRand of a Step, seeded from the Workflow seed and the Step name,
+// so the randomness of a Step doesn't depend on the scheduling order.
+func (s *Workflow) randFor(step StepDoer) *lazyRand {
+	h := fnv.New64a()
+	h.Write([]byte(step.String()))
+	return &lazyRand{seed: s.seed ^ int64(h.Sum64())}
+}
+
+// Rand returns the random source of the Step,
+// the context passed to Step's Do and Input functions carries it.
+//
+// The same Workflow seed reproduces the same randomness of each Step, regardless of scheduling order,
+// while different Steps get independent streams. The stream continues across attempts of the Step.
+// The returned *rand.Rand is not safe for concurrent use.
+//
+// It returns a randomly seeded *rand.Rand if the context is not from a running Workflow.
+func Rand(ctx context.Context) *rand.Rand {
+	if l, ok := ctx.Value(randKey{}).(*lazyRand); ok {
+		return l.get()
+	}
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
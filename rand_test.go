@@ -0,0 +1,71 @@
+package pl_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestRandReproducible(t *testing.T) {
+	run := func(seed int64) map[string][]int {
+		got := map[string][]int{}
+		var steps []pl.Steper[struct{}, struct{}]
+		for _, name := range []string{"a", "b", "c"} {
+			name := name
+			steps = append(steps, pl.FuncNoInOut(name, func(ctx context.Context) error {
+				r := pl.Rand(ctx)
+				got[name] = []int{r.Int(), r.Int()}
+				return nil
+			}))
+		}
+		// reverse the order to show it's independent from scheduling
+		if seed%2 == 0 {
+			steps[0], steps[2] = steps[2], steps[0]
+		}
+		w := new(pl.Workflow).WithOptions(pl.WorkflowSeed(seed), pl.WorkflowMaxConcurrency(1))
+		for _, step := range steps {
+			w.Add(pl.Step(step))
+		}
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if w.Seed() != seed {
+			t.Errorf("want seed %d, got %d", seed, w.Seed())
+		}
+		return got
+	}
+	first, second := run(42), run(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("want same randomness with same seed, got %v and %v", first, second)
+	}
+	if reflect.DeepEqual(first["a"], first["b"]) {
+		t.Errorf("want independent streams for different Steps, got %v", first)
+	}
+	if other := run(43); reflect.DeepEqual(first, other) {
+		t.Errorf("want different randomness with different seed, got %v", other)
+	}
+}
+
+func TestRandDefaultSeed(t *testing.T) {
+	var got int
+	newStep := func() pl.Steper[struct{}, struct{}] {
+		return pl.FuncNoInOut("step", func(ctx context.Context) error {
+			got = pl.Rand(ctx).Int()
+			return nil
+		})
+	}
+	w := new(pl.Workflow).Add(pl.Step(newStep()))
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	first := got
+	replay := new(pl.Workflow).WithOptions(pl.WorkflowSeed(w.Seed())).Add(pl.Step(newStep()))
+	if err := replay.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got != first {
+		t.Errorf("want replay with the recorded seed reproduce %d, got %d", first, got)
+	}
+}
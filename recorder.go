@@ -0,0 +1,260 @@
+package pl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// IORecorder records the Input and Output of Steps, e.g. as golden files for contract tests,
+// see WorkflowIORecorder.
+type IORecorder interface {
+	Record(ctx context.Context, record IORecord) error
+}
+
+// IORecord is the Input or Output of a Step in JSON.
+type IORecord struct {
+	Step  string          `json:"step"`
+	Kind  string          `json:"kind"` // input | output
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"` // why Data is absent, e.g. not JSON-marshalable
+}
+
+// WorkflowIORecorder records each Step's Input after flowing (in PhaseValidate, before ValidateInput),
+// and its Output after ValidateOutput passed (in PhaseOutput).
+//
+// Steps without `Input() *I` or `Output(*O)` method have nothing to record.
+// Input or Output not JSON-marshalable is recorded with the error instead of Data, without failing the Step,
+// while an error returned by the recorder fails the Step in the Phase.
+func WorkflowIORecorder(rec IORecorder) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("io recorder", summarizeSet(rec != nil))
+		s.ioRecorder = rec
+	}
+}
+
+// WorkflowIOVerifier checks each Step's Input after flowing (in PhaseValidate, before ValidateInput)
+// against the recording, e.g. the one recorded by the last release,
+// the Step fails with ErrIOMismatch if its Input differs from the recorded one.
+//
+// Steps without recorded Input, or with Input not JSON-marshalable are not checked.
+func WorkflowIOVerifier(recording IORecording) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("io verifier", summarizeSet(recording != nil))
+		s.ioRecording = recording
+	}
+}
+
+// IORecording is the recorded Input and Output of Steps by name, see FileRecorder.Load.
+type IORecording map[string]StepIO
+
+// StepIO is the recorded Input and Output of a Step, nil if not recorded.
+type StepIO struct {
+	Input  json.RawMessage
+	Output json.RawMessage
+}
+
+// ErrIOMismatch indicates the Input of a Step differs from the recording, see WorkflowIOVerifier.
+type ErrIOMismatch struct {
+	Step StepReader
+	Diff []string // the structural differences, e.g. `$.items[1].id: recorded 1, got 2`
+}
+
+func (e ErrIOMismatch) Error() string {
+	return fmt.Sprintf("ErrIOMismatch(%s):\n\t%s", e.Step, strings.Join(e.Diff, "\n\t"))
+}
+
+// recordInput records and verifies the Input of a Step.
+func (s *Workflow) recordInput(ctx context.Context, step StepDoer) error {
+	if s.ioRecorder == nil && s.ioRecording == nil {
+		return nil
+	}
+	in, ok := inputOf(step)
+	if !ok {
+		return nil
+	}
+	data, merr := marshalIO(in)
+	if s.ioRecorder != nil {
+		if err := s.ioRecorder.Record(ctx, newIORecord(step, "input", data, merr)); err != nil {
+			return err
+		}
+	}
+	if recorded := s.ioRecording[step.String()].Input; recorded != nil && merr == nil {
+		if diff := jsonDiff(recorded, data); len(diff) > 0 {
+			return ErrIOMismatch{Step: step, Diff: diff}
+		}
+	}
+	return nil
+}
+
+// recordOutput records the Output of a Step.
+func (s *Workflow) recordOutput(ctx context.Context, step StepDoer, out any) error {
+	if s.ioRecorder == nil {
+		return nil
+	}
+	data, merr := marshalIO(out)
+	return s.ioRecorder.Record(ctx, newIORecord(step, "output", data, merr))
+}
+
+func newIORecord(step StepReader, kind string, data json.RawMessage, err error) IORecord {
+	r := IORecord{Step: step.String(), Kind: kind, Data: data}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// marshalIO marshals v into JSON, a panic in custom MarshalJSON is returned as error.
+func marshalIO(v any) (data json.RawMessage, err error) {
+	err = catchPanicAsError(func() error {
+		data, err = json.Marshal(v)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// outputOf returns the Output of a Step via its `Output(*O)` method.
+func outputOf(step StepReader) (any, bool) {
+	m := reflect.ValueOf(step).MethodByName("Output")
+	if !m.IsValid() || m.Type().NumIn() != 1 || m.Type().NumOut() != 0 || m.Type().In(0).Kind() != reflect.Pointer {
+		return nil, false
+	}
+	out := reflect.New(m.Type().In(0).Elem())
+	m.Call([]reflect.Value{out})
+	return out.Elem().Interface(), true
+}
+
+// jsonDiff returns the structural differences between two JSON documents.
+func jsonDiff(recorded, got json.RawMessage) []string {
+	var r, g any
+	if err := json.Unmarshal(recorded, &r); err != nil {
+		return []string{fmt.Sprintf("$: invalid recorded JSON: %s", err)}
+	}
+	if err := json.Unmarshal(got, &g); err != nil {
+		return []string{fmt.Sprintf("$: invalid JSON: %s", err)}
+	}
+	return diffValue("$", r, g)
+}
+
+func diffValue(path string, r, g any) []string {
+	switch r := r.(type) {
+	case map[string]any:
+		if g, ok := g.(map[string]any); ok {
+			keys := make([]string, 0, len(r)+len(g))
+			for k := range r {
+				keys = append(keys, k)
+			}
+			for k := range g {
+				if _, ok := r[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			var diff []string
+			for _, k := range keys {
+				rv, rok := r[k]
+				gv, gok := g[k]
+				switch {
+				case !gok:
+					diff = append(diff, fmt.Sprintf("%s.%s: recorded %s, got missing", path, k, jsonString(rv)))
+				case !rok:
+					diff = append(diff, fmt.Sprintf("%s.%s: recorded missing, got %s", path, k, jsonString(gv)))
+				default:
+					diff = append(diff, diffValue(path+"."+k, rv, gv)...)
+				}
+			}
+			return diff
+		}
+	case []any:
+		if g, ok := g.([]any); ok {
+			var diff []string
+			if len(r) != len(g) {
+				diff = append(diff, fmt.Sprintf("%s: recorded %d elements, got %d", path, len(r), len(g)))
+			}
+			for i := 0; i < len(r) && i < len(g); i++ {
+				diff = append(diff, diffValue(fmt.Sprintf("%s[%d]", path, i), r[i], g[i])...)
+			}
+			return diff
+		}
+	}
+	if !reflect.DeepEqual(r, g) {
+		return []string{fmt.Sprintf("%s: recorded %s, got %s", path, jsonString(r), jsonString(g))}
+	}
+	return nil
+}
+
+func jsonString(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// FileRecorder is an IORecorder appending JSON lines to a file.
+type FileRecorder struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// NewFileRecorder creates a FileRecorder writing to path.
+func NewFileRecorder(path string) *FileRecorder {
+	return &FileRecorder{Path: path}
+}
+
+func (r *FileRecorder) Record(ctx context.Context, record IORecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, err := os.OpenFile(r.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads the recording from the file, the last record of a Step wins,
+// e.g. the Input of the last retry attempt.
+func (r *FileRecorder) Load() (IORecording, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, err := os.Open(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	recording := IORecording{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		var record IORecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, err
+		}
+		if record.Data == nil {
+			continue
+		}
+		io := recording[record.Step]
+		switch record.Kind {
+		case "input":
+			io.Input = record.Data
+		case "output":
+			io.Output = record.Data
+		}
+		recording[record.Step] = io
+	}
+	return recording, scanner.Err()
+}
@@ -0,0 +1,85 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+type order struct {
+	ID    int      `json:"id"`
+	Items []string `json:"items"`
+}
+
+func TestIORecorder(t *testing.T) {
+	build := func(o order) (*pl.Workflow, pl.StepReader) {
+		produce := pl.FuncOut("produce", func(context.Context) (func(*order), error) {
+			return func(out *order) { *out = o }, nil
+		})
+		consume := pl.FuncIn("consume", func(context.Context, order) error { return nil })
+		return new(pl.Workflow).Add(pl.Step(consume).DirectDependsOn(produce)), consume
+	}
+	golden := filepath.Join(t.TempDir(), "golden.jsonl")
+	recorder := pl.NewFileRecorder(golden)
+
+	w, _ := build(order{ID: 1, Items: []string{"a", "b"}})
+	if err := w.WithOptions(pl.WorkflowIORecorder(recorder)).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	recording, err := recorder.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(recording["consume"].Input); got != `{"id":1,"items":["a","b"]}` {
+		t.Errorf("want consume Input recorded, got %s", got)
+	}
+	if got := string(recording["produce"].Output); got != `{"id":1,"items":["a","b"]}` {
+		t.Errorf("want produce Output recorded, got %s", got)
+	}
+
+	t.Run("same", func(t *testing.T) {
+		w, _ := build(order{ID: 1, Items: []string{"a", "b"}})
+		if err := w.WithOptions(pl.WorkflowIOVerifier(recording)).Run(context.Background()); err != nil {
+			t.Errorf("want Inputs matched, got %v", err)
+		}
+	})
+	t.Run("changed", func(t *testing.T) {
+		w, consume := build(order{ID: 2, Items: []string{"a"}})
+		_ = w.WithOptions(pl.WorkflowIOVerifier(recording)).Run(context.Background())
+		var mismatch pl.ErrIOMismatch
+		if !errors.As(w.Err()[consume], &mismatch) {
+			t.Fatalf("want ErrIOMismatch, got %v", w.Err()[consume])
+		}
+		want := []string{
+			`$.id: recorded 1, got 2`,
+			`$.items: recorded 2 elements, got 1`,
+		}
+		if !reflect.DeepEqual(mismatch.Diff, want) {
+			t.Errorf("want diff %q, got %q", want, mismatch.Diff)
+		}
+	})
+}
+
+func TestIORecorderNotMarshalable(t *testing.T) {
+	step := pl.FuncIn("chan", func(context.Context, chan int) error { return nil })
+	golden := filepath.Join(t.TempDir(), "golden.jsonl")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowIORecorder(pl.NewFileRecorder(golden))).
+		Add(pl.Step(step))
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("want not JSON-marshalable Input skipped, got %v", err)
+	}
+	data, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"kind":"input","error":"json: unsupported type: chan int"`) {
+		t.Errorf("want the skipped Input recorded with error, got %s", data)
+	}
+}
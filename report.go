@@ -0,0 +1,106 @@
+package pl
+
+import (
+	"sort"
+	"time"
+)
+
+// WorkflowReport is a JSON-marshalable report of a run of Workflow, see Workflow.Report.
+type WorkflowReport struct {
+	Steps    []StepReport  `json:"steps"` // sorted by name
+	Edges    []ReportEdge  `json:"edges"` // sorted by Depender then Dependee
+	Duration time.Duration `json:"duration"`
+	// Terminated is true if all Steps terminated, i.e. the report is final.
+	Terminated bool  `json:"terminated"`
+	Seed       int64 `json:"seed"` // see WorkflowSeed
+	// Pending are the Steps left Pending and why, e.g. when the run is stopped early, see PendingSummary.
+	Pending []PendingStep `json:"pending,omitempty"`
+}
+
+// StepReport is the report of a Step in WorkflowReport.
+type StepReport struct {
+	Name       string      `json:"name"`
+	Status     string      `json:"status"`
+	Err        string      `json:"error,omitempty"`
+	Phase      Phase       `json:"phase,omitempty"` // the Phase where the Step failed
+	StartedAt  *time.Time  `json:"startedAt,omitempty"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+	Attempts   uint64      `json:"attempts,omitempty"`
+	Phases     []PhaseSpan `json:"phases,omitempty"` // when each Phase ran, across attempts
+}
+
+// ReportEdge is a dependency in WorkflowReport, by the names of Steps.
+type ReportEdge struct {
+	Dependee string `json:"dependee"`
+	Depender string `json:"depender"`
+}
+
+// Report returns a JSON-marshalable report of the Workflow, including the Steps of all statuses
+// and the dependencies as name pairs, e.g. for an external UI to reconstruct the graph.
+//
+// Duration is from the first Step started to the last Step finished, or until now if still running.
+// Report is safe to call while the Workflow is running, it's partial then.
+func (s *Workflow) Report() WorkflowReport {
+	now := s.clock().Now()
+	report := WorkflowReport{
+		Terminated: s.IsTerminated(),
+		Seed:       s.seed,
+	}
+	var first, last time.Time
+	for _, state := range s.States() {
+		snapshot := state.snapshot()
+		step := StepReport{
+			Name:       snapshot.Name,
+			Status:     snapshot.Status,
+			Err:        snapshot.Err,
+			Phase:      snapshot.Phase,
+			StartedAt:  snapshot.StartedAt,
+			FinishedAt: snapshot.FinishedAt,
+		}
+		s.errsMu.RLock()
+		if r, ok := s.records[state.Step.(StepDoer)]; ok {
+			step.Attempts = r.Attempts
+			step.Phases = append([]PhaseSpan(nil), r.Phases...)
+		}
+		s.errsMu.RUnlock()
+		report.Steps = append(report.Steps, step)
+
+		if state.StartedAt.IsZero() {
+			continue
+		}
+		if first.IsZero() || state.StartedAt.Before(first) {
+			first = state.StartedAt
+		}
+		end := state.FinishedAt
+		if end.IsZero() {
+			end = now
+		}
+		if end.After(last) {
+			last = end
+		}
+	}
+	report.Duration = last.Sub(first)
+	if !report.Terminated {
+		report.Pending = s.PendingSummary()
+	}
+	for _, depender := range s.sortedSteps() {
+		seen := map[StepDoer]bool{}
+		for _, dependee := range s.deps.UpstreamOf(depender) {
+			if seen[dependee] {
+				continue
+			}
+			seen[dependee] = true
+			report.Edges = append(report.Edges, ReportEdge{
+				Dependee: dependee.String(),
+				Depender: depender.String(),
+			})
+		}
+	}
+	sort.SliceStable(report.Edges, func(i, j int) bool {
+		if report.Edges[i].Depender != report.Edges[j].Depender {
+			return report.Edges[i].Depender < report.Edges[j].Depender
+		}
+		return report.Edges[i].Dependee < report.Edges[j].Dependee
+	})
+	return report
+}
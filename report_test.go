@@ -0,0 +1,57 @@
+package pl_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+	"github.com/xuxife/pl/pltest"
+)
+
+func TestWorkflowReport(t *testing.T) {
+	clock := pltest.NewClock(time.Unix(0, 0))
+	a := pl.FuncNoInOut("a", func(context.Context) error {
+		clock.Advance(time.Second)
+		return nil
+	})
+	b, c := fail("b"), succeed("c")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowClock(clock), pl.WorkflowSeed(1)).
+		Add(
+			pl.Step(b).ExtraDependsOn(a),
+			pl.Step(c).ExtraDependsOn(b, a),
+		)
+	if report := w.Report(); report.Terminated || len(report.Steps) != 3 {
+		t.Errorf("want partial report before Run, got %+v", report)
+	}
+	_ = w.Run(context.Background())
+
+	data, err := json.Marshal(w.Report())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report pl.WorkflowReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Terminated || report.Duration != time.Second || report.Seed != 1 {
+		t.Errorf("want terminated report lasting 1s with seed 1, got %+v", report)
+	}
+	var statuses []string
+	for _, step := range report.Steps {
+		statuses = append(statuses, step.Name+":"+step.Status)
+	}
+	if want := []string{"a:Succeeded", "b:Failed", "c:Canceled"}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("want %v, got %v", want, statuses)
+	}
+	if report.Steps[0].Attempts != 1 || report.Steps[0].StartedAt == nil || report.Steps[1].Err != "b failed" || report.Steps[1].Phase != pl.PhaseDo {
+		t.Errorf("want attempts, times and errors reported, got %+v", report.Steps)
+	}
+	want := []pl.ReportEdge{{"a", "b"}, {"a", "c"}, {"b", "c"}}
+	if !reflect.DeepEqual(report.Edges, want) {
+		t.Errorf("want edges %v, got %v", want, report.Edges)
+	}
+}
@@ -0,0 +1,53 @@
+package pl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Resource acquires an external resource (DB transaction, lock, etc.) for a Step,
+// returns the function to release it.
+type Resource func(context.Context) (release func(), err error)
+
+// ErrResourceAcquire indicates the Step failed to acquire its Resource, Do is not called.
+type ErrResourceAcquire struct {
+	Err error
+}
+
+func (e *ErrResourceAcquire) Error() string {
+	return fmt.Sprintf("ErrResourceAcquire: %s", e.Err.Error())
+}
+
+func (e *ErrResourceAcquire) Unwrap() error {
+	return e.Err
+}
+
+// withResources acquires the resources in order, calls fn, then releases them in reverse order.
+//
+// The resources are always released, even fn panics or timeouts.
+// A panic in release is recovered and joined into the returned error.
+func withResources(ctx context.Context, resources []Resource, fn func() error) (err error) {
+	if len(resources) == 0 {
+		return fn()
+	}
+	var release func()
+	if err := catchPanicAsError(func() error {
+		var err error
+		release, err = resources[0](ctx)
+		return err
+	}); err != nil {
+		return &ErrResourceAcquire{Err: err}
+	}
+	if release != nil {
+		defer func() {
+			if rerr := catchPanicAsError(func() error {
+				release()
+				return nil
+			}); rerr != nil {
+				err = errors.Join(err, fmt.Errorf("release resource: %w", rerr))
+			}
+		}()
+	}
+	return withResources(ctx, resources[1:], fn)
+}
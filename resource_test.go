@@ -0,0 +1,62 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestWithResource(t *testing.T) {
+	for _, step := range []pl.Steper[struct{}, struct{}]{succeed("succeed"), fail("fail")} {
+		acquired, released := false, false
+		w := new(pl.Workflow).Add(
+			pl.Step(step).WithResource(func(context.Context) (func(), error) {
+				acquired = true
+				return func() { released = true }, nil
+			}),
+		)
+		_ = w.Run(context.Background())
+		if !acquired || !released {
+			t.Errorf("%s: want resource acquired and released, got acquired=%v released=%v", step, acquired, released)
+		}
+	}
+}
+
+func TestWithResourceAcquireFailed(t *testing.T) {
+	called := false
+	step := pl.FuncNoInOut("step", func(context.Context) error {
+		called = true
+		return nil
+	})
+	w := new(pl.Workflow).Add(
+		pl.Step(step).WithResource(func(context.Context) (func(), error) {
+			return nil, fmt.Errorf("locked")
+		}),
+	)
+	_ = w.Run(context.Background())
+	var aerr *pl.ErrResourceAcquire
+	if !errors.As(w.Err()[step], &aerr) {
+		t.Errorf("want ErrResourceAcquire, got %v", w.Err()[step])
+	}
+	if called {
+		t.Error("want Do not called")
+	}
+}
+
+func TestWithResourceReleasePanic(t *testing.T) {
+	step := fail("step")
+	w := new(pl.Workflow).Add(
+		pl.Step(step).WithResource(func(context.Context) (func(), error) {
+			return func() { panic("release boom") }, nil
+		}),
+	)
+	_ = w.Run(context.Background())
+	err := w.Err()[step]
+	if err == nil || !strings.Contains(err.Error(), "step failed") || !strings.Contains(err.Error(), "release boom") {
+		t.Errorf("want both the Step error and the release panic, got %v", err)
+	}
+}
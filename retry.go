@@ -19,6 +19,13 @@ type RetryOption struct {
 	Attempts uint64 // 0 means no limit
 	StopIf   func(ctx context.Context, attempt uint64, since time.Duration, err error) bool
 	Timer    backoff.Timer
+	// WaitFor is called between attempts, the next attempt starts
+	// when either the returned channel fires or the backoff interval passed, whichever first.
+	WaitFor func(ctx context.Context) <-chan struct{}
+	// Notify is called after each failed attempt that will be retried,
+	// with the attempt number (starts from 1), its error, and the backoff delay before the next attempt.
+	// It's not called after a Permanent error, or once the Step level Timeout is exceeded.
+	Notify func(ctx context.Context, attempt uint64, err error, next time.Duration)
 }
 
 func (opt *RetryOption) Default() {
@@ -36,33 +43,132 @@ func (opt *RetryOption) Default() {
 	}
 }
 
-func (s *Workflow) retry(opt *RetryOption) func(
+// retry works on a copy of opt, the stored RetryOption is shared by Steps and runs, never write to it.
+func (s *Workflow) retry(opt RetryOption) func(
 	ctx context.Context,
 	fn func(context.Context) error,
 	notAfter time.Time, // the Step level timeout ddl
+	notify backoff.Notify, // called before sleeping between attempts
 ) error {
-	return func(ctx context.Context, fn func(context.Context) error, notAfter time.Time) error {
+	return func(ctx context.Context, fn func(context.Context) error, notAfter time.Time, notify backoff.Notify) error {
+		opt := opt
 		opt.Default()
+		opt.Backoff = freshBackOff(opt.Backoff)
 		if opt.Attempts > 0 {
 			opt.Backoff = backoff.WithMaxRetries(opt.Backoff, opt.Attempts)
 		}
+		timer := opt.Timer
+		if timer == nil && s.clk != nil {
+			timer = &clockTimer{clock: s.clk}
+		}
+		if opt.WaitFor != nil {
+			timer = &waitForTimer{
+				Timer:   timer,
+				waitFor: func() <-chan struct{} { return opt.WaitFor(ctx) },
+			}
+		}
 		attempt := uint64(0)
-		start := time.Now()
+		start := s.clock().Now()
+		notifyAll := func(err error, next time.Duration) {
+			if notify != nil {
+				notify(err, next)
+			}
+			if opt.Notify != nil {
+				opt.Notify(ctx, attempt, err, next) // attempt has counted the failed one
+			}
+		}
 		return backoff.RetryNotifyWithTimer(
 			func() error {
 				err := fn(ctx)
-				if !notAfter.IsZero() && time.Now().After(notAfter) { // timeouted
+				if !notAfter.IsZero() && !s.clock().Now().Before(notAfter) { // timeouted
 					err = backoff.Permanent(err)
 				}
-				if opt.StopIf != nil && opt.StopIf(ctx, attempt, time.Since(start), err) {
+				if opt.StopIf != nil && opt.StopIf(ctx, attempt, s.clock().Now().Sub(start), err) {
 					err = backoff.Permanent(err)
 				}
 				attempt++
 				return err
 			},
 			opt.Backoff,
-			nil,
-			opt.Timer,
+			notifyAll,
+			timer,
 		)
 	}
 }
+
+// freshBackOff returns a BackOff with its own state for one retrying,
+// the BackOffs shipped by backoff are copied, others are returned as is.
+func freshBackOff(b backoff.BackOff) backoff.BackOff {
+	switch b := b.(type) {
+	case *backoff.ExponentialBackOff:
+		c := *b
+		return &c
+	case *backoff.ConstantBackOff:
+		c := *b
+		return &c
+	}
+	return b
+}
+
+// waitForTimer fires when either the inner Timer fires or the waitFor channel fires.
+type waitForTimer struct {
+	backoff.Timer // nil means time.Timer
+	waitFor       func() <-chan struct{}
+	timer         *time.Timer
+	c             chan time.Time
+	done          chan struct{}
+}
+
+func (t *waitForTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *waitForTimer) Start(duration time.Duration) {
+	if t.c == nil {
+		t.c = make(chan time.Time, 1)
+	}
+	t.stopWaiting()
+	var fired <-chan time.Time
+	if t.Timer != nil {
+		t.Timer.Start(duration)
+		fired = t.Timer.C()
+	} else {
+		t.timer = time.NewTimer(duration)
+		fired = t.timer.C
+	}
+	wait := t.waitFor()
+	done := make(chan struct{})
+	t.done = done
+	go func() {
+		var now time.Time
+		select {
+		case now = <-fired:
+		case <-wait:
+			now = time.Now()
+		case <-done:
+			return
+		}
+		select {
+		case t.c <- now:
+		default:
+		}
+	}()
+}
+
+func (t *waitForTimer) Stop() {
+	t.stopWaiting()
+	if t.Timer != nil {
+		t.Timer.Stop()
+	}
+}
+
+func (t *waitForTimer) stopWaiting() {
+	if t.done != nil {
+		close(t.done)
+		t.done = nil
+	}
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
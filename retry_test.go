@@ -0,0 +1,231 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/xuxife/pl"
+	"github.com/xuxife/pl/pltest"
+)
+
+func TestRetryWaitFor(t *testing.T) {
+	clock := pltest.NewClock(time.Unix(0, 0))
+	var attempts atomic.Int32
+	ready := make(chan struct{})
+	step := pl.FuncNoInOut("step", func(context.Context) error {
+		if attempts.Add(1) == 1 {
+			return fmt.Errorf("upstream not ready")
+		}
+		return nil
+	})
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowClock(clock)).
+		Add(
+			pl.Step(step).Retry(pl.RetryOption{
+				Backoff:  backoff.NewConstantBackOff(time.Hour),
+				Attempts: 3,
+				WaitFor: func(context.Context) <-chan struct{} {
+					return ready
+				},
+			}),
+		)
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+
+	clock.BlockUntilTimers(1) // waiting for the backoff or ready
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("want 1 attempt before ready, got %d", got)
+	}
+	close(ready)
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("want 2 attempts, got %d", got)
+	}
+}
+
+func TestRetryReleaseLeaseWhileSleeping(t *testing.T) {
+	clock := pltest.NewClock(time.Unix(0, 0))
+	otherDone := make(chan struct{})
+	var attempts atomic.Int32
+	retrying := pl.FuncNoInOut("retrying", func(context.Context) error {
+		if attempts.Add(1) == 1 {
+			return fmt.Errorf("retry")
+		}
+		select {
+		case <-otherDone:
+			return nil
+		default:
+			return backoff.Permanent(fmt.Errorf("other Step didn't run during backoff"))
+		}
+	})
+	other := pl.FuncNoInOut("other", func(context.Context) error {
+		close(otherDone)
+		return nil
+	})
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowMaxConcurrency(1), pl.WorkflowClock(clock)).
+		Add(
+			pl.Step(retrying).Retry(pl.RetryOption{
+				Backoff:  backoff.NewConstantBackOff(time.Minute),
+				Attempts: 3,
+			}),
+			pl.Step(other),
+		)
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	clock.BlockUntilTimers(1) // retrying is sleeping
+	<-otherDone
+	clock.Advance(time.Minute)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRetryTimeoutWithClock(t *testing.T) {
+	clock := pltest.NewClock(time.Unix(0, 0))
+	var attempts atomic.Int32
+	step := pl.FuncNoInOut("step", func(ctx context.Context) error {
+		attempts.Add(1)
+		<-ctx.Done()
+		return context.Cause(ctx)
+	})
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowClock(clock)).
+		Add(
+			pl.Step(step).
+				Timeout(time.Hour).
+				Retry(pl.RetryOption{Backoff: backoff.NewConstantBackOff(time.Minute), Attempts: 3}),
+		)
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	clock.BlockUntilTimers(1) // the Step Timeout
+	clock.Advance(time.Hour)
+	<-done
+	if err := w.Err()[step]; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want Step timeout, got %v", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("want no retry after Step timeout, got %d attempts", got)
+	}
+}
+
+// instantTimer fires immediately, and records the durations it's started with.
+type instantTimer struct {
+	c         chan time.Time
+	durations []time.Duration
+}
+
+func (t *instantTimer) Start(d time.Duration) {
+	if t.c == nil {
+		t.c = make(chan time.Time, 1)
+	}
+	t.durations = append(t.durations, d)
+	t.c <- time.Now()
+}
+func (t *instantTimer) Stop()               {}
+func (t *instantTimer) C() <-chan time.Time { return t.c }
+
+func TestRetryNotify(t *testing.T) {
+	type notified struct {
+		attempt uint64
+		next    time.Duration
+	}
+	var got []notified
+	failures := 0
+	step := pl.FuncNoInOut("step", func(context.Context) error {
+		failures++
+		if failures == 4 {
+			return backoff.Permanent(fmt.Errorf("give up"))
+		}
+		return fmt.Errorf("failure %d", failures)
+	})
+	timer := new(instantTimer)
+	w := new(pl.Workflow).Add(pl.Step(step).Retry(pl.RetryOption{
+		Backoff: backoff.NewConstantBackOff(2 * time.Second),
+		Timer:   timer,
+		Notify: func(_ context.Context, attempt uint64, err error, next time.Duration) {
+			if want := fmt.Sprintf("failure %d", attempt); !strings.Contains(err.Error(), want) {
+				t.Errorf("want error of attempt %d, got %v", attempt, err)
+			}
+			got = append(got, notified{attempt, next})
+		},
+	}))
+	_ = w.Run(context.Background())
+
+	want := []notified{{1, 2 * time.Second}, {2, 2 * time.Second}, {3, 2 * time.Second}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want notified %v (not after the Permanent error), got %v", want, got)
+	}
+	if !reflect.DeepEqual(timer.durations, []time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second}) {
+		t.Errorf("want Timer started with the delays, got %v", timer.durations)
+	}
+}
+
+func TestAttemptFromContext(t *testing.T) {
+	var attempts []uint64
+	flaky := pl.FuncNoInOut("flaky", func(ctx context.Context) error {
+		attempts = append(attempts, pl.AttemptFromContext(ctx))
+		if len(attempts) < 3 {
+			return fmt.Errorf("flaky")
+		}
+		return nil
+	})
+	var once uint64
+	plain := pl.FuncNoInOut("plain", func(ctx context.Context) error {
+		once = pl.AttemptFromContext(ctx)
+		return nil
+	})
+	w := new(pl.Workflow).Add(
+		pl.Step(flaky).Retry(pl.RetryOption{Backoff: &backoff.ZeroBackOff{}, Attempts: 5}),
+		pl.Step(plain),
+	)
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(attempts, []uint64{1, 2, 3}) {
+		t.Errorf("want attempts 1, 2, 3, got %v", attempts)
+	}
+	if once != 1 {
+		t.Errorf("want attempt 1 without Retry, got %d", once)
+	}
+	if got := pl.AttemptFromContext(context.Background()); got != 0 {
+		t.Errorf("want 0 outside Workflow, got %d", got)
+	}
+}
+
+func TestRetryDefaultSharedBySteps(t *testing.T) {
+	var attempts [4]atomic.Int32
+	w := new(pl.Workflow).WithOptions(pl.WorkflowDefaultRetry(pl.RetryOption{
+		Backoff:  backoff.NewConstantBackOff(time.Millisecond),
+		Attempts: 2,
+	}))
+	for i := range attempts {
+		i := i
+		w.Add(pl.Steps(pl.FuncNoInOut(fmt.Sprintf("step-%d", i), func(context.Context) error {
+			attempts[i].Add(1)
+			return fmt.Errorf("always fail")
+		})))
+	}
+	for run := 1; run <= 2; run++ {
+		if err := w.Run(context.Background()); err == nil {
+			t.Fatalf("run %d: want error", run)
+		}
+		for i := range attempts {
+			if got := attempts[i].Swap(0); got != 3 {
+				t.Errorf("run %d: want 3 attempts of step-%d, got %d", run, i, got)
+			}
+		}
+		if err := w.Reset(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package pl
+
+// Scheduler decides which ready Steps to start and in which order, see WorkflowScheduler.
+type Scheduler interface {
+	// PickReady receives the ready Steps, whose Dependees terminated and Condition and When passed,
+	// in the order of being added, and returns the Steps to start in this tick, in the order to start.
+	//
+	// The ready Steps not returned keep waiting, and are passed again in the next tick,
+	// i.e. when a Step terminates, so return at least one Step if none is running,
+	// otherwise the Workflow never ends.
+	// The picked Steps still compete for the leases in order, see WorkflowMaxConcurrency.
+	PickReady(ready []StepDoer) []StepDoer
+}
+
+// WorkflowScheduler delegates the ordering and selection of ready Steps to the Scheduler,
+// e.g. shortest job first by the estimated durations.
+//
+// Without it, ready Steps start in the order of being added.
+func WorkflowScheduler(sched Scheduler) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("scheduler", summarizeSet(sched != nil))
+		s.scheduler = sched
+	}
+}
+
+// pickReady reorders the ready queue by the Scheduler, the picked Steps are put in front in the picked order,
+// followed by the others in their order. It returns the number of the picked Steps.
+func (s *Workflow) pickReady() int {
+	byStep := make(map[StepDoer]*readyStep, len(s.frontier.ready))
+	steps := make([]StepDoer, 0, len(s.frontier.ready))
+	for _, r := range s.frontier.ready {
+		byStep[r.step] = r
+		steps = append(steps, r.step)
+	}
+	ready := make([]*readyStep, 0, len(s.frontier.ready))
+	for _, step := range s.scheduler.PickReady(steps) {
+		if r, ok := byStep[step]; ok { // ignore the Steps not ready, or picked twice
+			ready = append(ready, r)
+			delete(byStep, step)
+		}
+	}
+	picked := len(ready)
+	for _, r := range s.frontier.ready {
+		if _, ok := byStep[r.step]; ok {
+			ready = append(ready, r)
+		}
+	}
+	s.frontier.ready = ready
+	return picked
+}
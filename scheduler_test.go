@@ -0,0 +1,63 @@
+package pl_test
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+// shortestFirst picks the ready Steps by the estimated durations, shortest first.
+type shortestFirst map[string]int
+
+func (s shortestFirst) PickReady(ready []pl.StepDoer) []pl.StepDoer {
+	picked := append([]pl.StepDoer(nil), ready...)
+	sort.SliceStable(picked, func(i, j int) bool {
+		return s[picked[i].String()] < s[picked[j].String()]
+	})
+	return picked
+}
+
+// onlyFirst picks the first ready Step in each tick.
+type onlyFirst struct{}
+
+func (onlyFirst) PickReady(ready []pl.StepDoer) []pl.StepDoer { return ready[:1] }
+
+func TestWorkflowScheduler(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		sched pl.Scheduler
+		opts  []pl.WorkflowOption
+		want  []string
+	}{
+		{"shortest first", shortestFirst{"a": 3, "b": 1, "c": 2}, []pl.WorkflowOption{pl.WorkflowMaxConcurrency(1)}, []string{"b", "c", "a"}},
+		{"one per tick", onlyFirst{}, nil, []string{"a", "b", "c"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				started []string
+			)
+			record := func(name string) pl.Steper[struct{}, struct{}] {
+				return pl.FuncNoInOut(name, func(context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					started = append(started, name)
+					return nil
+				})
+			}
+			w := new(pl.Workflow).
+				WithOptions(append(tc.opts, pl.WorkflowScheduler(tc.sched))...).
+				Add(pl.Steps(record("a"), record("b"), record("c")))
+			if err := w.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(started, tc.want) {
+				t.Errorf("want launch order %v, got %v", tc.want, started)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package pl
+
+// WorkflowSkipPropagation cascades Skipped through dependency,
+// e.g. if build is Skipped, skip deploy too.
+//
+// The default Condition becomes AllSucceededNoneSkipped (unless WorkflowDefaultCondition is set),
+// and a Step rejected by its Condition is Skipped instead of Canceled,
+// if none of its Dependees is Failed or Canceled, but some are Skipped,
+// so reports tell "deliberately not run" from "canceled due to failure".
+func WorkflowSkipPropagation() WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("skip propagation", "true")
+		s.skipPropagation = true
+	}
+}
+
+// rejectedStatus returns the status of a Step rejected by its Condition, see WorkflowSkipPropagation.
+func (s *Workflow) rejectedStatus(dependees []StepReader) StepStatus {
+	if !s.skipPropagation {
+		return StepStatusCanceled
+	}
+	skipped := false
+	for _, e := range dependees {
+		switch e.GetStatus() {
+		case StepStatusFailed, StepStatusCanceled:
+			return StepStatusCanceled
+		case StepStatusSkipped:
+			skipped = true
+		}
+	}
+	if skipped {
+		return StepStatusSkipped
+	}
+	return StepStatusCanceled
+}
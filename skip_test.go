@@ -0,0 +1,44 @@
+package pl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestWorkflowSkipPropagation(t *testing.T) {
+	never := func(context.Context) bool { return false }
+	for _, tc := range []struct {
+		name string
+		opts []pl.WorkflowOption
+		want pl.StepStatus // of deploy and notify
+	}{
+		{"default", nil, pl.StepStatusSucceeded},
+		{"propagation", []pl.WorkflowOption{pl.WorkflowSkipPropagation()}, pl.StepStatusSkipped},
+		{"explicit default condition", []pl.WorkflowOption{
+			pl.WorkflowSkipPropagation(),
+			pl.WorkflowDefaultCondition(pl.Succeeded),
+		}, pl.StepStatusSucceeded},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			build, lint, deploy, notify, release := succeed("build"), fail("lint"), succeed("deploy"), succeed("notify"), succeed("release")
+			w := new(pl.Workflow).WithOptions(tc.opts...).Add(
+				pl.Step(build).When(never),
+				pl.Step(deploy).ExtraDependsOn(build),
+				pl.Step(notify).ExtraDependsOn(deploy),
+				pl.Step(release).ExtraDependsOn(build, lint),
+			)
+			_ = w.Run(context.Background())
+			for step, want := range map[pl.StepReader]pl.StepStatus{
+				deploy:  tc.want,
+				notify:  tc.want,
+				release: pl.StepStatusCanceled, // a Failed Dependee still cancels
+			} {
+				if got := step.GetStatus(); got != want {
+					t.Errorf("want %s %s, got %s", step, want, got)
+				}
+			}
+		})
+	}
+}
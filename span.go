@@ -0,0 +1,123 @@
+package pl
+
+import "context"
+
+// SpanTracer starts the spans of a Workflow run and its Steps, see WorkflowSpanTracer.
+//
+// It's the minimal surface of a tracing library, e.g. an adapter of OpenTelemetry's trace.Tracer,
+// so the Workflow doesn't depend on any of them.
+type SpanTracer interface {
+	// Start starts a span named name as a child of the span in ctx,
+	// and returns a context carrying the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by SpanTracer.
+type Span interface {
+	// AddEvent records an event in the span, e.g. why a Step is Canceled or Skipped.
+	AddEvent(name string)
+	// End ends the span with the terminated status, the number of attempts and the error.
+	End(status StepStatus, attempts uint64, err error)
+}
+
+// WorkflowSpanTracer traces the Workflow with the SpanTracer.
+//
+// Each run is a span named "Workflow", as a child of the span in the context passed to Run.
+// Each started Step is a child span named by its String(), covering Input, Do and all retries,
+// the context passed to Step's Do and Input functions carries it, so the Step can start its own children.
+// Steps Canceled or Skipped without starting are short spans with an event explaining why.
+//
+// A nil tracer means no tracing, unless the Workflow runs in a Step of another Workflow, e.g. Stage,
+// then it inherits the tracer of that Workflow, and its run span nests under the Step's span.
+func WorkflowSpanTracer(t SpanTracer) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("span tracer", summarizeSet(t != nil))
+		s.spanTracer = t
+	}
+}
+
+// inheritSpanTracer decides the tracer of a run,
+// a Workflow without tracer inherits the one of the Workflow running it, e.g. Stage.
+func (s *Workflow) inheritSpanTracer(ctx context.Context) {
+	s.runSpanTracer = s.spanTracer
+	if s.runSpanTracer == nil {
+		if parent := workflowFromContext(ctx); parent != nil && parent != s {
+			s.runSpanTracer = parent.runSpanTracer
+		}
+	}
+}
+
+// startSpan starts the span of a Step, returns the context carrying it.
+func (s *Workflow) startSpan(ctx context.Context, step StepDoer) context.Context {
+	if s.runSpanTracer == nil {
+		return ctx
+	}
+	ctx, span := s.runSpanTracer.Start(ctx, step.String())
+	s.errsMu.Lock()
+	s.recordOf(step).span = span
+	s.errsMu.Unlock()
+	return ctx
+}
+
+// endSpan ends the span of a terminated Step,
+// a Step terminated without starting gets a short span with the reason.
+func (s *Workflow) endSpan(ctx context.Context, step StepDoer, status StepStatus, err error) {
+	if s.runSpanTracer == nil {
+		return
+	}
+	s.errsMu.Lock()
+	r := s.recordOf(step)
+	span, attempts := r.span, r.Attempts
+	r.span = nil
+	s.errsMu.Unlock()
+	if span == nil {
+		_, span = s.runSpanTracer.Start(ctx, step.String())
+		span.AddEvent(notStartedReason(status, err))
+	}
+	span.End(status, attempts, err)
+}
+
+// notStartedReason explains why a Step terminated without starting.
+func notStartedReason(status StepStatus, err error) string {
+	switch {
+	case status == StepStatusSkipped:
+		return "skipped by When"
+	case status == StepStatusCanceled && err == nil:
+		return "canceled by Condition"
+	case status == StepStatusCanceled:
+		return "canceled: " + err.Error()
+	case status == StepStatusSucceeded:
+		return "reused the Output of a prior run"
+	case err != nil:
+		return "failed before start: " + err.Error()
+	}
+	return string(status)
+}
+
+// WorkflowTracer sets a hook to trace the Steps, e.g. with OpenTelemetry, zipkin or a no-op,
+// start is called before a Step starts and returns the context carrying the span, and end to end it with the Step's error.
+//
+// The span of a Step covers its Input, Do and all retries, its parent is the span in the context passed to Run.
+// start is called again for each attempt with the context carrying the Step's span and the attempt,
+// see AttemptFromContext, the tracer can start a child span per attempt, or return the context and a nil end to skip it.
+func WorkflowTracer(start func(ctx context.Context, step StepReader) (context.Context, func(err error))) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("tracer", summarizeSet(start != nil))
+		s.tracer = start
+	}
+}
+
+// trace starts a span via the hook set by WorkflowTracer, returns the context carrying it and the function to end it.
+func (s *Workflow) trace(ctx context.Context, step StepReader) (context.Context, func(error)) {
+	if s.tracer == nil {
+		return ctx, func(error) {}
+	}
+	spanCtx, end := s.tracer(ctx, step)
+	if spanCtx == nil {
+		spanCtx = ctx
+	}
+	if end == nil {
+		end = func(error) {}
+	}
+	return spanCtx, end
+}
@@ -0,0 +1,157 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/xuxife/pl"
+)
+
+// recordTracer records the finished spans by path, e.g. "Workflow/stage/Workflow/inner".
+type recordTracer struct {
+	mu    sync.Mutex
+	spans map[string]*recordSpan
+}
+
+type recordSpan struct {
+	t        *recordTracer
+	path     string
+	events   []string
+	status   pl.StepStatus
+	attempts uint64
+	err      error
+}
+
+type spanKey struct{}
+
+func (t *recordTracer) Start(ctx context.Context, name string) (context.Context, pl.Span) {
+	path := name
+	if parent, ok := ctx.Value(spanKey{}).(*recordSpan); ok {
+		path = parent.path + "/" + name
+	}
+	span := &recordSpan{t: t, path: path}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *recordSpan) AddEvent(name string) { s.events = append(s.events, name) }
+
+func (s *recordSpan) End(status pl.StepStatus, attempts uint64, err error) {
+	s.status, s.attempts, s.err = status, attempts, err
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	if s.t.spans == nil {
+		s.t.spans = make(map[string]*recordSpan)
+	}
+	s.t.spans[s.path] = s
+}
+
+func (t *recordTracer) paths() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var paths []string
+	for p := range t.spans {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestWorkflowSpanTracer(t *testing.T) {
+	tracer := new(recordTracer)
+	a := pl.FuncNoInOut("a", func(ctx context.Context) error {
+		// the Step starts its own child span
+		_, span := tracer.Start(ctx, "child")
+		span.End(pl.StepStatusSucceeded, 0, nil)
+		return nil
+	})
+	inner := succeed("inner")
+	stage := &pl.Stage[struct{}, struct{}]{Name: "stage", Workflow: new(pl.Workflow).Add(pl.Step(inner))}
+	b, c, d := fail("b"), succeed("c"), succeed("d")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowSpanTracer(tracer)).
+		Add(
+			pl.Step(a),
+			pl.Step(stage).ExtraDependsOn(a),
+			pl.Step(c).ExtraDependsOn(b),
+			pl.Step(d).When(func(context.Context) bool { return false }),
+		)
+	_ = w.Run(context.Background())
+
+	want := []string{
+		"Workflow",
+		"Workflow/a",
+		"Workflow/a/child",
+		"Workflow/b",
+		"Workflow/c",
+		"Workflow/d",
+		"Workflow/stage",
+		"Workflow/stage/Workflow",
+		"Workflow/stage/Workflow/inner",
+	}
+	if got := tracer.paths(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want spans %v, got %v", want, got)
+	}
+	spans := tracer.spans
+	if s := spans["Workflow"]; s.status != pl.StepStatusFailed || s.err == nil {
+		t.Errorf("want run span Failed with error, got %s %v", s.status, s.err)
+	}
+	if s := spans["Workflow/b"]; s.status != pl.StepStatusFailed || s.attempts != 1 || s.err == nil {
+		t.Errorf("want b Failed after 1 attempt, got %s %d %v", s.status, s.attempts, s.err)
+	}
+	if s := spans["Workflow/stage/Workflow/inner"]; s.status != pl.StepStatusSucceeded {
+		t.Errorf("want inner Succeeded, got %s", s.status)
+	}
+	if s := spans["Workflow/c"]; s.status != pl.StepStatusCanceled || !reflect.DeepEqual(s.events, []string{"canceled by Condition"}) {
+		t.Errorf("want c Canceled by Condition, got %s %v", s.status, s.events)
+	}
+	if s := spans["Workflow/d"]; s.status != pl.StepStatusSkipped || !reflect.DeepEqual(s.events, []string{"skipped by When"}) {
+		t.Errorf("want d Skipped by When, got %s %v", s.status, s.events)
+	}
+}
+
+func TestWorkflowTracer(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		spans []string
+	)
+	tracer := func(ctx context.Context, step pl.StepReader) (context.Context, func(error)) {
+		name := step.String()
+		if attempt := pl.AttemptFromContext(ctx); attempt > 0 {
+			name = fmt.Sprintf("%s/attempt-%d", ctx.Value(spanKey{}), attempt)
+		}
+		return context.WithValue(ctx, spanKey{}, name), func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			spans = append(spans, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	failed := 0
+	flaky := pl.FuncNoInOut("flaky", func(context.Context) error {
+		if failed < 2 {
+			failed++
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowTracer(tracer)).
+		Add(pl.Step(flaky).Retry(pl.RetryOption{Backoff: &backoff.ZeroBackOff{}, Attempts: 3}))
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"flaky/attempt-1: flaky",
+		"flaky/attempt-2: flaky",
+		"flaky/attempt-3: <nil>",
+		"flaky: <nil>",
+	}
+	if !reflect.DeepEqual(spans, want) {
+		t.Errorf("want spans %q, got %q", want, spans)
+	}
+}
@@ -3,6 +3,8 @@ package pl
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // Stage wraps a Workflow into a Step.
@@ -24,6 +26,24 @@ type Stage[I, O any] struct {
 	Workflow  *Workflow
 	SetInput  func(I)  // SetInput sets the inside Steps' Input from Stage Input
 	SetOutput func(*O) // SetOutput sets the Stage Output from the inside Steps' Output
+	// Atomic makes the Stage all-or-nothing:
+	// once an inside Step failed, the remaining inside Steps are canceled (as WorkflowFailFast),
+	// then the compensations (see Compensate) of the Succeeded inside Steps are run
+	// in the reverse order of their termination, and the Stage fails with ErrAtomic.
+	//
+	// Nesting atomic Stages is not supported, an atomic Stage fails with ErrNestedAtomic
+	// if its Workflow contains another atomic Stage, at any depth of non-atomic Stages.
+	//
+	// The options of Workflow are not changed, fail-fast only applies to the Stage's run.
+	// Compensations run without the cancellation of the Stage's context,
+	// so they still run when the Stage failed because its context is done.
+	Atomic bool
+	// RetryInnerFailedOnly makes a retry of the Stage (see Retry) only re-run the inside Steps
+	// not Succeeded in the last attempt, see Workflow.ResetFailed,
+	// so the Succeeded inside Steps run at most once across the retries.
+	//
+	// Otherwise each attempt of the Stage resets and re-runs all the inside Steps.
+	RetryInnerFailedOnly bool
 }
 
 func (s *Stage[I, O]) String() string {
@@ -43,5 +63,137 @@ func (s *Stage[I, O]) Do(ctx context.Context) error {
 	if s.SetInput != nil {
 		s.SetInput(s.In)
 	}
-	return s.Workflow.Run(ctx)
+	if s.Workflow.HasRun() { // retried
+		reset := s.Workflow.Reset
+		if s.RetryInnerFailedOnly {
+			reset = s.Workflow.ResetFailed
+		}
+		if err := reset(); err != nil {
+			return err
+		}
+	}
+	if !s.Atomic {
+		return s.Workflow.Run(ctx)
+	}
+	if nested := nestedAtomic(s.Workflow); nested != nil {
+		return ErrNestedAtomic{Stage: s, Nested: nested}
+	}
+	err := s.Workflow.run(ctx, runOptions{failFast: true})
+	if err == nil {
+		return nil
+	}
+	return &ErrAtomic{
+		Err: err,
+		// compensate even the Stage failed because ctx is done
+		Compensations: s.Workflow.compensate(context.WithoutCancel(ctx)),
+	}
+}
+
+func (s *Stage[I, O]) isAtomic() bool {
+	return s.Atomic
+}
+
+func (s *Stage[I, O]) innerWorkflow() *Workflow {
+	return s.Workflow
+}
+
+type atomicStage interface {
+	isAtomic() bool
+	innerWorkflow() *Workflow
+}
+
+// nestedAtomic returns an atomic Stage in the Workflow,
+// including the ones inside non-atomic Stages at any depth.
+func nestedAtomic(w *Workflow) StepReader {
+	if w == nil {
+		return nil
+	}
+	for _, step := range w.steps {
+		a, ok := step.(atomicStage)
+		if !ok {
+			continue
+		}
+		if a.isAtomic() {
+			return step
+		}
+		if nested := nestedAtomic(a.innerWorkflow()); nested != nil {
+			return nested
+		}
+	}
+	return nil
+}
+
+// compensate runs the compensations of Succeeded Steps in the reverse order of their termination,
+// returns the failed compensations.
+func (s *Workflow) compensate(ctx context.Context) map[StepReader]error {
+	var succeeded []StepState
+	for _, state := range s.States() {
+		if state.Status == StepStatusSucceeded {
+			succeeded = append(succeeded, state)
+		}
+	}
+	sort.SliceStable(succeeded, func(i, j int) bool {
+		return succeeded[i].FinishedAt.After(succeeded[j].FinishedAt)
+	})
+	errs := map[StepReader]error{}
+	for _, state := range succeeded {
+		step := state.Step.(StepDoer)
+		fn := step.getCompensate()
+		if fn == nil {
+			continue
+		}
+		if err := catchPanicAsError(func() error {
+			return fn(ctx)
+		}); err != nil {
+			errs[step] = err
+		}
+	}
+	return errs
+}
+
+// ErrAtomic reports the failure of an atomic Stage,
+// with the failures of the compensations.
+type ErrAtomic struct {
+	Err           error                // the original failure of the Stage's Workflow
+	Compensations map[StepReader]error // the failed compensations
+}
+
+func (e *ErrAtomic) Error() string {
+	builder := new(strings.Builder)
+	builder.WriteString(fmt.Sprintf("ErrAtomic: %s", e.Err.Error()))
+	for _, step := range e.compensated() {
+		builder.WriteString(fmt.Sprintf("\ncompensate %s: %s", step, e.Compensations[step].Error()))
+	}
+	return builder.String()
+}
+
+// Unwrap returns the original failure, then the failed compensations sorted by Step name.
+func (e *ErrAtomic) Unwrap() []error {
+	errs := []error{e.Err}
+	for _, step := range e.compensated() {
+		errs = append(errs, e.Compensations[step])
+	}
+	return errs
+}
+
+// compensated returns the Steps with failed compensation, sorted by name.
+func (e *ErrAtomic) compensated() []StepReader {
+	steps := make([]StepReader, 0, len(e.Compensations))
+	for step := range e.Compensations {
+		steps = append(steps, step)
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].String() < steps[j].String()
+	})
+	return steps
+}
+
+// ErrNestedAtomic indicates an atomic Stage contains another atomic Stage.
+type ErrNestedAtomic struct {
+	Stage  StepReader
+	Nested StepReader
+}
+
+func (e ErrNestedAtomic) Error() string {
+	return fmt.Sprintf("ErrNestedAtomic: atomic Stage %s contains atomic Stage %s", e.Stage, e.Nested)
 }
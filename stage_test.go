@@ -0,0 +1,183 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/xuxife/pl"
+)
+
+func TestAtomicStage(t *testing.T) {
+	var compensated []string
+	a, d, b := succeed("a"), succeed("d"), fail("b")
+	inner := new(pl.Workflow).Add(
+		pl.Step(a).Compensate(func(context.Context) error {
+			compensated = append(compensated, "a")
+			return nil
+		}),
+		pl.Step(d).ExtraDependsOn(a).Compensate(func(context.Context) error {
+			compensated = append(compensated, "d")
+			return fmt.Errorf("compensate d failed")
+		}),
+		pl.Step(b).ExtraDependsOn(d),
+	)
+	stage := &pl.Stage[struct{}, struct{}]{Name: "stage", Workflow: inner, Atomic: true}
+	after := succeed("after")
+	w := new(pl.Workflow).Add(pl.Step(after).ExtraDependsOn(stage))
+	_ = w.Run(context.Background())
+
+	if want := []string{"d", "a"}; !reflect.DeepEqual(compensated, want) {
+		t.Errorf("want compensations in reverse order %v, got %v", want, compensated)
+	}
+	var aerr *pl.ErrAtomic
+	if !errors.As(w.Err()[stage], &aerr) {
+		t.Fatalf("want ErrAtomic, got %v", w.Err()[stage])
+	}
+	if aerr.Compensations[d] == nil || len(aerr.Compensations) != 1 {
+		t.Errorf("want the failed compensation of d, got %v", aerr.Compensations)
+	}
+	if got := after.GetStatus(); got != pl.StepStatusCanceled {
+		t.Errorf("want Depender of the atomic Stage Canceled, got %s", got)
+	}
+}
+
+func TestAtomicStageNested(t *testing.T) {
+	nested := &pl.Stage[struct{}, struct{}]{
+		Name:     "nested",
+		Workflow: new(pl.Workflow).Add(pl.Step(succeed("a"))),
+		Atomic:   true,
+	}
+	stage := &pl.Stage[struct{}, struct{}]{
+		Name:     "stage",
+		Workflow: new(pl.Workflow).Add(pl.Step(nested)),
+		Atomic:   true,
+	}
+	w := new(pl.Workflow).Add(pl.Step(stage))
+	_ = w.Run(context.Background())
+	var nerr pl.ErrNestedAtomic
+	if !errors.As(w.Err()[stage], &nerr) {
+		t.Errorf("want ErrNestedAtomic, got %v", w.Err()[stage])
+	}
+}
+
+func TestAtomicStageNestedDeep(t *testing.T) {
+	nested := &pl.Stage[struct{}, struct{}]{
+		Name:     "nested",
+		Workflow: new(pl.Workflow).Add(pl.Step(succeed("a"))),
+		Atomic:   true,
+	}
+	middle := &pl.Stage[struct{}, struct{}]{
+		Name:     "middle",
+		Workflow: new(pl.Workflow).Add(pl.Step(nested)),
+	}
+	stage := &pl.Stage[struct{}, struct{}]{
+		Name:     "stage",
+		Workflow: new(pl.Workflow).Add(pl.Step(middle)),
+		Atomic:   true,
+	}
+	w := new(pl.Workflow).Add(pl.Step(stage))
+	_ = w.Run(context.Background())
+	var nerr pl.ErrNestedAtomic
+	if !errors.As(w.Err()[stage], &nerr) || nerr.Nested != nested {
+		t.Errorf("want ErrNestedAtomic through a non-atomic Stage, got %v", w.Err()[stage])
+	}
+}
+
+func TestAtomicStageKeepsOptions(t *testing.T) {
+	b, after := fail("b"), succeed("after")
+	inner := new(pl.Workflow).Add(pl.Step(after).ExtraDependsOn(b).Condition(pl.Always))
+	stage := &pl.Stage[struct{}, struct{}]{Name: "stage", Workflow: inner, Atomic: true}
+	_ = new(pl.Workflow).Add(pl.Step(stage)).Run(context.Background())
+	if got := after.GetStatus(); got != pl.StepStatusCanceled {
+		t.Errorf("want fail-fast in atomic Stage, got %s", got)
+	}
+
+	if err := inner.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	_ = inner.Run(context.Background())
+	if got := after.GetStatus(); got != pl.StepStatusSucceeded {
+		t.Errorf("want the inner Workflow not fail-fast on its own, got %s", got)
+	}
+}
+
+func TestAtomicStageCompensateAfterCanceled(t *testing.T) {
+	var compensateErr error
+	started := make(chan struct{})
+	a := succeed("a")
+	b := pl.FuncNoInOut("b", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	inner := new(pl.Workflow).Add(
+		pl.Step(a).Compensate(func(ctx context.Context) error {
+			compensateErr = ctx.Err()
+			return nil
+		}),
+		pl.Step(b).ExtraDependsOn(a),
+	)
+	stage := &pl.Stage[struct{}, struct{}]{Name: "stage", Workflow: inner, Atomic: true}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_ = new(pl.Workflow).Add(pl.Step(stage)).Run(ctx)
+	if compensateErr != nil {
+		t.Errorf("want compensation run with a live context, got %v", compensateErr)
+	}
+}
+
+func TestErrAtomicSorted(t *testing.T) {
+	a, b, c := succeed("a"), succeed("b"), succeed("c")
+	err := &pl.ErrAtomic{
+		Err:           errors.New("failed"),
+		Compensations: map[pl.StepReader]error{c: errors.New("c"), a: errors.New("a"), b: errors.New("b")},
+	}
+	for i := 0; i < 5; i++ {
+		if got, want := err.Error(), "ErrAtomic: failed\ncompensate a: a\ncompensate b: b\ncompensate c: c"; got != want {
+			t.Fatalf("want %q, got %q", want, got)
+		}
+	}
+}
+
+func TestStageRetryInnerFailedOnly(t *testing.T) {
+	for _, failedOnly := range []bool{true, false} {
+		var aRuns, bRuns atomic.Int32
+		a := pl.FuncNoInOut("a", func(context.Context) error {
+			aRuns.Add(1)
+			return nil
+		})
+		b := pl.FuncNoInOut("b", func(context.Context) error {
+			if bRuns.Add(1) == 1 {
+				return fmt.Errorf("b failed")
+			}
+			return nil
+		})
+		stage := &pl.Stage[struct{}, struct{}]{
+			Name:                 "stage",
+			Workflow:             new(pl.Workflow).Add(pl.Step(b).ExtraDependsOn(a)),
+			RetryInnerFailedOnly: failedOnly,
+		}
+		w := new(pl.Workflow).Add(
+			pl.Step(stage).Retry(pl.RetryOption{Backoff: &backoff.ZeroBackOff{}, Attempts: 1}),
+		)
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatalf("want Stage succeeded after retry, got %v", err)
+		}
+		wantA := int32(2)
+		if failedOnly {
+			wantA = 1
+		}
+		if aRuns.Load() != wantA || bRuns.Load() != 2 {
+			t.Errorf("RetryInnerFailedOnly=%v: want a run %d times and b 2 times, got %d and %d",
+				failedOnly, wantA, aRuns.Load(), bRuns.Load())
+		}
+	}
+}
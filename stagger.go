@@ -0,0 +1,52 @@
+package pl
+
+import "time"
+
+// WorkflowRootStagger spaces out the starts of the root Steps (without Dependee) by d,
+// the i-th root Step in the order of being added starts no earlier than i*d after Run starts,
+// e.g. to avoid a thundering herd of many root Steps fetching tokens at once.
+//
+// It's independent of WorkflowMaxConcurrency, other Steps are unaffected.
+// The stagger is driven by the Clock, see WorkflowClock.
+// A root Step waiting for its turn is Canceled if the Workflow stops.
+func WorkflowRootStagger(d time.Duration) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("root stagger", summarizeDuration(d))
+		s.rootStagger = d
+	}
+}
+
+// startStagger decides when each root Step can start in this run, and wakes the scheduler at those times,
+// returns the function to stop the timers.
+func (s *Workflow) startStagger() (stop func()) {
+	s.rootNotBefore = nil
+	if s.rootStagger <= 0 {
+		return func() {}
+	}
+	s.rootNotBefore = make(map[StepDoer]time.Time)
+	now, wake := s.clock().Now(), s.wake
+	var timers []Timer
+	i := 0
+	for _, step := range s.steps {
+		if len(s.deps.UpstreamOf(step)) > 0 || step.GetStatus().IsTerminated() {
+			continue
+		}
+		if i > 0 {
+			d := time.Duration(i) * s.rootStagger
+			s.rootNotBefore[step] = now.Add(d)
+			timers = append(timers, s.clock().AfterFunc(d, func() { wakeUp(wake) }))
+		}
+		i++
+	}
+	return func() {
+		for _, t := range timers {
+			t.Stop()
+		}
+	}
+}
+
+// staggered returns whether the root Step is waiting for its turn, see WorkflowRootStagger.
+func (s *Workflow) staggered(step StepDoer) bool {
+	notBefore, ok := s.rootNotBefore[step]
+	return ok && s.clock().Now().Before(notBefore)
+}
@@ -0,0 +1,63 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+	"github.com/xuxife/pl/pltest"
+)
+
+func TestWorkflowRootStagger(t *testing.T) {
+	clock := pltest.NewClock(time.Unix(0, 0))
+	a, b, c, d := succeed("a"), succeed("b"), succeed("c"), succeed("d")
+	w := new(pl.Workflow).
+		WithOptions(
+			pl.WorkflowClock(clock),
+			pl.WorkflowRootStagger(time.Second),
+		).
+		Add(
+			pl.Steps(a, b, c),
+			pl.Step(d).ExtraDependsOn(a),
+		)
+	waitFor := func(step pl.StepReader, status pl.StepStatus) {
+		t.Helper()
+		for deadline := time.Now().Add(time.Second); step.GetStatus() != status; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("want %s %s, got %s", step, status, step.GetStatus())
+			}
+		}
+	}
+
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	clock.BlockUntilTimers(2) // the turns of b and c
+	waitFor(a, pl.StepStatusSucceeded)
+	waitFor(d, pl.StepStatusSucceeded) // not a root, unaffected
+	if got := b.GetStatus(); got != pl.StepStatusPending {
+		t.Errorf("want b Pending before its turn, got %s", got)
+	}
+
+	clock.Advance(time.Second)
+	waitFor(b, pl.StepStatusSucceeded)
+	if got := c.GetStatus(); got != pl.StepStatusPending {
+		t.Errorf("want c Pending before its turn, got %s", got)
+	}
+	if start, _ := b.(pl.StepTimer).GetTimes(); !start.Equal(time.Unix(1, 0)) {
+		t.Errorf("want b started 1s after Run, got %s", start)
+	}
+
+	// cancel in the stagger window
+	w.Cancel()
+	if err := <-done; !errors.Is(err, pl.ErrWorkflowCanceled) {
+		t.Errorf("want ErrWorkflowCanceled, got %v", err)
+	}
+	if got := c.GetStatus(); got != pl.StepStatusCanceled {
+		t.Errorf("want c Canceled, got %s", got)
+	}
+	if got := clock.Timers(); got != 0 {
+		t.Errorf("want the stagger timers stopped after Run, got %d", got)
+	}
+}
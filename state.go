@@ -0,0 +1,238 @@
+package pl
+
+import (
+	"sort"
+	"time"
+)
+
+// stepRecord is what Workflow records for a Step during a run.
+type stepRecord struct {
+	ReadyAt    time.Time // when all Dependees terminated and Condition passed
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Attempts   uint64 // the number of attempts of Do, including the first one
+	Timings    StepTimings
+	Phases     []PhaseSpan   // every Phase run, across attempts
+	Phase      Phase         // the Phase where the last attempt failed
+	Output     any           // snapshotted in PhaseOutput
+	span       Span          // the span of the started Step in this run, see WorkflowSpanTracer
+	pending    PendingReason // why the Pending Step was passed over, see PendingSummary
+}
+
+// StepState is a snapshot of a Step in Workflow.
+type StepState struct {
+	Step       StepReader
+	Status     StepStatus
+	Err        error
+	StartedAt  time.Time // zero if the Step has not started, or is Skipped / Canceled
+	FinishedAt time.Time // zero if the Step has not terminated
+	Phase      Phase     // the Phase where the last attempt failed, empty if it didn't fail in a Phase
+	Output     any       // the Output snapshotted in PhaseOutput after Do succeeded, nil if not snapshotted
+}
+
+// recordOf returns the record of a Step, creates one if absent.
+// Caller should hold errsMu.
+func (s *Workflow) recordOf(step StepDoer) *stepRecord {
+	r, ok := s.records[step]
+	if !ok {
+		r = &stepRecord{Timings: make(StepTimings)}
+		s.records[step] = r
+	}
+	return r
+}
+
+func (s *Workflow) recordReady(step StepDoer) {
+	s.errsMu.Lock()
+	defer s.errsMu.Unlock()
+	s.recordOf(step).ReadyAt = s.clock().Now()
+}
+
+func (s *Workflow) recordStart(step StepDoer) {
+	s.errsMu.Lock()
+	defer s.errsMu.Unlock()
+	now := s.clock().Now()
+	r := s.recordOf(step)
+	r.StartedAt = now
+	r.pending = ""
+	step.setStartedAt(now)
+}
+
+func (s *Workflow) recordFinish(step StepDoer) {
+	s.errsMu.Lock()
+	defer s.errsMu.Unlock()
+	now := s.clock().Now()
+	s.recordOf(step).FinishedAt = now
+	step.setFinishedAt(now)
+	step.setErr(s.errs[step])
+}
+
+func (s *Workflow) recordAttempt(step StepDoer, attempt uint64) {
+	s.errsMu.Lock()
+	defer s.errsMu.Unlock()
+	r := s.recordOf(step)
+	r.Attempts = attempt
+	r.Phase = "" // a new attempt, the failed Phase is of the last attempt
+}
+
+// States returns a snapshot of all Steps in Workflow, sorted by name.
+//
+// States is safe to call while the Workflow is running,
+// e.g. to report progress from another goroutine.
+func (s *Workflow) States() []StepState {
+	s.errsMu.RLock()
+	defer s.errsMu.RUnlock()
+	states := make([]StepState, 0, len(s.deps))
+	for step := range s.deps {
+		state := StepState{
+			Step:   step,
+			Status: step.GetStatus(),
+			Err:    s.errs[step],
+		}
+		if r, ok := s.records[step]; ok {
+			state.StartedAt = r.StartedAt
+			state.FinishedAt = r.FinishedAt
+			state.Phase = r.Phase
+			state.Output = r.Output
+		}
+		states = append(states, state)
+	}
+	sort.SliceStable(states, func(i, j int) bool {
+		return states[i].Step.String() < states[j].Step.String()
+	})
+	return states
+}
+
+// StepSnapshot is a JSON-marshalable snapshot of a Step in Workflow, see Workflow.Snapshot.
+type StepSnapshot struct {
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	Err        string     `json:"error,omitempty"`
+	Phase      Phase      `json:"phase,omitempty"` // the Phase where the Step failed
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Snapshot returns a JSON-marshalable snapshot of all Steps in Workflow, sorted by name,
+// e.g. to persist the progress or report it to a monitoring endpoint.
+//
+// Snapshot is safe to call while the Workflow is running.
+func (s *Workflow) Snapshot() []StepSnapshot {
+	states := s.States()
+	snapshots := make([]StepSnapshot, 0, len(states))
+	for _, state := range states {
+		snapshots = append(snapshots, state.snapshot())
+	}
+	return snapshots
+}
+
+func (state StepState) snapshot() StepSnapshot {
+	snapshot := StepSnapshot{
+		Name:   state.Step.String(),
+		Status: state.Status.String(),
+	}
+	if state.Err != nil {
+		snapshot.Err = state.Err.Error()
+		snapshot.Phase = state.Phase
+	}
+	if !state.StartedAt.IsZero() {
+		startedAt := state.StartedAt
+		snapshot.StartedAt = &startedAt
+	}
+	if !state.FinishedAt.IsZero() {
+		finishedAt := state.FinishedAt
+		snapshot.FinishedAt = &finishedAt
+	}
+	return snapshot
+}
+
+// StepProfile is the performance profile of a Step in the last run, see Workflow.Profile.
+type StepProfile struct {
+	Duration time.Duration // from started to finished, zero if the Step never started
+	Attempts uint64        // the number of attempts, including the first one
+	Status   StepStatus
+}
+
+// Profile returns how long each Step took and how many attempts it consumed in the last run,
+// for performance analysis.
+//
+// All Steps in Workflow appear, Skipped and Canceled Steps have zero Duration and Attempts.
+// A Step still running lasts until now.
+func (s *Workflow) Profile() map[StepReader]StepProfile {
+	now := s.clock().Now()
+	s.errsMu.RLock()
+	defer s.errsMu.RUnlock()
+	profile := make(map[StepReader]StepProfile, len(s.deps))
+	for step := range s.deps {
+		p := StepProfile{Status: step.GetStatus()}
+		if r, ok := s.records[step]; ok {
+			p.Attempts = r.Attempts
+			if !r.StartedAt.IsZero() {
+				end := r.FinishedAt
+				if end.IsZero() {
+					end = now
+				}
+				p.Duration = end.Sub(r.StartedAt)
+			}
+		}
+		profile[step] = p
+	}
+	return profile
+}
+
+// DefaultStarvationThreshold is the max time a Step can wait to start after being ready,
+// before it's reported by StarvationReport, see WorkflowStarvationThreshold.
+var DefaultStarvationThreshold = time.Second
+
+// WorkflowStarvationThreshold sets the threshold of StarvationReport for this Workflow,
+// it overrides DefaultStarvationThreshold.
+func WorkflowStarvationThreshold(d time.Duration) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("starvation threshold", summarizeDuration(d))
+		s.starvation = d
+	}
+}
+
+// StarvationReport returns the Steps ready (all Dependees terminated, Condition and When passed)
+// for longer than the threshold before starting, the longest waited first,
+// e.g. Steps stuck behind WorkflowMaxConcurrency or WorkflowAdmissionControl.
+//
+// A Step still waiting is reported once it has waited longer than the threshold.
+// StarvationReport is safe to call while the Workflow is running.
+func (s *Workflow) StarvationReport() []StepReader {
+	threshold := s.starvation
+	if threshold == 0 {
+		threshold = DefaultStarvationThreshold
+	}
+	now := s.clock().Now()
+	s.errsMu.RLock()
+	defer s.errsMu.RUnlock()
+	type starved struct {
+		step   StepReader
+		waited time.Duration
+	}
+	var steps []starved
+	for _, step := range s.steps {
+		r, ok := s.records[step]
+		if !ok || r.ReadyAt.IsZero() {
+			continue
+		}
+		left := r.StartedAt // when the Step left the ready queue
+		if left.IsZero() {
+			left = r.FinishedAt // e.g. failed by StartDeadline, or Canceled
+		}
+		if left.IsZero() {
+			left = now
+		}
+		if waited := left.Sub(r.ReadyAt); waited > threshold {
+			steps = append(steps, starved{step, waited})
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].waited > steps[j].waited
+	})
+	report := make([]StepReader, 0, len(steps))
+	for _, s := range steps {
+		report = append(report, s.step)
+	}
+	return report
+}
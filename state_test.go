@@ -0,0 +1,169 @@
+package pl_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/xuxife/pl"
+	"github.com/xuxife/pl/pltest"
+)
+
+func TestWorkflowStates(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	running := pl.FuncNoInOut("running", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	skipped := succeed("skipped")
+	w := new(pl.Workflow).Add(pl.Step(running), pl.Step(skipped).When(pl.Skip))
+
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	<-started
+	for _, state := range w.States() {
+		if state.Step == running && (state.Status != pl.StepStatusRunning || state.StartedAt.IsZero()) {
+			t.Errorf("want running Step Running with StartedAt, got %+v", state)
+		}
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	states := w.States()
+	if len(states) != 2 {
+		t.Fatalf("want 2 states, got %v", states)
+	}
+	for _, state := range states {
+		switch state.Step {
+		case running:
+			if state.Status != pl.StepStatusSucceeded || state.FinishedAt.Before(state.StartedAt) {
+				t.Errorf("want running Step Succeeded with timestamps, got %+v", state)
+			}
+		case skipped:
+			if state.Status != pl.StepStatusSkipped || !state.StartedAt.IsZero() || state.FinishedAt.IsZero() {
+				t.Errorf("want skipped Step only FinishedAt, got %+v", state)
+			}
+		}
+	}
+}
+
+func TestStepTimes(t *testing.T) {
+	ok, failing, canceled, skipped := succeed("ok"), fail("failing"), succeed("canceled"), succeed("skipped")
+	w := new(pl.Workflow).Add(
+		pl.Step(ok),
+		pl.Step(canceled).ExtraDependsOn(failing),
+		pl.Step(skipped).When(pl.Skip),
+	)
+	_ = w.Run(context.Background())
+	for _, step := range []pl.StepReader{ok, failing} {
+		if start, end := step.(pl.StepTimer).GetTimes(); start.IsZero() || end.Before(start) {
+			t.Errorf("want %s started and finished, got %s - %s", step, start, end)
+		}
+	}
+	for _, step := range []pl.StepReader{canceled, skipped} {
+		if start, end := step.(pl.StepTimer).GetTimes(); !start.IsZero() || end.IsZero() {
+			t.Errorf("want %s only finished, got %s - %s", step, start, end)
+		}
+	}
+
+	if err := w.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	w.Add(pl.Step(ok).When(pl.Skip))
+	_ = w.Run(context.Background())
+	if start, _ := ok.(pl.StepTimer).GetTimes(); !start.IsZero() {
+		t.Errorf("want times of last run cleared, got start %s", start)
+	}
+}
+
+func TestWorkflowSnapshot(t *testing.T) {
+	ok, failing, skipped := succeed("ok"), fail("failing"), succeed("skipped")
+	w := new(pl.Workflow).Add(pl.Steps(ok, failing), pl.Step(skipped).When(pl.Skip))
+	_ = w.Run(context.Background())
+
+	b, err := json.Marshal(w.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("want 3 Steps, got %s", b)
+	}
+	// sorted by name: failing, ok, skipped
+	for i, want := range []struct {
+		name, status string
+		hasErr       bool
+		hasStart     bool
+	}{
+		{"failing", "Failed", true, true},
+		{"ok", "Succeeded", false, true},
+		{"skipped", "Skipped", false, false},
+	} {
+		s := got[i]
+		_, hasErr := s["error"]
+		_, hasStart := s["startedAt"]
+		_, hasFinish := s["finishedAt"]
+		if s["name"] != want.name || s["status"] != want.status ||
+			hasErr != want.hasErr || hasStart != want.hasStart || !hasFinish {
+			t.Errorf("want %+v, got %v", want, s)
+		}
+	}
+}
+
+func TestWorkflowProfile(t *testing.T) {
+	attempts := 0
+	flaky := pl.FuncNoInOut("flaky", func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("flaky")
+		}
+		return nil
+	})
+	skipped := succeed("skipped")
+	w := new(pl.Workflow).Add(
+		pl.Step(flaky).Retry(pl.RetryOption{Backoff: &backoff.ZeroBackOff{}, Attempts: 5}),
+		pl.Step(skipped).When(pl.Skip),
+	)
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	profile := w.Profile()
+	if p := profile[flaky]; p.Attempts != 3 || p.Status != pl.StepStatusSucceeded || p.Duration <= 0 {
+		t.Errorf("want flaky Succeeded after 3 attempts, got %+v", p)
+	}
+	if p, ok := profile[skipped]; !ok || p.Attempts != 0 || p.Duration != 0 || p.Status != pl.StepStatusSkipped {
+		t.Errorf("want skipped Step with zero Duration and Attempts, got %+v", p)
+	}
+}
+
+func TestWorkflowStarvationReport(t *testing.T) {
+	clock := pltest.NewClock(time.Unix(0, 0))
+	long := pl.FuncNoInOut("long", func(context.Context) error {
+		clock.Advance(2 * time.Minute)
+		return nil
+	})
+	starved := succeed("starved")
+	w := new(pl.Workflow).
+		WithOptions(
+			pl.WorkflowClock(clock),
+			pl.WorkflowMaxConcurrency(1),
+			pl.WorkflowStarvationThreshold(time.Minute),
+		).
+		Add(pl.Steps(long, starved))
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	report := w.StarvationReport()
+	if len(report) != 1 || report[0] != starved {
+		t.Errorf("want only starved reported, got %v", report)
+	}
+}
@@ -0,0 +1,333 @@
+package pl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// StateStore persists the Checkpoint of a Workflow, see WorkflowStateStore.
+type StateStore interface {
+	Save(ctx context.Context, checkpoint Checkpoint) error
+	Load(ctx context.Context) (Checkpoint, error)
+}
+
+// Checkpoint is the state of a Workflow saved to StateStore.
+type Checkpoint struct {
+	// Fingerprint is of the Workflow saving the Checkpoint, Restore compares it, see Workflow.Fingerprint.
+	Fingerprint string                    `json:"fingerprint"`
+	Steps       map[string]StepCheckpoint `json:"steps"` // keyed by the key of Step
+}
+
+// StepCheckpoint is the state of a Step in Checkpoint.
+type StepCheckpoint struct {
+	Name   string     `json:"name"`
+	Status StepStatus `json:"status"`
+	// Output is the serialized Output of Succeeded Steps implementing OutputRestorer.
+	Output json.RawMessage `json:"output,omitempty"`
+}
+
+// OutputRestorer is implemented by Steps whose Output can be restored from StateStore.
+//
+// The Output of a Succeeded OutputRestorer is saved as JSON,
+// RestoreOutput receives it in Workflow.Restore, so the Depender can still receive it.
+type OutputRestorer interface {
+	RestoreOutput([]byte) error
+}
+
+// WorkflowStateStore makes the Workflow save the Checkpoint to store after Steps terminate,
+// and Workflow.Restore loads it to resume a Workflow, e.g. after the process restarts.
+//
+// Saving is in a dedicated goroutine, so a slow store doesn't delay Steps nor the scheduling,
+// the Steps terminated while saving are saved together in the next Save.
+// Run waits for the last Save before returning.
+//
+// keyFn returns the key of a Step in store, it should be unique and stable across processes,
+// the default is the Step name, i.e. String().
+//
+// A Save error doesn't affect Steps, the first one is returned from Run.
+func WorkflowStateStore(store StateStore, keyFn func(StepDoer) string) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("state store", summarizeSet(store != nil))
+		s.stateStore = store
+		s.stateKey = keyFn
+	}
+}
+
+// keyOf returns the key of Step in StateStore.
+func (s *Workflow) keyOf(step StepDoer) string {
+	if s.stateKey == nil {
+		return step.String()
+	}
+	return s.stateKey(step)
+}
+
+// stateSaver saves the Checkpoint of a run in a dedicated goroutine.
+type stateSaver struct {
+	req         chan struct{} // a pending request to save, requests are coalesced
+	stop        chan struct{}
+	done        chan struct{}       // closed when the goroutine returns
+	fingerprint string              // of the run
+	outputs     map[StepDoer][]byte // serialized Output of Succeeded Steps, saved again without marshaling
+	err         error               // the first error, read after done
+}
+
+// startSaver starts saving the Checkpoint of a run if WorkflowStateStore is set.
+func (s *Workflow) startSaver(ctx context.Context) {
+	if s.stateStore == nil {
+		s.saver = nil
+		return
+	}
+	sv := &stateSaver{
+		req:         make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		fingerprint: s.Fingerprint(),
+		outputs:     make(map[StepDoer][]byte),
+	}
+	s.saver = sv
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer close(sv.done)
+		for {
+			select {
+			case <-sv.req:
+			case <-sv.stop:
+				select {
+				case <-sv.req: // the last request before stopping
+				default:
+					return
+				}
+			}
+			s.save(ctx, sv)
+		}
+	}()
+}
+
+// stopSaver waits for the pending save, then stops the saver.
+// It returns the first error saving in this run.
+func (s *Workflow) stopSaver() error {
+	if s.saver == nil {
+		return nil
+	}
+	close(s.saver.stop)
+	<-s.saver.done
+	return s.saver.err
+}
+
+// saveState requests the saver to save the Checkpoint, it never blocks.
+func (s *Workflow) saveState() {
+	if s.saver == nil {
+		return
+	}
+	select {
+	case s.saver.req <- struct{}{}:
+	default: // a save is pending, which will include this one
+	}
+}
+
+// save saves the Checkpoint of all Steps to StateStore, only called by the saver goroutine.
+func (s *Workflow) save(ctx context.Context, sv *stateSaver) {
+	checkpoint := Checkpoint{
+		Fingerprint: sv.fingerprint,
+		Steps:       make(map[string]StepCheckpoint, len(s.steps)),
+	}
+	for _, state := range s.States() {
+		step := state.Step.(StepDoer)
+		sc := StepCheckpoint{Name: step.String(), Status: state.Status}
+		if _, ok := step.(OutputRestorer); ok && state.Status == StepStatusSucceeded {
+			data, err := sv.outputOf(state)
+			if err != nil {
+				sv.setErr(fmt.Errorf("save Output of %s: %w", step, err))
+				continue
+			}
+			sc.Output = data
+		}
+		checkpoint.Steps[s.keyOf(step)] = sc
+	}
+	if err := s.stateStore.Save(ctx, checkpoint); err != nil {
+		sv.setErr(err)
+	}
+}
+
+// outputOf returns the serialized Output of a Succeeded Step,
+// the one snapshotted in PhaseOutput, or the restored one.
+func (sv *stateSaver) outputOf(state StepState) ([]byte, error) {
+	step := state.Step.(StepDoer)
+	if data, ok := sv.outputs[step]; ok {
+		return data, nil
+	}
+	out, ok := state.Output, state.Output != nil
+	if !ok {
+		if out, ok = outputOf(step); !ok {
+			return nil, nil
+		}
+	}
+	data, err := marshalIO(out)
+	if err != nil {
+		return nil, err
+	}
+	sv.outputs[step] = data
+	return data, nil
+}
+
+func (sv *stateSaver) setErr(err error) {
+	if sv.err == nil {
+		sv.err = err
+	}
+}
+
+// ErrDefinitionChanged is returned by Restore when the Checkpoint was saved by a Workflow of another definition,
+// i.e. the Fingerprint differs, see AllowPartialRestore.
+//
+// A renamed Step (with the default key) is listed as both Removed and Added.
+// Both are empty if only the edges or the settings of Steps changed.
+type ErrDefinitionChanged struct {
+	Added   []string // the keys of Steps absent in the Checkpoint, sorted
+	Removed []string // the keys in the Checkpoint absent in the Workflow, sorted
+}
+
+func (e ErrDefinitionChanged) Error() string {
+	return fmt.Sprintf("ErrDefinitionChanged: added %v, removed %v", e.Added, e.Removed)
+}
+
+// RestoreOption configures Workflow.Restore.
+type RestoreOption func(*restoreOptions)
+
+type restoreOptions struct {
+	partial bool
+}
+
+// AllowPartialRestore makes Restore restore the Steps matched by key even when the definition changed,
+// instead of returning ErrDefinitionChanged, the Steps not in the Checkpoint are left Pending.
+func AllowPartialRestore() RestoreOption {
+	return func(o *restoreOptions) {
+		o.partial = true
+	}
+}
+
+// Restore loads the Checkpoint from StateStore, and marks the Steps Succeeded in store as Succeeded,
+// with their Output restored if they implement OutputRestorer.
+// The next Run only runs the other Steps.
+//
+// Restore returns ErrDefinitionChanged if the Checkpoint was saved by a Workflow of another definition,
+// unless AllowPartialRestore.
+//
+// Restore should be called before Run, it returns ErrWorkflowHasRun if the Workflow has run,
+// and ErrWorkflowIsRunning if the Workflow is running.
+func (s *Workflow) Restore(ctx context.Context, opts ...RestoreOption) error {
+	if s.stateStore == nil {
+		return fmt.Errorf("Restore: no StateStore, see WorkflowStateStore")
+	}
+	var o restoreOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !s.isRunning.TryLock() {
+		return ErrWorkflowIsRunning
+	}
+	defer s.isRunning.Unlock()
+	if s.HasRun() {
+		return ErrWorkflowHasRun
+	}
+	checkpoint, err := s.stateStore.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if checkpoint.Fingerprint != "" && checkpoint.Fingerprint != s.Fingerprint() && !o.partial {
+		return s.definitionChanged(checkpoint)
+	}
+	var restored []StepDoer
+	for _, step := range s.steps {
+		sc, ok := checkpoint.Steps[s.keyOf(step)]
+		if !ok || sc.Status != StepStatusSucceeded {
+			continue
+		}
+		if r, ok := step.(OutputRestorer); ok && len(sc.Output) > 0 {
+			if err := r.RestoreOutput(sc.Output); err != nil {
+				return fmt.Errorf("restore Output of %s: %w", step, err)
+			}
+		}
+		restored = append(restored, step)
+	}
+	for _, step := range restored {
+		step.setStatus(StepStatusSucceeded)
+	}
+	if len(restored) > 0 {
+		s.keepTerminated = true
+	}
+	return nil
+}
+
+// definitionChanged lists the Steps added and removed since the Checkpoint.
+func (s *Workflow) definitionChanged(checkpoint Checkpoint) ErrDefinitionChanged {
+	var err ErrDefinitionChanged
+	keys := make(map[string]bool, len(s.steps))
+	for _, step := range s.steps {
+		key := s.keyOf(step)
+		keys[key] = true
+		if _, ok := checkpoint.Steps[key]; !ok {
+			err.Added = append(err.Added, key)
+		}
+	}
+	for key := range checkpoint.Steps {
+		if !keys[key] {
+			err.Removed = append(err.Removed, key)
+		}
+	}
+	sort.Strings(err.Added)
+	sort.Strings(err.Removed)
+	return err
+}
+
+// DumpStatuses returns the statuses of all Steps keyed by their names (String()),
+// e.g. to persist them and resume the Workflow via LoadStatuses after a crash.
+// The Step names should be unique.
+func (s *Workflow) DumpStatuses() map[string]StepStatus {
+	statuses := make(map[string]StepStatus, len(s.steps))
+	for _, step := range s.steps {
+		statuses[step.String()] = step.GetStatus()
+	}
+	return statuses
+}
+
+// LoadStatuses sets the statuses of Steps by their names (String()), e.g. from DumpStatuses,
+// so the next Run keeps the terminated Steps and only runs the rest.
+// Running Steps (e.g. interrupted by a crash) are loaded as Pending to run again.
+// Steps Failed before are reported with ErrFailedBeforeResume by Run.
+//
+// The Output of terminated Steps is not persisted, but still flows to their Dependers in the next Run,
+// so the caller must restore it before Run, e.g. by rebuilding the Steps with their Output,
+// or via WorkflowStateStore and OutputRestorer.
+//
+// It returns error for unknown names, ErrWorkflowHasRun if the Workflow has run,
+// and ErrWorkflowIsRunning if it's running.
+func (s *Workflow) LoadStatuses(statuses map[string]StepStatus) error {
+	if !s.isRunning.TryLock() {
+		return ErrWorkflowIsRunning
+	}
+	defer s.isRunning.Unlock()
+	if s.HasRun() {
+		return ErrWorkflowHasRun
+	}
+	byName := make(map[string][]StepDoer, len(s.steps))
+	for _, step := range s.steps {
+		byName[step.String()] = append(byName[step.String()], step)
+	}
+	for name := range statuses {
+		if _, ok := byName[name]; !ok {
+			return fmt.Errorf("LoadStatuses: no Step named %q", name)
+		}
+	}
+	for name, status := range statuses {
+		if status == StepStatusRunning {
+			status = StepStatusPending
+		}
+		for _, step := range byName[name] {
+			step.setStatus(status)
+		}
+	}
+	s.keepTerminated = true
+	return nil
+}
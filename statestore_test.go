@@ -0,0 +1,245 @@
+package pl_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xuxife/pl"
+)
+
+type memoryStore struct {
+	mu         sync.Mutex
+	checkpoint pl.Checkpoint
+	saves      int
+	release    chan struct{} // if not nil, Save blocks until it's closed
+}
+
+func (m *memoryStore) Save(_ context.Context, checkpoint pl.Checkpoint) error {
+	if m.release != nil {
+		<-m.release
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoint = checkpoint
+	m.saves++
+	return nil
+}
+
+func (m *memoryStore) Load(context.Context) (pl.Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.checkpoint, nil
+}
+
+// provision is a long Step whose Output can be restored.
+type provision struct {
+	pl.StepBaseInOut[struct{}, string]
+	runs int
+}
+
+func (p *provision) String() string { return "provision" }
+
+func (p *provision) Do(context.Context) error {
+	p.runs++
+	p.Out = "vm-1"
+	return nil
+}
+
+func (p *provision) RestoreOutput(data []byte) error {
+	return json.Unmarshal(data, &p.Out)
+}
+
+func TestWorkflowStateStore(t *testing.T) {
+	store := new(memoryStore)
+	newWorkflow := func(flaky bool) (*pl.Workflow, *provision, *string) {
+		var got string
+		p := new(provision)
+		configure := pl.FuncIn("configure", func(_ context.Context, vm string) error {
+			if flaky {
+				return fmt.Errorf("process crashed")
+			}
+			got = vm
+			return nil
+		})
+		w := new(pl.Workflow).
+			WithOptions(pl.WorkflowStateStore(store, nil)).
+			Add(pl.Step(configure).DirectDependsOn(p))
+		return w, p, &got
+	}
+
+	w, p, _ := newWorkflow(true)
+	if err := w.Run(context.Background()); err == nil {
+		t.Fatal("want first run failed")
+	}
+	if p.runs != 1 {
+		t.Fatalf("want provision run once, got %d", p.runs)
+	}
+	if got := store.checkpoint.Steps["provision"]; got.Status != pl.StepStatusSucceeded || string(got.Output) != `"vm-1"` {
+		t.Fatalf("want provision saved with Output, got %+v", got)
+	}
+	if got := store.checkpoint.Steps["configure"].Status; got != pl.StepStatusFailed {
+		t.Fatalf("want configure saved as Failed, got %s", got)
+	}
+
+	// a new process restores from the store
+	w, p, got := newWorkflow(false)
+	if err := w.Restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if p.runs != 0 {
+		t.Errorf("want restored provision not run, got %d runs", p.runs)
+	}
+	if *got != "vm-1" {
+		t.Errorf("want configure receive the restored Output, got %q", *got)
+	}
+}
+
+func TestWorkflowStateStoreSlowSave(t *testing.T) {
+	store := &memoryStore{release: make(chan struct{})}
+	last := make(chan struct{})
+	w := new(pl.Workflow).WithOptions(pl.WorkflowStateStore(store, nil))
+	var prev pl.StepDoer
+	for i := 0; i < 20; i++ {
+		step := succeed(fmt.Sprintf("step-%02d", i))
+		if i == 19 {
+			step = pl.FuncNoInOut("step-19", func(context.Context) error {
+				close(last)
+				return nil
+			})
+		}
+		if prev == nil {
+			w.Add(pl.Steps(step))
+		} else {
+			w.Add(pl.Steps(step).DependsOn(prev))
+		}
+		prev = step
+	}
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	select {
+	case <-last: // the chain proceeds while the first Save is blocked
+	case <-time.After(5 * time.Second):
+		t.Fatal("want Steps not delayed by the slow Save")
+	}
+	close(store.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if store.saves >= 20 {
+		t.Errorf("want saves coalesced, got %d saves", store.saves)
+	}
+	for key, sc := range store.checkpoint.Steps {
+		if sc.Status != pl.StepStatusSucceeded {
+			t.Errorf("want %s saved Succeeded by the last Save, got %s", key, sc.Status)
+		}
+	}
+}
+
+func TestWorkflowRestoreDefinitionChanged(t *testing.T) {
+	build := func(names ...string) (*pl.Workflow, *memoryStore) {
+		store := new(memoryStore)
+		w := new(pl.Workflow).WithOptions(pl.WorkflowStateStore(store, nil))
+		for _, name := range names {
+			w.Add(pl.Steps(succeed(name)))
+		}
+		return w, store
+	}
+	old, store := build("a", "b", "c")
+	if err := old.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name           string
+		steps          []string
+		added, removed []string
+	}{
+		{"rename", []string{"a", "b", "d"}, []string{"d"}, []string{"c"}},
+		{"addition", []string{"a", "b", "c", "d"}, []string{"d"}, nil},
+		{"removal", []string{"a", "b"}, nil, []string{"c"}},
+	} {
+		w, _ := build(tc.steps...)
+		w.WithOptions(pl.WorkflowStateStore(store, nil))
+		err := w.Restore(context.Background())
+		var derr pl.ErrDefinitionChanged
+		if !errors.As(err, &derr) {
+			t.Fatalf("%s: want ErrDefinitionChanged, got %v", tc.name, err)
+		}
+		if fmt.Sprint(derr.Added) != fmt.Sprint(tc.added) || fmt.Sprint(derr.Removed) != fmt.Sprint(tc.removed) {
+			t.Errorf("%s: want added %v and removed %v, got %v", tc.name, tc.added, tc.removed, derr)
+		}
+		if err := w.Restore(context.Background(), pl.AllowPartialRestore()); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		for _, state := range w.States() {
+			want := pl.StepStatus(pl.StepStatusSucceeded)
+			if slices.Contains(tc.added, state.Step.String()) {
+				want = pl.StepStatusPending
+			}
+			if state.Status != want {
+				t.Errorf("%s: want %s restored %s, got %s", tc.name, state.Step, want, state.Status)
+			}
+		}
+	}
+}
+
+func TestWorkflowLoadStatuses(t *testing.T) {
+	var got string
+	newWorkflow := func() (*pl.Workflow, *provision, pl.StepReader, pl.StepReader) {
+		p := new(provision)
+		configure := pl.FuncIn("configure", func(_ context.Context, vm string) error {
+			got = vm
+			return nil
+		})
+		failed, down := fail("failed"), succeed("down")
+		w := new(pl.Workflow).Add(
+			pl.Step(configure).DirectDependsOn(p),
+			pl.Step(down).ExtraDependsOn(failed),
+		)
+		return w, p, configure, down
+	}
+
+	w, _, _, _ := newWorkflow()
+	dumped := w.DumpStatuses()
+	if len(dumped) != 4 || dumped["configure"] != pl.StepStatusPending {
+		t.Fatalf("want all Steps dumped Pending, got %v", dumped)
+	}
+
+	// the last process crashed while configure was running
+	w, p, configure, down := newWorkflow()
+	p.Out = "vm-1" // the contract: restore the Output of terminated Steps
+	if err := w.LoadStatuses(map[string]pl.StepStatus{
+		"provision": pl.StepStatusSucceeded,
+		"configure": pl.StepStatusRunning,
+		"failed":    pl.StepStatusFailed,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.LoadStatuses(map[string]pl.StepStatus{"unknown": pl.StepStatusSucceeded}); err == nil {
+		t.Error("want error for unknown Step")
+	}
+	err := w.Run(context.Background())
+	if !errors.Is(err, pl.ErrFailedBeforeResume) {
+		t.Errorf("want the loaded Failed Step reported, got %v", err)
+	}
+	if p.runs != 0 {
+		t.Errorf("want loaded Succeeded Step not run, got %d runs", p.runs)
+	}
+	if got != "vm-1" || configure.GetStatus() != pl.StepStatusSucceeded {
+		t.Errorf("want interrupted configure run again with the restored Output, got %q %s", got, configure.GetStatus())
+	}
+	if got := down.GetStatus(); got != pl.StepStatusCanceled {
+		t.Errorf("want the downstream of loaded Failed Step Canceled, got %s", got)
+	}
+	if got := w.DumpStatuses(); got["provision"] != pl.StepStatusSucceeded || got["failed"] != pl.StepStatusFailed {
+		t.Errorf("want the loaded statuses dumped, got %v", got)
+	}
+}
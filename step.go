@@ -2,6 +2,7 @@ package pl
 
 import (
 	"context"
+	"log/slog"
 	"time"
 )
 
@@ -41,12 +42,26 @@ type addStep[I any] struct {
 //	)
 func (as *addStep[I]) DependsOn(adapts ...*adapt[I]) *addStep[I] {
 	for _, adapt := range adapts {
-		as.cy[as.r] = append(as.cy[as.r], link{
+		for _, e := range adapt.Extra {
+			// the Output of e is read by the Flow of adapt.Dependee,
+			// the Flow here only marks the data link, e.g. for ToDOT and ToMermaid
+			as.cy[as.r] = append(as.cy[as.r], link{
+				Dependee: e,
+				Flow:     func(context.Context) error { return nil },
+			})
+		}
+		l := link{
 			Dependee: adapt.Dependee,
 			Flow: func(ctx context.Context) error {
 				return adapt.Flow(ctx, as.r.Input())
 			},
-		})
+		}
+		if adapt.Fallback != nil {
+			l.Fallback = func(context.Context) error {
+				return adapt.Fallback(as.r.Input())
+			}
+		}
+		as.cy[as.r] = append(as.cy[as.r], l)
 	}
 	return as
 }
@@ -75,19 +90,86 @@ func Adapt[I, O any](e dependee[O], fn AdaptFunc[I, O]) *adapt[I] {
 	return &adapt[I]{
 		Dependee: e,
 		Flow: func(ctx context.Context, i *I) error {
-			return fn(ctx, GetOutput(e), i)
+			return fn(ctx, flowed(ctx, GetOutput(e)), i)
+		},
+	}
+}
+
+// AdaptOr is Adapt with a fallback, which runs instead of fn when the Dependee is Skipped or Canceled,
+// e.g. to set a default Input, so the Input is deterministic regardless of the Dependee's status.
+//
+// Like Adapt, fn runs if the Dependee is Succeeded or Failed.
+// Use a Condition passing Canceled Dependees (e.g. Always) for the Depender to run after a Canceled Dependee.
+func AdaptOr[I, O any](e dependee[O], fn AdaptFunc[I, O], fallback func(*I) error) *adapt[I] {
+	a := Adapt(e, fn)
+	a.Fallback = fallback
+	return a
+}
+
+// Adapt2 is Adapt for 2 Dependees of different Output types,
+// fn receives their Outputs together, and is called once in the Depender's Flow.
+//
+// Like Adapt, the Outputs only flow if all the Dependees are Succeeded or Failed.
+func Adapt2[I, O1, O2 any](
+	e1 dependee[O1], e2 dependee[O2],
+	fn func(context.Context, O1, O2, *I) error,
+) *adapt[I] {
+	return &adapt[I]{
+		Dependee: e2,
+		Extra:    []StepDoer{e1},
+		Flow: func(ctx context.Context, i *I) error {
+			if !flowsOutput(ctx, e1, e2) {
+				return nil
+			}
+			return fn(ctx, flowed(ctx, GetOutput(e1)), flowed(ctx, GetOutput(e2)), i)
+		},
+	}
+}
+
+// Adapt3 is Adapt for 3 Dependees of different Output types, see Adapt2.
+func Adapt3[I, O1, O2, O3 any](
+	e1 dependee[O1], e2 dependee[O2], e3 dependee[O3],
+	fn func(context.Context, O1, O2, O3, *I) error,
+) *adapt[I] {
+	return &adapt[I]{
+		Dependee: e3,
+		Extra:    []StepDoer{e1, e2},
+		Flow: func(ctx context.Context, i *I) error {
+			if !flowsOutput(ctx, e1, e2, e3) {
+				return nil
+			}
+			return fn(ctx, flowed(ctx, GetOutput(e1)), flowed(ctx, GetOutput(e2)), flowed(ctx, GetOutput(e3)), i)
 		},
 	}
 }
 
 type adapt[I any] struct {
 	Dependee StepDoer
+	Extra    []StepDoer // other Dependees read by Flow, see Adapt2
 	Flow     func(context.Context, *I) error
+	Fallback func(*I) error // runs instead of Flow if Dependee's Output doesn't flow, see AdaptOr
+}
+
+// flowsOutput returns whether the Outputs of all the Dependees flow to Depender,
+// only Succeeded or Failed Steps flow their Output, unless WorkflowFlowFromAllTerminated.
+func flowsOutput(ctx context.Context, dependees ...StepReader) bool {
+	if w := workflowFromContext(ctx); w != nil && w.flowAllTerminated {
+		return true // Dependees have terminated before Flow
+	}
+	for _, e := range dependees {
+		switch e.GetStatus() {
+		case StepStatusSucceeded, StepStatusFailed:
+		default:
+			return false
+		}
+	}
+	return true
 }
 
 // DirectDependsOn declares dependency between Steps.
 //
 // DirectDependsOn is for Dependee's Output == Depender's Input type.
+// A Dependee wired more than once, e.g. by different Add, flows its Output once in each attempt.
 //
 // Usage:
 //
@@ -97,8 +179,11 @@ func (as *addStep[I]) DirectDependsOn(es ...dependee[I]) *addStep[I] {
 	for _, e := range es {
 		as.cy[as.r] = append(as.cy[as.r], link{
 			Dependee: e,
-			Flow: func(context.Context) error {
-				e.Output(as.r.Input())
+			Direct:   true,
+			Flow: func(ctx context.Context) error {
+				in := as.r.Input()
+				e.Output(in)
+				*in = flowed(ctx, *in)
 				return nil
 			},
 		})
@@ -146,6 +231,37 @@ func (as *addStep[I]) Input(fns ...func(context.Context, *I) error) *addStep[I]
 	return as
 }
 
+// InputAndValidate sets the Input for the Step, then validates it,
+// the Step fails with the error of validate without running Do.
+//
+// Like Input, InputAndValidate respects the order in building calls.
+func (as *addStep[I]) InputAndValidate(set func(*I), validate func(I) error) *addStep[I] {
+	return as.Input(func(_ context.Context, i *I) error {
+		set(i)
+		return validate(*i)
+	})
+}
+
+// InputFromErrors sets the Input for the Step from the errors of its Dependees,
+// e.g. to aggregate the errors of optional Dependees and decide a final action.
+//
+// The map contains all Dependees of the Step, with nil for the Dependees without error.
+// Use Condition(Always) or SucceededOrFailed to run the Step even if some Dependees failed.
+//
+// Like Input, InputFromErrors respects the order in building calls.
+func (as *addStep[I]) InputFromErrors(fn func(context.Context, map[StepReader]error, *I) error) *addStep[I] {
+	as.cy[as.r] = append(as.cy[as.r], link{
+		Flow: func(ctx context.Context) error {
+			var errs map[StepReader]error
+			if w := workflowFromContext(ctx); w != nil {
+				errs = w.errsOf(w.deps.UpstreamOf(as.r))
+			}
+			return fn(ctx, errs, as.r.Input())
+		},
+	})
+	return as
+}
+
 // Timeout sets the Step timeout.
 //
 // It's the Step level timeout (beyond retry),
@@ -155,12 +271,40 @@ func (as *addStep[I]) Timeout(timeout time.Duration) *addStep[I] {
 	return as
 }
 
+// LogLevel sets the level of the Step's logger, replacing the level of the Workflow logger,
+// e.g. slog.LevelDebug to debug a Step, or slog.LevelWarn to quiet a noisy one.
+// It applies to the Workflow's own records about the Step as well, see Logger.
+func (as *addStep[I]) LogLevel(level slog.Level) *addStep[I] {
+	as.r.setLogLevel(level)
+	return as
+}
+
 // Condition decides whether the Step should be Canceled.
 func (as *addStep[I]) Condition(cond Condition) *addStep[I] {
 	as.r.setCondition(cond)
 	return as
 }
 
+// ConditionFor overrides the Condition for a single Dependee, e.g. ConditionFor(optional, Always)
+// to run the Step regardless of an optional Dependee, while the other Dependees are still under Condition.
+//
+// cond receives only the Dependee, and the Condition of the Step receives the other Dependees,
+// the Step is Canceled if either is false.
+// The Dependee is declared as ExtraDependsOn if not yet.
+func (as *addStep[I]) ConditionFor(dependee StepDoer, cond Condition) *addStep[I] {
+	found := false
+	for i, l := range as.cy[as.r] {
+		if l.Dependee == dependee {
+			as.cy[as.r][i].Cond = cond
+			found = true
+		}
+	}
+	if !found {
+		as.cy[as.r] = append(as.cy[as.r], link{Dependee: dependee, Cond: cond})
+	}
+	return as
+}
+
 // When decides whether the Step should be Skipped.
 func (as *addStep[I]) When(when When) *addStep[I] {
 	as.r.setWhen(when)
@@ -173,6 +317,72 @@ func (as *addStep[I]) Retry(opt RetryOption) *addStep[I] {
 	return as
 }
 
+// WithResource attaches an external resource lifecycle to the Step.
+//
+// The resource is acquired before the Step runs (before all retry attempts),
+// and released after, even on panic or timeout.
+// If acquire fails, the Step fails with ErrResourceAcquire without calling Do.
+// Multiple resources are acquired in order and released in reverse order.
+func (as *addStep[I]) WithResource(acquire func(context.Context) (release func(), err error)) *addStep[I] {
+	as.r.addResource(acquire)
+	return as
+}
+
+// ConcurrencyGroup tags the Step with concurrency groups,
+// the Step runs only when a slot of every group is available, see WorkflowConcurrencyGroup.
+func (as *addStep[I]) ConcurrencyGroup(names ...string) *addStep[I] {
+	as.r.addConcurrencyGroups(names...)
+	return as
+}
+
+// Journal records the side effects of the Step around each attempt of Do.
+func (as *addStep[I]) Journal(j Journal) *addStep[I] {
+	as.r.setJournal(j)
+	return as
+}
+
+// Compensate sets the function to undo the Step's side effects,
+// it's called when the Step Succeeded but its atomic Stage failed, see Stage.Atomic.
+func (as *addStep[I]) Compensate(fn func(context.Context) error) *addStep[I] {
+	as.r.setCompensate(fn)
+	return as
+}
+
+// StartDeadline sets the max duration the Step can wait to start after being ready,
+// i.e. all Dependees terminated, Condition and When passed,
+// e.g. the Step is stuck behind WorkflowMaxConcurrency.
+//
+// Once exceeded, the escalation (see WorkflowStartDeadlineEscalation) is called,
+// and if failStep, the Step fails with ErrStartDeadlineExceeded instead of starting.
+func (as *addStep[I]) StartDeadline(d time.Duration, failStep bool) *addStep[I] {
+	as.r.setStartDeadline(startDeadline{d: d, fail: failStep})
+	return as
+}
+
+// NotBefore leaves the Step Pending until the Clock (see WorkflowClock) passes t,
+// e.g. a scheduled segment of pipeline not starting before 02:00.
+//
+// The Step waits after being ready, as Steps waiting for a lease do,
+// it's Canceled if the Workflow stops before t, see WorkflowTimeout to bound the wait.
+func (as *addStep[I]) NotBefore(t time.Time) *addStep[I] {
+	as.r.setNotBefore(t)
+	return as
+}
+
+// AllowPolicyException exempts the Step from the policy bounds, see WorkflowPolicyBounds,
+// making the intentional outlier visible in code review.
+func (as *addStep[I]) AllowPolicyException() *addStep[I] {
+	as.r.setPolicyException(true)
+	return as
+}
+
+// SkipIfOutputPresent makes the Step Succeeded without running again if it has Succeeded in a prior run,
+// i.e. reuse its Output after Workflow.Reset, see WorkflowSkipIfOutputPresent.
+func (as *addStep[I]) SkipIfOutputPresent() *addStep[I] {
+	as.r.setSkipIfOutputPresent(true)
+	return as
+}
+
 func (as *addStep[I]) Done() dependency {
 	if _, ok := as.cy[as.r]; !ok {
 		as.cy[as.r] = nil
@@ -180,6 +390,10 @@ func (as *addStep[I]) Done() dependency {
 	return as.cy
 }
 
+func (as *addStep[I]) order() []StepDoer {
+	return stepsInOrder([]StepDoer{as.r}, as.cy)
+}
+
 // Steps declares a series of Steps.
 //
 // The Steps are mutually independent, and will be executed in parallel.
@@ -198,7 +412,7 @@ func Steps(dependers ...StepDoer) addSteps {
 	for _, r := range dependers {
 		d[r] = nil
 	}
-	return addSteps(d)
+	return addSteps{deps: d, steps: dependers}
 }
 
 // ToStepDoer converts []<StepDoer implemention> to []StepDoer.
@@ -217,7 +431,10 @@ func ToStepDoer[S StepDoer](steps []S) []StepDoer {
 	return rv
 }
 
-type addSteps dependency
+type addSteps struct {
+	deps  dependency
+	steps []StepDoer // in declared order
+}
 
 // DependsOn declares dependency with another group of Steps.
 func (as addSteps) DependsOn(dependees ...StepDoer) addSteps {
@@ -225,15 +442,26 @@ func (as addSteps) DependsOn(dependees ...StepDoer) addSteps {
 	for _, e := range dependees {
 		links = append(links, link{Dependee: e})
 	}
-	for r := range as {
-		as[r] = append(as[r], links...)
+	for _, r := range as.steps {
+		as.deps[r] = append(as.deps[r], links...)
 	}
 	return as
 }
 
-// Timeout sets the Step timeout.
+// Timeout sets the timeout for the Steps without one.
+//
+// Settings take precedence as: per-Step explicit > bulk > Workflow default,
+// regardless of the order they are declared, see ForceTimeout to override.
 func (as addSteps) Timeout(timeout time.Duration) addSteps {
-	for j := range as {
+	for _, j := range as.steps {
+		setTimeoutIfUnset(j, timeout)
+	}
+	return as
+}
+
+// ForceTimeout sets the timeout for all the Steps, overriding the ones already set.
+func (as addSteps) ForceTimeout(timeout time.Duration) addSteps {
+	for _, j := range as.steps {
 		j.setTimeout(timeout)
 	}
 	return as
@@ -241,7 +469,7 @@ func (as addSteps) Timeout(timeout time.Duration) addSteps {
 
 // Condition decides whether the Step should be Canceled.
 func (as addSteps) Condition(cond Condition) addSteps {
-	for j := range as {
+	for _, j := range as.steps {
 		j.setCondition(cond)
 	}
 	return as
@@ -249,22 +477,38 @@ func (as addSteps) Condition(cond Condition) addSteps {
 
 // When decides whether the Step should be Skipped.
 func (as addSteps) When(when When) addSteps {
-	for j := range as {
+	for _, j := range as.steps {
 		j.setWhen(when)
 	}
 	return as
 }
 
-// Retry sets the RetryOption for the Step.
+// Retry sets the RetryOption for the Steps without one.
+//
+// Settings take precedence as: per-Step explicit > bulk > Workflow default,
+// regardless of the order they are declared, see ForceRetry to override.
 func (as addSteps) Retry(opt RetryOption) addSteps {
-	for j := range as {
+	for _, j := range as.steps {
+		setRetryIfUnset(j, opt)
+	}
+	return as
+}
+
+// ForceRetry sets the RetryOption for all the Steps, overriding the ones already set.
+func (as addSteps) ForceRetry(opt RetryOption) addSteps {
+	for _, j := range as.steps {
+		opt := opt
 		j.setRetry(&opt)
 	}
 	return as
 }
 
 func (as addSteps) Done() dependency {
-	return dependency(as)
+	return as.deps
+}
+
+func (as addSteps) order() []StepDoer {
+	return stepsInOrder(as.steps, as.deps)
 }
 
 // TSteps is Typed-Steps, which is used to declare Steps with the same Input type.
@@ -320,8 +564,16 @@ func (as addTypedSteps[I]) Input(fns ...func(context.Context, *I) error) addType
 	return as
 }
 
-// Timeout sets the Steps timeout.
+// Timeout sets the timeout for the Steps without one, see addSteps.Timeout.
 func (as addTypedSteps[I]) Timeout(timeout time.Duration) addTypedSteps[I] {
+	for _, addStep := range as {
+		setTimeoutIfUnset(addStep.r, timeout)
+	}
+	return as
+}
+
+// ForceTimeout sets the timeout for all the Steps, overriding the ones already set.
+func (as addTypedSteps[I]) ForceTimeout(timeout time.Duration) addTypedSteps[I] {
 	for _, addStep := range as {
 		addStep.Timeout(timeout)
 	}
@@ -344,14 +596,32 @@ func (as addTypedSteps[I]) When(when When) addTypedSteps[I] {
 	return as
 }
 
-// Retry sets the RetryOption for the Steps.
+// Retry sets the RetryOption for the Steps without one, see addSteps.Retry.
 func (as addTypedSteps[I]) Retry(opt RetryOption) addTypedSteps[I] {
+	for _, addStep := range as {
+		setRetryIfUnset(addStep.r, opt)
+	}
+	return as
+}
+
+// ForceRetry sets the RetryOption for all the Steps, overriding the ones already set.
+func (as addTypedSteps[I]) ForceRetry(opt RetryOption) addTypedSteps[I] {
 	for _, addStep := range as {
 		addStep.Retry(opt)
 	}
 	return as
 }
 
+func (as addTypedSteps[I]) order() []StepDoer {
+	var dependers []StepDoer
+	d := make(dependency)
+	for _, addStep := range as {
+		dependers = append(dependers, addStep.r)
+		d.merge(addStep.cy)
+	}
+	return stepsInOrder(dependers, d)
+}
+
 func (as addTypedSteps[I]) Done() dependency {
 	d := make(dependency)
 	for _, addStep := range as {
@@ -359,3 +629,18 @@ func (as addTypedSteps[I]) Done() dependency {
 	}
 	return d
 }
+
+// bulk setters only fill the settings not set yet,
+// so that per-Step settings win regardless of the declaring order.
+
+func setTimeoutIfUnset(step stepBase, timeout time.Duration) {
+	if step.getTimeout() == 0 {
+		step.setTimeout(timeout)
+	}
+}
+
+func setRetryIfUnset(step stepBase, opt RetryOption) {
+	if step.getRetry() == nil {
+		step.setRetry(&opt)
+	}
+}
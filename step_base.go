@@ -1,6 +1,8 @@
 package pl
 
 import (
+	"context"
+	"log/slog"
 	"sync"
 	"time"
 )
@@ -22,18 +24,76 @@ type stepBase interface {
 
 	getTimeout() time.Duration
 	setTimeout(time.Duration)
+
+	getResources() []Resource
+	addResource(Resource)
+
+	getConcurrencyGroups() []string
+	addConcurrencyGroups(...string)
+
+	getJournal() Journal
+	setJournal(Journal)
+
+	getCompensate() func(context.Context) error
+	setCompensate(func(context.Context) error)
+
+	getStartDeadline() startDeadline
+	setStartDeadline(startDeadline)
+
+	getNotBefore() time.Time
+	setNotBefore(time.Time)
+
+	getPolicyException() bool
+	setPolicyException(bool)
+
+	getLogLevel() *slog.Level
+	setLogLevel(slog.Level)
+
+	HasRun() bool
+	setHasRun(bool)
+	getSkipIfOutputPresent() bool
+	setSkipIfOutputPresent(bool)
+
+	setStartedAt(time.Time)
+	setFinishedAt(time.Time)
+	getErr() error
+	setErr(error)
 }
 
+// StepTimer reports when a Step started and finished in its last run,
+// StepBase implements it.
+//
+// Skipped and Canceled Steps have only the finished time,
+// the times are zero before the Step starts or terminates.
+type StepTimer interface {
+	GetTimes() (start, end time.Time)
+}
+
+var _ StepTimer = &StepBase{}
+
 var _ stepBase = &StepBase{}
 
 // StepBase is to be embeded into your Step implement struct.
 type StepBase struct {
-	mutex   sync.RWMutex
-	status  StepStatus
-	cond    Condition
-	retry   *RetryOption
-	when    When
-	timeout time.Duration
+	mutex      sync.RWMutex // guards status, hasRun, startedAt, finishedAt and err
+	status     StepStatus
+	hasRun     bool // Do Succeeded in a run, see HasRun
+	startedAt  time.Time
+	finishedAt time.Time
+	err        error // recorded in the last run, see StepResult
+	cond       Condition
+	retry      *RetryOption
+	when       When
+	timeout    time.Duration
+	resources  []Resource
+	groups     []string // see WorkflowConcurrencyGroup
+	journal    Journal
+	compensate func(context.Context) error
+	deadline   startDeadline
+	notBefore  time.Time
+	exception  bool // see AllowPolicyException
+	memoize    bool
+	logLevel   *slog.Level
 }
 
 func (b *StepBase) GetStatus() StepStatus {
@@ -48,6 +108,51 @@ func (b *StepBase) setStatus(status StepStatus) {
 	b.status = status
 }
 
+// HasRun returns whether the Step's Do has Succeeded in a run, so its Output is present.
+// Steps Succeeded via Restore, LoadStatuses or skipped for WorkflowSkipIfOutputPresent don't set it,
+// it's cleared when the Step runs again, and by Workflow.Reset unless the Step skips if its Output is present.
+func (b *StepBase) HasRun() bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.hasRun
+}
+
+func (b *StepBase) setHasRun(hasRun bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.hasRun = hasRun
+}
+
+func (b *StepBase) GetTimes() (start, end time.Time) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.startedAt, b.finishedAt
+}
+
+func (b *StepBase) setStartedAt(t time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.startedAt = t
+}
+
+func (b *StepBase) setFinishedAt(t time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.finishedAt = t
+}
+
+func (b *StepBase) getErr() error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.err
+}
+
+func (b *StepBase) setErr(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.err = err
+}
+
 func (b *StepBase) getCondition() Condition {
 	return b.cond
 }
@@ -80,6 +185,38 @@ func (b *StepBase) setTimeout(timeout time.Duration) {
 	b.timeout = timeout
 }
 
+func (b *StepBase) getResources() []Resource {
+	return b.resources
+}
+
+func (b *StepBase) addResource(r Resource) {
+	b.resources = append(b.resources, r)
+}
+
+func (b *StepBase) getConcurrencyGroups() []string {
+	return b.groups
+}
+
+func (b *StepBase) addConcurrencyGroups(names ...string) {
+	b.groups = append(b.groups, names...)
+}
+
+func (b *StepBase) getJournal() Journal {
+	return b.journal
+}
+
+func (b *StepBase) setJournal(j Journal) {
+	b.journal = j
+}
+
+func (b *StepBase) getCompensate() func(context.Context) error {
+	return b.compensate
+}
+
+func (b *StepBase) setCompensate(fn func(context.Context) error) {
+	b.compensate = fn
+}
+
 // StepBaseIn[I] is to be embeded into your Step implement struct,
 // with the sepcified input type `I`.
 type StepBaseIn[I any] struct {
@@ -117,3 +254,43 @@ func GetOutput[A any](out outputer[A]) A {
 	out.Output(&v)
 	return v
 }
+
+func (b *StepBase) getStartDeadline() startDeadline {
+	return b.deadline
+}
+
+func (b *StepBase) setStartDeadline(d startDeadline) {
+	b.deadline = d
+}
+
+func (b *StepBase) getNotBefore() time.Time {
+	return b.notBefore
+}
+
+func (b *StepBase) setNotBefore(t time.Time) {
+	b.notBefore = t
+}
+
+func (b *StepBase) getPolicyException() bool {
+	return b.exception
+}
+
+func (b *StepBase) setPolicyException(allow bool) {
+	b.exception = allow
+}
+
+func (b *StepBase) getLogLevel() *slog.Level {
+	return b.logLevel
+}
+
+func (b *StepBase) setLogLevel(level slog.Level) {
+	b.logLevel = &level
+}
+
+func (b *StepBase) getSkipIfOutputPresent() bool {
+	return b.memoize
+}
+
+func (b *StepBase) setSkipIfOutputPresent(skip bool) {
+	b.memoize = skip
+}
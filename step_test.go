@@ -0,0 +1,277 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/xuxife/pl"
+)
+
+func TestInputFromErrors(t *testing.T) {
+	optionalA, optionalB := fail("optionalA"), succeed("optionalB")
+	aggregator := pl.FuncIn("aggregator", func(context.Context, int) error { return nil })
+	w := new(pl.Workflow).Add(
+		pl.Step(aggregator).
+			ExtraDependsOn(optionalA, optionalB).
+			InputFromErrors(func(_ context.Context, errs map[pl.StepReader]error, failed *int) error {
+				if len(errs) != 2 {
+					t.Errorf("want errors of 2 Dependees, got %v", errs)
+				}
+				for _, err := range errs {
+					if err != nil {
+						*failed++
+					}
+				}
+				return nil
+			}).
+			Condition(pl.Always),
+	)
+	_ = w.Run(context.Background())
+	if got := *aggregator.Input(); got != 1 {
+		t.Errorf("want 1 failed Dependee, got %d", got)
+	}
+}
+
+func TestInputAndValidate(t *testing.T) {
+	var called atomic.Bool
+	step := pl.FuncIn("step", func(context.Context, int) error {
+		called.Store(true)
+		return nil
+	})
+	errNegative := fmt.Errorf("negative")
+	w := new(pl.Workflow).Add(
+		pl.Step(step).InputAndValidate(
+			func(i *int) { *i = -1 },
+			func(i int) error {
+				if i < 0 {
+					return errNegative
+				}
+				return nil
+			},
+		),
+	)
+	_ = w.Run(context.Background())
+	if step.GetStatus() != pl.StepStatusFailed || !errors.Is(w.Err()[step], errNegative) {
+		t.Errorf("want step Failed by validation, got %s %v", step.GetStatus(), w.Err()[step])
+	}
+	if called.Load() {
+		t.Error("want Do not called after validation failed")
+	}
+}
+
+func TestBulkSettersPrecedence(t *testing.T) {
+	attempts := func(n uint64) pl.RetryOption {
+		return pl.RetryOption{Backoff: &backoff.ZeroBackOff{}, Attempts: n}
+	}
+	// build returns the Workflow with the settings declared in the given order,
+	// and the counters of attempts of each Step.
+	type counted struct {
+		step  pl.Steper[struct{}, struct{}]
+		count *atomic.Int32
+	}
+	newCounted := func(name string) counted {
+		c := counted{count: new(atomic.Int32)}
+		c.step = pl.FuncNoInOut(name, func(context.Context) error {
+			c.count.Add(1)
+			return fmt.Errorf("fail")
+		})
+		return c
+	}
+	for _, tc := range []struct {
+		name  string
+		build func(explicit, bulk counted) *pl.Workflow
+	}{
+		{"explicit then bulk", func(explicit, bulk counted) *pl.Workflow {
+			return new(pl.Workflow).Add(
+				pl.Step(explicit.step).Retry(attempts(3)),
+				pl.Steps(explicit.step, bulk.step).Retry(attempts(2)),
+			)
+		}},
+		{"bulk then explicit", func(explicit, bulk counted) *pl.Workflow {
+			return new(pl.Workflow).Add(
+				pl.Steps(explicit.step, bulk.step).Retry(attempts(2)),
+				pl.Step(explicit.step).Retry(attempts(3)),
+			)
+		}},
+		{"typed bulk then explicit", func(explicit, bulk counted) *pl.Workflow {
+			return new(pl.Workflow).Add(
+				pl.TSteps(explicit.step, bulk.step).Retry(attempts(2)),
+				pl.Step(explicit.step).Retry(attempts(3)),
+			)
+		}},
+		{"explicit then typed bulk", func(explicit, bulk counted) *pl.Workflow {
+			return new(pl.Workflow).Add(
+				pl.Step(explicit.step).Retry(attempts(3)),
+				pl.TSteps(explicit.step, bulk.step).Retry(attempts(2)),
+			)
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			explicit, bulk, fallback := newCounted("explicit"), newCounted("bulk"), newCounted("fallback")
+			w := tc.build(explicit, bulk).
+				Add(pl.Step(fallback.step)).
+				WithOptions(pl.WorkflowDefaultRetry(attempts(4)))
+			_ = w.Run(context.Background())
+			for _, c := range []struct {
+				counted
+				want int32
+			}{{explicit, 4}, {bulk, 3}, {fallback, 5}} { // the first attempt plus the retries
+				if got := c.count.Load(); got != c.want {
+					t.Errorf("%s: want %d attempts, got %d", c.step, c.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBulkForceRetry(t *testing.T) {
+	var count atomic.Int32
+	step := pl.FuncNoInOut("step", func(context.Context) error {
+		count.Add(1)
+		return fmt.Errorf("fail")
+	})
+	w := new(pl.Workflow).Add(
+		pl.Step(step).Retry(pl.RetryOption{Backoff: &backoff.ZeroBackOff{}, Attempts: 3}),
+		pl.Steps(step).ForceRetry(pl.RetryOption{Backoff: &backoff.ZeroBackOff{}, Attempts: 2}),
+	)
+	_ = w.Run(context.Background())
+	if got := count.Load(); got != 3 {
+		t.Errorf("want 3 attempts, got %d", got)
+	}
+}
+
+func TestBulkConditionAndWhenOverride(t *testing.T) {
+	// unlike Retry and Timeout, bulk Condition and When override the per-Step ones as before
+	skipped, canceled := succeed("skipped"), succeed("canceled")
+	w := new(pl.Workflow).Add(
+		pl.Step(skipped).When(func(context.Context) bool { return true }),
+		pl.Step(canceled).Condition(pl.Always),
+		pl.Steps(skipped).When(func(context.Context) bool { return false }),
+		pl.Steps(canceled).Condition(func([]pl.StepReader) bool { return false }),
+	)
+	_ = w.Run(context.Background())
+	if got := skipped.GetStatus(); got != pl.StepStatusSkipped {
+		t.Errorf("want bulk When to override, got %s", got)
+	}
+	if got := canceled.GetStatus(); got != pl.StepStatusCanceled {
+		t.Errorf("want bulk Condition to override, got %s", got)
+	}
+}
+
+func TestAdapt3(t *testing.T) {
+	type input struct {
+		Name  string
+		Count int
+		OK    bool
+		Calls int
+	}
+	newWorkflow := func(countErr error, cond pl.Condition) (*pl.Workflow, pl.Steper[struct{}, int], pl.StepReader, *input) {
+		var got input
+		name := pl.FuncOut("name", func(context.Context) (func(*string), error) {
+			return func(o *string) { *o = "x" }, nil
+		})
+		count := pl.FuncOut("count", func(context.Context) (func(*int), error) {
+			return func(o *int) { *o = 3 }, countErr
+		})
+		ok := pl.FuncOut("ok", func(context.Context) (func(*bool), error) {
+			return func(o *bool) { *o = true }, nil
+		})
+		fanIn := pl.FuncIn("fanIn", func(_ context.Context, i input) error {
+			got = i
+			return nil
+		})
+		w := new(pl.Workflow).Add(
+			pl.Step(fanIn).
+				DependsOn(pl.Adapt3(name, count, ok, func(_ context.Context, n string, c int, o bool, i *input) error {
+					i.Name, i.Count, i.OK = n, c, o
+					i.Calls++
+					return nil
+				})).
+				Condition(cond),
+		)
+		return w, count, fanIn, &got
+	}
+
+	t.Run("all succeeded", func(t *testing.T) {
+		w, _, _, got := newWorkflow(nil, pl.Succeeded)
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if want := (input{"x", 3, true, 1}); *got != want {
+			t.Errorf("want %+v flowed once, got %+v", want, *got)
+		}
+	})
+	t.Run("one failed", func(t *testing.T) {
+		w, _, _, got := newWorkflow(fmt.Errorf("count failed"), pl.SucceededOrFailed)
+		_ = w.Run(context.Background())
+		// the Output of Failed Step still flows
+		if want := (input{"x", 3, true, 1}); *got != want {
+			t.Errorf("want %+v flowed once, got %+v", want, *got)
+		}
+	})
+	t.Run("data edges", func(t *testing.T) {
+		w, _, _, _ := newWorkflow(nil, pl.Succeeded)
+		if got := w.Mermaid(); strings.Count(got, " --> ") != 3 || strings.Contains(got, " -.-> ") {
+			t.Errorf("want 3 data edges, got:\n%s", got)
+		}
+		if got := w.DOT(); strings.Contains(got, "[style=dashed]") {
+			t.Errorf("want no dashed edge, got:\n%s", got)
+		}
+	})
+	t.Run("one skipped", func(t *testing.T) {
+		w, count, fanIn, got := newWorkflow(nil, pl.Always)
+		w.Add(pl.Step(count).When(pl.Skip))
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if fanIn.GetStatus() != pl.StepStatusSucceeded || got.Calls != 0 {
+			t.Errorf("want fanIn run without flow, got %s %+v", fanIn.GetStatus(), *got)
+		}
+	})
+}
+
+func TestAdaptOr(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		when pl.When
+		want int
+	}{
+		{"flows", nil, 3},
+		{"skipped", pl.Skip, -1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got int
+			count := pl.FuncOut("count", func(context.Context) (func(*int), error) {
+				return func(o *int) { *o = 3 }, nil
+			})
+			use := pl.FuncIn("use", func(_ context.Context, i int) error {
+				got = i
+				return nil
+			})
+			w := new(pl.Workflow).Add(
+				pl.Step(count).When(tc.when),
+				pl.Step(use).DependsOn(pl.AdaptOr(count,
+					func(_ context.Context, o int, i *int) error {
+						*i = o
+						return nil
+					},
+					func(i *int) error {
+						*i = -1
+						return nil
+					},
+				)),
+			)
+			if err := w.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("want Input %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
@@ -2,36 +2,169 @@ package pl
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/cenkalti/backoff/v4"
 )
 
 // Workflow represents a collection of connected Steps that form a directed acyclic graph (DAG).
 //
 // Workflow executes Steps in a topological order,
 // and flow the Output(s) from Dependee(s) to Input(s) of Depender(s).
+//
+// The snapshot methods (IsTerminated, Status, States, Err, Timings) are safe to call
+// from another goroutine or from inside a running Step's Do:
+// they only take short-lived locks (errsMu, each Step's status lock),
+// and the scheduler never holds those locks while waiting for a Step,
+// so they never block on a running Step.
 type Workflow struct {
-	deps              dependency
-	errs              ErrWorkflow
-	errsMu            sync.RWMutex   // need this because errs are written from each Step's goroutine
-	when              When           // Workflow level When
-	leaseBucket       chan struct{}  // constraint max concurrency of running Steps
+	deps        dependency
+	steps       []StepDoer // Steps in the order of being added, for deterministic iteration
+	errs        ErrWorkflow
+	records     map[StepDoer]*stepRecord
+	deadLetters []DeadLetter // see DeadLetters
+	errsMu      sync.RWMutex // need this because errs and records are written from each Step's goroutine
+
+	downstreamMu sync.Mutex
+	downstream   map[StepDoer][]StepDoer // reverse index of deps, built lazily, see DownstreamOf
+	generation   uint64                  // incremented by each Add, to invalidate the compiled Plan
+	plan         atomic.Pointer[Plan]    // see Compile
+
+	// options, see WithOptions
+	optionsMu           sync.RWMutex   // serializes WithOptions, and guards defaultCond / defaultWhen read by their getters
+	applied             appliedOptions // guarded by optionsMu, see OptionsSummary
+	when                When           // Workflow level When
+	defaultCond         Condition      // default Condition for Steps without one, see WorkflowDefaultCondition
+	defaultWhen         When           // default When for Steps without one, see WorkflowDefaultWhen
+	defaultRetry        *RetryOption   // see WorkflowDefaultRetry
+	defaultTimeout      time.Duration  // see WorkflowDefaultTimeout
+	leaseBucket         chan struct{}  // constraint max concurrency of running Steps
+	failFast            bool           // see WorkflowFailFast
+	timeout             time.Duration  // see WorkflowTimeout
+	beforeStep          func(context.Context, StepReader) context.Context
+	afterStep           func(context.Context, StepReader, error)
+	clk                 Clock       // see WorkflowClock
+	ioRecorder          IORecorder  // see WorkflowIORecorder
+	ioRecording         IORecording // see WorkflowIOVerifier
+	onStartDeadline     func(context.Context, StepReader, time.Duration)
+	tracer              func(context.Context, StepReader) (context.Context, func(error))
+	hookQueueSize       int
+	hookOverflow        HookOverflow
+	hooks               atomic.Pointer[hookDispatcher]         // the hook dispatcher of the current or last run, see WorkflowHookQueue
+	eventsMu            sync.Mutex                             // guards events
+	events              chan StepEvent                         // see Events
+	terminatedMu        sync.Mutex                             // guards terminated
+	terminated          chan struct{}                          // closed when a Step terminates, see AwaitStep
+	admit               func(context.Context, StepReader) bool // see WorkflowAdmissionControl
+	barrier             map[StepDoer]bool                      // see WorkflowBarrier
+	starvation          time.Duration                          // see WorkflowStarvationThreshold
+	seedOpt             *int64                                 // see WorkflowSeed
+	wrapErrors          bool                                   // see WorkflowWrapErrors
+	skipIfOutputPresent bool                                   // see WorkflowSkipIfOutputPresent
+	deepCopyFlow        bool                                   // see WorkflowDeepCopyFlow
+	flowAllTerminated   bool                                   // see WorkflowFlowFromAllTerminated
+	skipPropagation     bool                                   // see WorkflowSkipPropagation
+	policyBounds        *PolicyBounds                          // see WorkflowPolicyBounds
+	softPolicyBounds    *PolicyBounds                          // see WorkflowSoftPolicyBounds
+	scheduler           Scheduler                              // see WorkflowScheduler
+	rootStagger         time.Duration                          // see WorkflowRootStagger
+	rootNotBefore       map[StepDoer]time.Time                 // when each root Step can start in this run, see WorkflowRootStagger
+	groupBuckets        map[string]chan struct{}               // see WorkflowConcurrencyGroup
+	stateStore          StateStore                             // see WorkflowStateStore
+	stateKey            func(StepDoer) string                  // see WorkflowStateStore
+	saver               *stateSaver                            // saves to stateStore in the current or last run
+	indeterminate       map[StepDoer]indeterminateSteps        // loaded when the run starts, see IndeterminateJournal
+	seed                int64                                  // seed of the current or last run
+	leakCheck           bool                                   // see WorkflowLeakCheck
+	logger              *slog.Logger                           // see WorkflowLogger
+	runLogger           *slog.Logger                           // the logger of the current or last run
+	stepLogHook         StepLogger                             // see WorkflowStepLogger
+	spanTracer          SpanTracer                             // see WorkflowSpanTracer
+	runSpanTracer       SpanTracer                             // the tracer of the current or last run
+	leak                *GoroutineLeak                         // see Leak
+
+	stopMu    sync.Mutex // guards stopCause, cancelRun, stopCh and wake
+	stopCause error      // non-nil when the Workflow stops scheduling Pending Steps
+	cancelRun context.CancelCauseFunc
+	stopCh    chan struct{} // closed when the Workflow stops in this run
+
+	continued atomic.Bool // see Continue
+	paused    atomic.Bool // see Paused
+
 	waitGroup         sync.WaitGroup // to prevent goroutine leak, only Add(1) when a Step start running
 	isRunning         sync.Mutex
-	oneStepTerminated chan struct{} // signals for next tick
+	runOpts           runOptions    // options of the current run
+	keepTerminated    bool          // whether the next run keeps the terminated Steps, see ResetFailed and LoadStatuses
+	oneStepTerminated chan StepDoer // signals for next tick
+	wake              chan struct{} // signals for next tick without Step terminated, see wakeUp
+	admitRecheck      *atomic.Bool  // whether a recheck of denied Steps is scheduled in this run, see WorkflowAdmissionControl
+	frontier          *frontier     // the Steps to be visited in next tick
+	runPlan           *Plan         // the compiled Plan used by the current run, nil if not compiled
 }
 
 // Add appends Steps into Workflow.
+//
+// Steps are scheduled in the order of being added,
+// it doesn't change which Steps run, but makes the scheduling deterministic.
 func (s *Workflow) Add(dbs ...WorkflowStep) *Workflow {
 	if s.deps == nil {
 		s.deps = make(dependency)
 	}
 	for _, db := range dbs {
-		s.deps.merge(db.Done())
+		d := db.Done()
+		var steps []StepDoer
+		if o, ok := db.(stepOrderer); ok {
+			steps = o.order()
+		}
+		// the Steps not in order are appended by name
+		for _, step := range stepsInOrder(append(steps, d.sortedSteps()...), d) {
+			if _, ok := s.deps[step]; !ok {
+				s.steps = append(s.steps, step)
+			}
+		}
+		s.deps.merge(d)
 	}
+	s.downstreamMu.Lock()
+	s.downstream = nil // invalidate the reverse index
+	s.downstreamMu.Unlock()
+	s.generation++ // invalidate the compiled Plan
 	return s
 }
 
+// DownstreamOf returns all Depender(s) of a Dependee, in the order of being added.
+//
+// It's O(degree) with a reverse index built on demand, the index is invalidated by Add.
+// Prefer it to Dep().DownstreamOf when querying repeatedly.
+func (s *Workflow) DownstreamOf(dependee StepDoer) []StepDoer {
+	return append([]StepDoer(nil), s.downstreamIndex()[dependee]...)
+}
+
+// downstreamIndex returns the reverse index of deps, builds it if absent.
+// The returned index should not be modified.
+func (s *Workflow) downstreamIndex() map[StepDoer][]StepDoer {
+	s.downstreamMu.Lock()
+	defer s.downstreamMu.Unlock()
+	if s.downstream == nil {
+		s.downstream = make(map[StepDoer][]StepDoer)
+		for _, step := range s.steps {
+			for _, up := range s.deps.UpstreamOf(step) {
+				if ds := s.downstream[up]; len(ds) == 0 || ds[len(ds)-1] != step {
+					s.downstream[up] = append(ds, step)
+				}
+			}
+		}
+	}
+	return s.downstream
+}
+
 // Dep returns the Steps and its depedencies in this Workflow.
 //
 // Iterate all Steps and its dependencies:
@@ -53,15 +186,95 @@ func (s *Workflow) Dep() dependency {
 // and waits until all Steps terminated.
 //
 // Run will block the current goroutine.
+//
+// Once ctx is done, the Workflow stops: Steps not started yet (including the ones waiting for
+// a concurrency slot) are Canceled with the cause of ctx recorded in ErrWorkflow.
 func (s *Workflow) Run(ctx context.Context) error {
+	return s.run(ctx, runOptions{})
+}
+
+// Validate checks the Workflow as Run does before running any Step,
+// and returns ErrValidation with all the problems found,
+// e.g. unexpected initial status of Steps, cycle dependency, nil Steps and policy violations (see WorkflowPolicyBounds).
+//
+// It returns ErrWorkflowHasRun if the Workflow has run, and ErrWorkflowIsRunning if it's running.
+func (s *Workflow) Validate() error {
 	if !s.isRunning.TryLock() {
 		return ErrWorkflowIsRunning
 	}
 	defer s.isRunning.Unlock()
+	return s.preflight()
+}
 
-	if s.when != nil && !s.when(ctx) {
+// DryRun checks the Workflow as Run does (Steps' initial status, cycle dependency),
+// and returns the execution plan without running any Step,
+// e.g. to validate a dynamically assembled Workflow in CI and print what would run.
+//
+// The plan is the Steps in topological batches, Steps in a batch can run in parallel,
+// and a Step is in the batch right after its last Dependee's.
+// Neither Input functions nor Condition / When are evaluated, so all Steps are planned.
+// Steps stay Pending afterward, so Run can follow immediately.
+func (s *Workflow) DryRun(ctx context.Context) ([][]StepReader, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !s.isRunning.TryLock() {
+		return nil, ErrWorkflowIsRunning
+	}
+	defer s.isRunning.Unlock()
+	if err := s.preflight(); err != nil {
+		return nil, err
+	}
+	levels, err := s.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+	var plan [][]StepReader
+	for _, level := range levels {
+		batch := make([]StepReader, 0, len(level))
+		for _, step := range level {
+			batch = append(batch, step)
+		}
+		plan = append(plan, batch)
+	}
+	return plan, nil
+}
+
+// runOptions alters a single run of the Workflow, without changing its options.
+type runOptions struct {
+	failFast bool // as WorkflowFailFast, used by atomic Stage
+}
+
+func (s *Workflow) run(ctx context.Context, opts runOptions) (err error) {
+	if !s.isRunning.TryLock() {
+		return ErrWorkflowIsRunning
+	}
+	defer s.isRunning.Unlock()
+	s.inheritSpanTracer(ctx)
+	if s.runSpanTracer != nil {
+		var span Span
+		ctx, span = s.runSpanTracer.Start(ctx, "Workflow")
+		defer func() {
+			status := StepStatus(StepStatusSucceeded)
+			if err != nil {
+				status = StepStatusFailed
+			}
+			span.End(status, 0, err)
+		}()
+	}
+	if s.leakCheck {
+		defer s.checkLeak(runtime.NumGoroutine())
+	}
+	s.runOpts = opts
+	defer s.closeEvents()
+	s.startHooks()
+	defer s.stopHooks()
+
+	if s.when != nil && !s.when(context.WithValue(ctx, workflowKey{}, s)) {
 		for step := range s.deps {
+			old := step.GetStatus()
 			step.setStatus(StepStatusSkipped)
+			s.publish(step, old, StepStatusSkipped, nil)
 		}
 		return nil
 	}
@@ -71,37 +284,99 @@ func (s *Workflow) Run(ctx context.Context) error {
 		return err
 	}
 
+	s.keepTerminated = false
+	s.runPlan = s.compiled()
+	s.newSeed()
+	s.inheritLogger(ctx)
+
+	s.errsMu.Lock()
 	s.errs = make(ErrWorkflow)
-	s.oneStepTerminated = make(chan struct{}, len(s.deps))
+	s.deadLetters = nil
+	records := make(map[StepDoer]*stepRecord)
+	for _, step := range s.steps {
+		if status := step.GetStatus(); status.IsTerminated() { // kept by ResetFailed or LoadStatuses
+			if r, ok := s.records[step]; ok {
+				records[step] = r
+			}
+			if status == StepStatusFailed {
+				s.errs[step] = ErrFailedBeforeResume
+			}
+			continue
+		}
+		// clear the times of last run
+		step.setStartedAt(time.Time{})
+		step.setFinishedAt(time.Time{})
+		step.setErr(nil)
+	}
+	s.records = records
+	s.errsMu.Unlock()
+	s.loadIndeterminate()
+	if s.timeout > 0 {
+		timeoutCtx, cancelTimeout := s.withTimeout(ctx, s.timeout, ErrWorkflowTimeout)
+		defer cancelTimeout()
+		ctx = timeoutCtx
+	}
+	// stop scheduling Pending Steps once ctx is done, e.g. WorkflowTimeout exceeded
+	runCtx := ctx
+	stopAfter := context.AfterFunc(runCtx, func() {
+		s.stop(context.Cause(runCtx), true)
+	})
+	defer stopAfter()
+	ctx, cancel := context.WithCancelCause(context.WithValue(ctx, workflowKey{}, s))
+	defer cancel(nil)
+	s.oneStepTerminated = make(chan StepDoer, len(s.steps))
+	s.stopMu.Lock()
+	s.cancelRun = cancel
+	s.wake = make(chan struct{}, 1)
+	s.stopCh = make(chan struct{})
+	if s.stopCause != nil { // stopped before Run, see Cancel
+		close(s.stopCh)
+	}
+	s.stopMu.Unlock()
+	s.admitRecheck = new(atomic.Bool)
+	defer s.startStagger()()
+	defer s.startNotBefore()()
+	if s.runPlan != nil {
+		s.frontier = newFrontierFromPlan(s.runPlan)
+	} else {
+		s.frontier = newFrontier(s.steps, s.deps, s.downstreamIndex())
+	}
+	// the Steps kept by ResetFailed or LoadStatuses have terminated
+	terminated := 0
+	for _, step := range s.steps {
+		if step.GetStatus().IsTerminated() {
+			terminated++
+			s.frontier.pushDownstreamOf(step)
+		}
+	}
+	s.startSaver(ctx)
 	// first tick
 	s.tick(ctx)
-	// each time one Step terminated, tick forward
-	for range s.oneStepTerminated {
-		if s.IsTerminated() {
-			break
+	// each time one Step terminated or woken up, tick forward,
+	// every Step signals exactly once when it terminated.
+	for terminated < len(s.steps) {
+		select {
+		case step := <-s.oneStepTerminated:
+			terminated++
+			s.frontier.pushDownstreamOf(step)
+		case <-s.wake:
 		}
 		s.tick(ctx)
 	}
+	s.paused.Store(false) // the held Steps are Canceled if not continued
 	// consume all the following singals cooperataed with waitGroup
 	s.waitGroup.Wait()
 	close(s.oneStepTerminated)
+	storeErr := s.stopSaver()
 
 	// check whether all Steps succeeded without error
 	if s.errs.IsNil() {
-		return nil
+		return storeErr
 	}
-	return s.errs
-}
-
-const scanned StepStatus = "scanned" // a private status for preflight
-
-func isAllDependeeScanned(deps []StepReader) bool {
-	for _, dep := range deps {
-		if dep.GetStatus() != scanned {
-			return false
-		}
+	if storeErr != nil {
+		return errors.Join(s.errs, storeErr)
 	}
-	return true
+	return s.errs
 }
 
 func (s *Workflow) preflight() error {
@@ -110,181 +385,428 @@ func (s *Workflow) preflight() error {
 		return ErrWorkflowHasRun
 	}
 
-	// assert all Steps' status is Pending
+	var errs ErrValidation
+	// assert no nil Step
+	var steps []StepDoer
+	for _, step := range s.steps {
+		if !isNilStep(step) {
+			steps = append(steps, step)
+		}
+	}
+	hasNil := len(steps) < len(s.steps)
+	if hasNil {
+		errs = append(errs, ErrNilStep)
+	}
+
+	// assert all Steps' status is Pending, or terminated kept by ResetFailed or LoadStatuses
 	unexpectStatusSteps := []StepReader{}
-	for step := range s.deps {
+	for _, step := range steps {
+		if s.keepTerminated && step.GetStatus().IsTerminated() {
+			continue
+		}
 		if step.GetStatus() != StepStatusPending {
 			unexpectStatusSteps = append(unexpectStatusSteps, step)
 		}
 	}
 	if len(unexpectStatusSteps) > 0 {
-		return ErrUnexpectStepInitStatus(unexpectStatusSteps)
+		errs = append(errs, ErrUnexpectStepInitStatus(unexpectStatusSteps))
 	}
 
-	// assert all dependency would not form a cycle
-	// start scanning, mark Step as Scanned only when its all depdencies are Scanned
-	for {
-		hasNewScanned := false // whether a new Step being marked as Scanned this turn
-		for step := range s.deps {
-			if step.GetStatus() == scanned {
-				continue
-			}
-			if isAllDependeeScanned(s.deps.listUpstreamReporterOf(step)) {
-				hasNewScanned = true
-				step.setStatus(scanned)
-			}
-		}
-		if !hasNewScanned { // break when no new Step being Scanned
-			break
+	// assert the timeout and retry of Steps are within the policy bounds
+	errs = append(errs, s.checkPolicy(steps)...)
+
+	// assert all dependency would not form a cycle,
+	// skipped with nil Steps, since the Steps depending on them can never be leveled,
+	// and skipped if compiled, since Compile has checked it
+	if !hasNil && s.compiled() == nil {
+		if _, err := topologicalOrder(s.steps, s.deps); err != nil {
+			errs = append(errs, err)
 		}
 	}
-	// check whether still have Steps not Scanned,
-	// not Scanned Steps are in a cycle.
-	stepsInCycle := map[StepReader][]StepReader{}
-	for step := range s.deps {
-		if step.GetStatus() != scanned {
-			for _, dep := range s.deps.listUpstreamReporterOf(step) {
-				if dep.GetStatus() != scanned {
-					stepsInCycle[step] = append(stepsInCycle[step], dep)
-				}
-			}
-		}
+	if len(errs) > 0 {
+		return errs
 	}
-	if len(stepsInCycle) > 0 {
-		return ErrCycleDependency(stepsInCycle)
+	return nil
+}
+
+// isNilStep returns whether the Step is nil, or a nil pointer.
+func isNilStep(step StepDoer) bool {
+	if step == nil {
+		return true
 	}
+	v := reflect.ValueOf(step)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
 
-	// reset all Steps' status to Pending
-	for step := range s.deps {
-		step.setStatus(StepStatusPending)
+func (s *Workflow) signalTick(step StepDoer) {
+	s.oneStepTerminated <- step
+}
+
+// wakeUp signals for next tick without blocking,
+// e.g. when a lease is released or a timer fires.
+func wakeUp(wake chan struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default:
 	}
-	return nil
 }
 
-func (s *Workflow) signalTick() {
-	s.oneStepTerminated <- struct{}{}
+// stop makes the Workflow stop scheduling, all Pending Steps will be Canceled with cause,
+// if interrupt, the context of running Steps will be canceled as well.
+// Only the first cause is kept.
+func (s *Workflow) stop(cause error, interrupt bool) {
+	s.stopMu.Lock()
+	defer s.stopMu.Unlock()
+	if s.stopCause != nil {
+		return
+	}
+	s.stopCause = cause
+	if interrupt && s.cancelRun != nil {
+		s.cancelRun(cause)
+	}
+	if s.stopCh != nil {
+		close(s.stopCh)
+	}
+	wakeUp(s.wake) // to sweep the Pending Steps
+}
+
+// Cancel stops the Workflow from starting Pending Steps,
+// the remaining Pending Steps are Canceled with ErrWorkflowCanceled,
+// and the running Steps are left to drain, then Run returns the partial ErrWorkflow.
+//
+// Cancel is safe to call multiple times and from another goroutine.
+// If called before Run, the next Run cancels all Steps, until Reset.
+func (s *Workflow) Cancel() {
+	s.stop(ErrWorkflowCanceled, false)
+}
+
+// stopped returns the cause if the Workflow has stopped scheduling.
+func (s *Workflow) stopped() error {
+	s.stopMu.Lock()
+	defer s.stopMu.Unlock()
+	return s.stopCause
+}
+
+// cancelStep marks a Pending Step as Canceled with the cause recorded as its error.
+func (s *Workflow) cancelStep(ctx context.Context, step StepDoer, cause error) {
+	s.errsMu.Lock()
+	s.errs[step] = cause
+	s.errsMu.Unlock()
+	s.terminate(ctx, step, StepStatusCanceled, cause)
+}
+
+// terminate sets the terminated status of a Step, calls the after hook and signals for next tick.
+func (s *Workflow) terminate(ctx context.Context, step StepDoer, status StepStatus, err error) {
+	s.recordFinish(step)
+	old := step.GetStatus()
+	step.setStatus(status)
+	s.logTransition(ctx, step, status, err)
+	s.publish(step, old, status, err)
+	s.endSpan(ctx, step, status, err)
+	s.saveState()
+	if s.afterStep != nil {
+		ctx := context.WithoutCancel(ctx)
+		s.dispatchHook(func() { s.afterStep(ctx, step, err) })
+	}
+	s.signalTick(step)
 }
 
 // tick will not block, it starts a goroutine for each runnable Step.
 func (s *Workflow) tick(ctx context.Context) {
+	// stop before visiting the Steps in case ctx is done,
+	// but the stop in run's AfterFunc has not happened yet
+	if ctx.Err() != nil {
+		s.stop(context.Cause(ctx), true)
+	}
+	defer func() {
+		// cancel all Pending Steps if the Workflow has stopped scheduling
+		if cause := s.stopped(); cause != nil && !s.frontier.swept {
+			s.frontier.swept = true
+			for _, r := range s.frontier.ready {
+				r.leave()
+			}
+			s.frontier.ready = nil
+			for _, step := range s.steps {
+				if step.GetStatus() == StepStatusPending {
+					s.cancelStep(ctx, step, cause)
+				}
+			}
+		}
+	}()
+	s.releaseBarrier()
 tick:
-	for step := range s.deps {
+	for _, step := range s.frontier.pop() {
 		// skip if the Step is not Pending
 		if step.GetStatus() != StepStatusPending {
 			continue
 		}
+		// stop scheduling if the Workflow has stopped
+		if s.stopped() != nil {
+			return
+		}
 		// check whether all Dependees / Upstreams are terminated
-		es := s.deps.listUpstreamReporterOf(step)
+		es := s.upstreamOf(step)
 		for _, e := range es {
 			if !e.GetStatus().IsTerminated() {
 				continue tick
 			}
 		}
+		// hold the Step until Continue if it depends on a barrier Step
+		if s.heldByBarrier(step) {
+			s.recordPending(step, PendingPaused)
+			s.frontier.held = append(s.frontier.held, step)
+			s.paused.Store(true)
+			continue
+		}
 		// check whether the Step should be Canceled via Condition
 		cond := step.getCondition()
 		if cond == nil {
-			cond = DefaultCondition
+			cond = s.DefaultCondition()
 		}
-		if !cond(es) {
-			step.setStatus(StepStatusCanceled)
-			s.signalTick()
+		if !s.conditionPasses(step, es, cond) {
+			s.terminate(ctx, step, s.rejectedStatus(es), nil)
 			continue
 		}
 		// check whether the Step should be skip via When
 		when := step.getWhen()
 		if when == nil {
-			when = DefaultWhenFunc
+			when = s.DefaultWhen()
 		}
-		if !when(ctx) {
-			step.setStatus(StepStatusSkipped)
-			s.signalTick()
+		if !when(context.WithValue(ctx, stepKey{}, step)) {
+			s.terminate(ctx, step, StepStatusSkipped, nil)
+			continue
+		}
+		// reuse the Output of a prior run
+		if step.HasRun() && (s.skipIfOutputPresent || step.getSkipIfOutputPresent()) {
+			s.terminate(ctx, step, StepStatusSucceeded, nil)
 			continue
 		}
-		// if WithMaxConcurrency is set
-		if s.leaseBucket != nil {
-			s.leaseBucket <- struct{}{} // lease
+		// the Step is ready, queue it to start
+		s.recordReady(step)
+		s.recordPending(step, PendingReady)
+		r := &readyStep{step: step}
+		s.watchStartDeadline(ctx, r)
+		s.frontier.ready = append(s.frontier.ready, r)
+	}
+	s.startReady(ctx)
+}
+
+// startReady starts the Steps in the ready queue in order,
+// a Step waits if its lease is not available (see WorkflowMaxConcurrency and WorkflowConcurrencyGroup),
+// and so do the Steps behind it sharing the same bucket.
+//
+// The Steps left waiting in the queue are still checked for StartDeadline,
+// so they fail even when the leases are exhausted.
+func (s *Workflow) startReady(ctx context.Context) {
+	picked := len(s.frontier.ready)
+	if s.scheduler != nil && picked > 0 {
+		picked = s.pickReady()
+	}
+	waiting := s.frontier.ready[:0]
+	full := map[chan struct{}]bool{} // the buckets without slot in this tick
+	for i, r := range s.frontier.ready {
+		step := r.step
+		if s.stopped() != nil || step.GetStatus() != StepStatusPending {
+			waiting = append(waiting, r) // the sweep in tick handles it
+			continue
+		}
+		if i >= picked { // not picked by the Scheduler in this tick
+			waiting = append(waiting, r)
+			continue
+		}
+		if s.staggered(step) { // woken up at its turn
+			waiting = append(waiting, r)
+			continue
 		}
+		if s.tooEarly(step) { // woken up at its NotBefore
+			waiting = append(waiting, r)
+			continue
+		}
+		if sd := step.getStartDeadline(); sd.fail && r.isExceeded() {
+			r.leave()
+			err := ErrStartDeadlineExceeded{Deadline: sd.d}
+			s.errsMu.Lock()
+			s.errs[step] = err
+			s.errsMu.Unlock()
+			s.failStep(ctx, step, err)
+			continue
+		}
+		// if WithMaxConcurrency or WorkflowConcurrencyGroup is set
+		l := s.leaseOf(step)
+		if slices.ContainsFunc(l.buckets, func(b chan struct{}) bool { return full[b] }) {
+			s.recordPending(step, PendingForLease)
+			waiting = append(waiting, r)
+			continue
+		}
+		if !s.admitted(ctx, step) {
+			s.recordPending(step, PendingAdmission)
+			waiting = append(waiting, r)
+			continue
+		}
+		if bucket := l.tryAcquire(); bucket != nil {
+			s.recordPending(step, PendingForLease)
+			full[bucket] = true // keep the order, Steps behind in the bucket wait as well
+			waiting = append(waiting, r)
+			continue
+		}
+		r.leave()
 		// start the Step
+		s.recordStart(step)
 		step.setStatus(StepStatusRunning)
+		step.setHasRun(false) // its Output is rewritten
+		s.logTransition(ctx, step, StepStatusRunning, nil)
+		s.publish(step, StepStatusPending, StepStatusRunning, nil)
 		s.waitGroup.Add(1)
 		go func(ctx context.Context, step StepDoer) {
 			defer s.waitGroup.Done()
-			err := s.runStep(ctx, step)
-			// mark the Step as succeeded or failed
-			if err != nil {
-				step.setStatus(StepStatusFailed)
-			} else {
-				step.setStatus(StepStatusSucceeded)
-			}
-			if s.leaseBucket != nil {
-				<-s.leaseBucket // unlease
+			ctx = s.labelStep(ctx, step)
+			hookCtx := ctx // the context derived by before hook, passed to after hook
+			err := s.runStep(ctx, step, l, &hookCtx)
+			l.release()
+			// mark the Step as succeeded, failed, or canceled while waiting for a lease
+			var leaseWait errLeaseWait
+			switch {
+			case errors.As(err, &leaseWait):
+				s.cancelStep(hookCtx, step, leaseWait.cause)
+			case err != nil:
+				s.failStep(hookCtx, step, err)
+			default:
+				step.setHasRun(true)
+				s.terminate(hookCtx, step, StepStatusSucceeded, nil)
 			}
-			s.signalTick()
-		}(ctx, step)
+		}(s.startSpan(ctx, step), step)
+	}
+	clear(s.frontier.ready[len(waiting):])
+	s.frontier.ready = waiting
+}
+
+// failStep marks a Step as Failed, the error should have been recorded in errs.
+func (s *Workflow) failStep(ctx context.Context, step StepDoer, err error) {
+	if s.failFast || s.runOpts.failFast {
+		s.stop(context.Canceled, true)
 	}
+	s.terminate(ctx, step, StepStatusFailed, err)
 }
 
-func (s *Workflow) runStep(ctx context.Context, step StepDoer) error {
+func (s *Workflow) runStep(ctx context.Context, step StepDoer, l *lease, hookCtx *context.Context) (err error) {
+	runCtx, stop := ctx, s.stopCh
+	logger := s.stepLogger(step)
+	ctx = context.WithValue(ctx, loggerKey{}, logger)
+	ctx, endSpan := s.trace(ctx, step)
+	defer func() { endSpan(err) }()
 	// set timeout for the Step
 	var notAfter time.Time
 	timeout := step.getTimeout()
+	if timeout == 0 {
+		timeout = s.defaultTimeout
+	}
 	if timeout > 0 {
-		notAfter = time.Now().Add(timeout)
+		notAfter = s.clock().Now().Add(timeout)
 		var cancel func()
-		ctx, cancel = context.WithTimeout(ctx, timeout)
+		ctx, cancel = s.withTimeout(ctx, timeout, context.DeadlineExceeded)
 		defer cancel()
 	}
-	// run the Step with or without retry
-	do := s.makeDoForStep(step)
-	var err error
-	if retryOpt := step.getRetry(); retryOpt == nil {
-		err = do(ctx)
-	} else {
-		err = s.retry(retryOpt)(ctx, do, notAfter)
+	// run the Step with or without retry, holding its resources
+	do := s.makeDoForStep(step, hookCtx)
+	err = withResources(ctx, step.getResources(), func() error {
+		retryOpt := step.getRetry()
+		if retryOpt == nil {
+			retryOpt = s.defaultRetry
+		}
+		if retryOpt != nil {
+			// release the lease while sleeping between attempts,
+			// and acquire it again before the next attempt
+			doWithLease := func(ctx context.Context) error {
+				if err := l.acquire(ctx, stop); err != nil {
+					cause := s.stopped()
+					if cause == nil && runCtx.Err() != nil {
+						cause = context.Cause(runCtx)
+					}
+					if cause != nil {
+						// the Workflow stopped while waiting, cancel the Step instead of failing it
+						return backoff.Permanent(errLeaseWait{cause})
+					}
+					return err
+				}
+				return do(ctx)
+			}
+			wake := s.wake
+			failed := 0
+			return s.retry(*retryOpt)(ctx, doWithLease, notAfter, func(err error, next time.Duration) {
+				failed++
+				logger.LogAttrs(ctx, slog.LevelWarn, "Step attempt failed, will retry",
+					slog.Int("attempt", failed), slog.Any("error", err), slog.Duration("next", next))
+				l.release()
+				wakeUp(wake)
+			})
+		}
+		return do(ctx)
+	})
+	if errors.As(err, new(errLeaseWait)) {
+		return err // the caller cancels the Step
+	}
+	if err != nil && s.wrapErrors {
+		err = &errWithStep{step, err}
 	}
 	// use mutex to guard errs
 	s.errsMu.Lock()
 	s.errs[step] = err
+	if err != nil {
+		s.recordDeadLetter(step, err)
+	}
 	s.errsMu.Unlock()
 	return err
 }
 
 // makeDoForStep is panic-free from Step's Do and Input.
-func (s *Workflow) makeDoForStep(step StepDoer) func(ctx context.Context) error {
-	return func(ctx context.Context) error {
-		return catchPanicAsError(
-			func() error {
-				// apply dependee's output to current Step's input
-				for _, l := range s.deps[step] {
-					if l.Dependee != nil {
-						switch l.Dependee.GetStatus() {
-						case StepStatusSucceeded, StepStatusFailed:
-							// only flow data from succeeded or failed Step
-							// TODO(xuxife): is this a good decision?
-						default:
-							continue
-						}
-					} // or flow data from Dependee == nil (it's Input)
-					if l.Flow != nil {
-						if ferr := catchPanicAsError(func() error {
-							return l.Flow(ctx)
-						}); ferr != nil {
-							return &ErrFlow{
-								Err:  ferr,
-								From: l.Dependee,
-							}
-						}
-					}
+//
+// It runs the phases of the Step in order and records the time spent in each,
+// the first error stops the following phases.
+//
+// The context returned by before hook is stored into hookCtx (detached from cancellation),
+// so the after hook can close what before opened.
+func (s *Workflow) makeDoForStep(step StepDoer, hookCtx *context.Context) func(ctx context.Context) error {
+	attempt := uint64(0)
+	rng := s.randFor(step)
+	return func(ctx context.Context) (err error) {
+		attempt++
+		s.recordAttempt(step, attempt)
+		ctx = context.WithValue(ctx, attemptKey{}, attempt)
+		ctx, endSpan := s.trace(ctx, step)
+		defer func() { endSpan(err) }()
+		ctx = context.WithValue(ctx, randKey{}, rng)
+		for _, p := range s.phasesOf(step, attempt) {
+			if p.Name == PhaseDo && s.beforeStep != nil {
+				derived := ctx
+				if err := catchPanicAsError(func() error {
+					derived = s.beforeStep(ctx, step)
+					return nil
+				}); err != nil {
+					s.recordFailedPhase(step, p.Name)
+					return fmt.Errorf("before hook: %w", err)
 				}
-				return step.Do(ctx)
-			},
-		)
+				if derived != nil {
+					ctx = derived
+					*hookCtx = context.WithoutCancel(derived)
+				}
+			}
+			start := s.clock().Now()
+			err := catchPanicAsError(func() error {
+				return p.Do(ctx)
+			})
+			s.recordPhase(step, p.Name, start, s.clock().Now())
+			if err != nil {
+				s.recordFailedPhase(step, p.Name)
+				return err
+			}
+		}
+		return nil
 	}
 }
 
 // IsTerminated returns true if all Steps terminated.
 func (s *Workflow) IsTerminated() bool {
-	for step := range s.deps {
+	for _, step := range s.steps {
 		if !step.GetStatus().IsTerminated() {
 			return false
 		}
@@ -292,6 +814,28 @@ func (s *Workflow) IsTerminated() bool {
 	return true
 }
 
+// Status returns a snapshot of the status of all Steps in Workflow.
+//
+// Status is safe to call while the Workflow is running, even from inside a Step.
+func (s *Workflow) Status() map[StepReader]StepStatus {
+	status := make(map[StepReader]StepStatus, len(s.deps))
+	for step := range s.deps {
+		status[step] = step.GetStatus()
+	}
+	return status
+}
+
+// errsOf returns the recorded errors of the Steps, nil for Steps without error.
+func (s *Workflow) errsOf(steps []StepDoer) map[StepReader]error {
+	s.errsMu.RLock()
+	defer s.errsMu.RUnlock()
+	errs := make(map[StepReader]error, len(steps))
+	for _, step := range steps {
+		errs[step] = s.errs[step]
+	}
+	return errs
+}
+
 // Err returns the errors of all Steps in Workflow.
 //
 // Usage:
@@ -325,6 +869,7 @@ func (s *Workflow) Err() ErrWorkflow {
 
 // Reset resets every Step's status to StepStatusPending,
 // will not reset input/output.
+// StepBase.HasRun is cleared too, except for the Steps skipped if their Output is present.
 // Reset will return ErrWorkflowIsRunning if the workflow is running.
 func (s *Workflow) Reset() error {
 	if !s.isRunning.TryLock() {
@@ -334,9 +879,82 @@ func (s *Workflow) Reset() error {
 
 	for step := range s.deps {
 		step.setStatus(StepStatusPending)
+		if !s.skipIfOutputPresent && !step.getSkipIfOutputPresent() {
+			step.setHasRun(false)
+		}
+	}
+	s.errsMu.Lock()
+	s.errs = nil
+	s.records = nil
+	s.deadLetters = nil
+	s.errsMu.Unlock()
+	s.keepTerminated = false
+	s.continued.Store(false)
+	s.oneStepTerminated = nil
+	s.stopMu.Lock()
+	s.stopCause = nil
+	s.cancelRun = nil
+	s.stopMu.Unlock()
+	return nil
+}
+
+// ResetFailed resets the Steps not Succeeded in the last run to StepStatusPending,
+// and keeps the Succeeded ones, so the next Run only runs the Steps not Succeeded,
+// e.g. to retry a Workflow without repeating the side effects of the Succeeded Steps.
+//
+// Like Reset, it will not reset input/output,
+// and returns ErrWorkflowIsRunning if the workflow is running.
+func (s *Workflow) ResetFailed() error {
+	if !s.isRunning.TryLock() {
+		return ErrWorkflowIsRunning
+	}
+	s.isRunning.Unlock()
+
+	s.errsMu.Lock()
+	for step := range s.deps {
+		if step.GetStatus() != StepStatusSucceeded {
+			step.setStatus(StepStatusPending)
+			delete(s.records, step)
+		}
 	}
 	s.errs = nil
-	s.leaseBucket = nil
+	s.errsMu.Unlock()
+	s.keepTerminated = true
+	s.continued.Store(false)
 	s.oneStepTerminated = nil
+	s.stopMu.Lock()
+	s.stopCause = nil
+	s.cancelRun = nil
+	s.stopMu.Unlock()
 	return nil
 }
+
+// RerunFailed runs the Workflow again after ResetFailed,
+// so only the Steps not Succeeded in the last run are run again,
+// e.g. Failed and Canceled Steps and their Skipped downstream.
+//
+// The Succeeded Steps keep their Output, and still flow it to their re-run Dependers.
+func (s *Workflow) RerunFailed(ctx context.Context) error {
+	if err := s.ResetFailed(); err != nil {
+		return err
+	}
+	return s.Run(ctx)
+}
+
+// HasRun returns whether the Workflow has run since created or reset,
+// Run returns ErrWorkflowHasRun if so, call Reset before running it again.
+func (s *Workflow) HasRun() bool {
+	s.errsMu.RLock()
+	defer s.errsMu.RUnlock()
+	return s.errs != nil
+}
+
+// IsRunning returns whether the Workflow is running,
+// Run and Reset return ErrWorkflowIsRunning if so.
+func (s *Workflow) IsRunning() bool {
+	if !s.isRunning.TryLock() {
+		return true
+	}
+	s.isRunning.Unlock()
+	return false
+}
@@ -1,9 +1,24 @@
 package pl
 
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // WorkflowOption alters the behavior of a Workflow.
 type WorkflowOption func(*Workflow)
 
+// WithOptions applies the options to the Workflow.
+//
+// Options other than WorkflowDefaultCondition and WorkflowDefaultWhen are read by Run without lock,
+// so apply them before Run, not while the Workflow is running.
 func (s *Workflow) WithOptions(opts ...WorkflowOption) *Workflow {
+	s.optionsMu.Lock()
+	defer s.optionsMu.Unlock()
 	for _, opt := range opts {
 		opt(s)
 	}
@@ -11,8 +26,17 @@ func (s *Workflow) WithOptions(opts ...WorkflowOption) *Workflow {
 }
 
 // WorkflowMaxConcurrency limits the max concurrency of running Steps.
+//
+// A Step sleeping between retry attempts releases its slot,
+// and competes for a slot again before the next attempt,
+// so it may wait longer than the backoff interval when other Steps occupy all slots.
+// A Step waiting for a slot is Canceled once the Workflow stops (see Cancel) or the context of Run is done.
+//
+// Ready Steps start in the order of being added, so WorkflowMaxConcurrency(1)
+// runs the Steps one by one in a deterministic order, e.g. for examples and tests.
 func WorkflowMaxConcurrency(n int) WorkflowOption {
 	return func(s *Workflow) {
+		s.applied.record("max concurrency", strconv.Itoa(n))
 		// use buffered channel as a sized bucket
 		// a Step needs to create a lease in the bucket to run,
 		// and remove the lease from the bucket when it's done.
@@ -20,9 +44,281 @@ func WorkflowMaxConcurrency(n int) WorkflowOption {
 	}
 }
 
+// WorkflowFailFast makes the Workflow stop at the first Failed Step:
+// the context of running Steps is canceled, and all Pending Steps are Canceled
+// with context.Canceled recorded in ErrWorkflow.
+//
+// Without this option, the Workflow runs every Step whose Condition passes.
+func WorkflowFailFast() WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("fail-fast", "true")
+		s.failFast = true
+	}
+}
+
+// WorkflowTimeout bounds the total run time of the Workflow.
+//
+// The context passed to Run is wrapped with context.WithTimeout,
+// so every running Step observes the cancellation via its context
+// (context.Cause of which is ErrWorkflowTimeout),
+// and Steps not started yet are Canceled with ErrWorkflowTimeout recorded in ErrWorkflow,
+// which wraps context.DeadlineExceeded.
+//
+// Step level Timeout is derived from the Workflow context,
+// so a Step is bounded by whichever deadline comes first,
+// a Step exceeding its own Timeout fails as usual without stopping the Workflow.
+func WorkflowTimeout(timeout time.Duration) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("timeout", summarizeDuration(timeout))
+		s.timeout = timeout
+	}
+}
+
+// WorkflowStepHooks sets hooks called around each Step, either can be nil.
+//
+// before is called right before the Step's Do (after Flow and Validate) in each attempt,
+// the returned context is passed to Do, e.g. to carry a logger or trace span.
+// A panic in before fails the attempt without calling Do.
+//
+// after is called after the Step's status is set to terminated, from the hook queue (see WorkflowHookQueue),
+// with the error recorded for the Step (including ErrFlow and panic converted errors).
+// after receives the context returned by the last before (without its cancellation),
+// so it can end what before started, e.g. the trace span.
+// after is also called for Skipped and Canceled Steps, with a nil error
+// unless the Step is Canceled because the Workflow stopped.
+// A panic in after is recovered and dropped, since the Step has terminated.
+func WorkflowStepHooks(
+	before func(ctx context.Context, step StepReader) context.Context,
+	after func(ctx context.Context, step StepReader, err error),
+) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("step hooks", summarizeSet(before != nil || after != nil))
+		s.beforeStep = before
+		s.afterStep = after
+	}
+}
+
 // WorkflowWhen sets the Workflow-level When condition.
 func WorkflowWhen(when When) WorkflowOption {
 	return func(s *Workflow) {
+		s.applied.record("when", summarizeSet(when != nil))
 		s.when = when
 	}
 }
+
+// WorkflowDefaultCondition sets the Condition for Steps in this Workflow without one.
+//
+// It overrides the package level DefaultCondition for this Workflow only.
+func WorkflowDefaultCondition(cond Condition) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("default condition", summarizeSet(cond != nil))
+		s.defaultCond = cond
+	}
+}
+
+// WorkflowContinueOnError makes a failure not cancel the downstream Steps,
+// e.g. for a batch of independent items.
+//
+// It sets the default Condition of the Workflow to SucceededOrFailed (see WorkflowDefaultCondition),
+// so Steps without an explicit Condition run after their Dependees Succeeded or Failed,
+// and receive whatever Output the Dependees have.
+// Conditions set explicitly on Steps are not overridden.
+// All errors are still collected into ErrWorkflow.
+func WorkflowContinueOnError() WorkflowOption {
+	return WorkflowDefaultCondition(SucceededOrFailed)
+}
+
+// WorkflowDefaultWhen sets the When for Steps in this Workflow without one.
+//
+// It overrides the package level DefaultWhenFunc for this Workflow only.
+func WorkflowDefaultWhen(when When) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("default when", summarizeSet(when != nil))
+		s.defaultWhen = when
+	}
+}
+
+// WorkflowDefaultRetry sets the RetryOption for Steps in this Workflow without one.
+//
+// Settings take precedence as: per-Step explicit > bulk (e.g. Steps(...).Retry) > Workflow default.
+func WorkflowDefaultRetry(opt RetryOption) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("default retry", "set")
+		s.defaultRetry = &opt
+	}
+}
+
+// WorkflowDefaultTimeout sets the timeout for Steps in this Workflow without one.
+//
+// Settings take precedence as: per-Step explicit > bulk (e.g. Steps(...).Timeout) > Workflow default.
+func WorkflowDefaultTimeout(timeout time.Duration) WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("default timeout", summarizeDuration(timeout))
+		s.defaultTimeout = timeout
+	}
+}
+
+// WorkflowWrapErrors makes the Workflow wrap the error of each Failed Step with the Step name,
+// as "<step>: <err>", so the error tells where it's from even when logged alone.
+//
+// The original error is still reachable via errors.Is and errors.As.
+func WorkflowWrapErrors() WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("wrap errors", "true")
+		s.wrapErrors = true
+	}
+}
+
+// WorkflowSkipIfOutputPresent makes every Step Succeeded without running again
+// if it has Succeeded in a prior run (StepBase.HasRun), reusing its Output,
+// e.g. to resume a pipeline after Reset with only the not Succeeded Steps run.
+//
+// HasRun is tracked instead of inspecting the Output, so a zero value Output is still reused.
+// The Step still waits for its Dependees, and its Condition and When are still checked.
+func WorkflowSkipIfOutputPresent() WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("skip if output present", "true")
+		s.skipIfOutputPresent = true
+	}
+}
+
+// WorkflowFlowFromAllTerminated makes the Output of Dependees flow whatever their terminated status,
+// e.g. a Canceled Dependee whose Output is a meaningful default.
+//
+// The statuses whose Output flows to the Depender, via DependsOn, DirectDependsOn and Adapt(s):
+//
+//	Dependee Status | default | WorkflowFlowFromAllTerminated
+//	Succeeded       | flow    | flow
+//	Failed          | flow    | flow
+//	Canceled        | no flow | flow
+//	Skipped         | no flow | flow
+//
+// The fallback of AdaptOr only runs when the Output doesn't flow, so never with this option.
+func WorkflowFlowFromAllTerminated() WorkflowOption {
+	return func(s *Workflow) {
+		s.applied.record("flow from all terminated", "true")
+		s.flowAllTerminated = true
+	}
+}
+
+// appliedOptions tracks the Workflow-level options applied via WithOptions, see OptionsSummary.
+//
+// Each WorkflowXxx option records its summary by name when applied,
+// the last applied one of the same name wins.
+type appliedOptions struct {
+	summaries map[string]string
+	extra     []string // the names not in optionsInSummary, in the order of first applied
+}
+
+func (o *appliedOptions) record(name, summary string) {
+	if o.summaries == nil {
+		o.summaries = make(map[string]string)
+	}
+	if _, ok := o.summaries[name]; !ok && !slices.ContainsFunc(optionsInSummary, func(opt optionInSummary) bool {
+		return opt.name == name
+	}) {
+		o.extra = append(o.extra, name)
+	}
+	o.summaries[name] = summary
+}
+
+type optionInSummary struct {
+	name  string
+	unset string // the summary when the option is not applied
+}
+
+// optionsInSummary are the Workflow-level options in the order of OptionsSummary.
+var optionsInSummary = []optionInSummary{
+	{"max concurrency", "unlimited"},
+	{"timeout", "none"},
+	{"fail-fast", "false"},
+	{"when", "unset"},
+	{"default timeout", "none"},
+	{"default retry", "unset"},
+	{"default condition", "unset"},
+	{"default when", "unset"},
+	{"step hooks", "unset"},
+	{"wrap errors", "false"},
+	{"skip if output present", "false"},
+	{"flow from all terminated", "false"},
+	{"skip propagation", "false"},
+	{"deep copy flow", "false"},
+	{"admission control", "unset"},
+	{"barrier", "none"},
+	{"scheduler", "unset"},
+	{"root stagger", "none"},
+	{"start deadline escalation", "unset"},
+	{"starvation threshold", "none"},
+	{"policy bounds", "unset"},
+	{"soft policy bounds", "unset"},
+	{"hook queue", fmt.Sprintf("%d/%s", DefaultHookQueueSize, HookOverflowBlock)},
+	{"clock", "unset"},
+	{"seed", "random"},
+	{"logger", "unset"},
+	{"step logger", "unset"},
+	{"span tracer", "unset"},
+	{"tracer", "unset"},
+	{"io recorder", "unset"},
+	{"io verifier", "unset"},
+	{"state store", "unset"},
+	{"leak check", "false"},
+}
+
+func summarizeSet(isSet bool) string {
+	if isSet {
+		return "set"
+	}
+	return "unset"
+}
+
+func summarizeDuration(d time.Duration) string {
+	if d <= 0 {
+		return "none"
+	}
+	return d.String()
+}
+
+// OptionsSummary describes the Workflow-level options in effect, e.g. to confirm WithOptions took effect,
+// as comma separated key=value pairs of all options in a fixed order,
+// followed by the per-name ones (e.g. WorkflowConcurrencyGroup) in the order of applied, e.g.
+//
+//	max concurrency=4, timeout=1m0s, fail-fast=true, when=set, default timeout=none, ..., concurrency group db=2
+func (s *Workflow) OptionsSummary() string {
+	s.optionsMu.RLock()
+	defer s.optionsMu.RUnlock()
+	pairs := make([]string, 0, len(optionsInSummary)+len(s.applied.extra))
+	for _, opt := range optionsInSummary {
+		summary, ok := s.applied.summaries[opt.name]
+		if !ok {
+			summary = opt.unset
+		}
+		pairs = append(pairs, opt.name+"="+summary)
+	}
+	for _, name := range s.applied.extra {
+		pairs = append(pairs, name+"="+s.applied.summaries[name])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// DefaultCondition returns the Condition used for Steps without one.
+func (s *Workflow) DefaultCondition() Condition {
+	s.optionsMu.RLock()
+	defer s.optionsMu.RUnlock()
+	if s.defaultCond != nil {
+		return s.defaultCond
+	}
+	if s.skipPropagation {
+		return AllSucceededNoneSkipped
+	}
+	return DefaultCondition
+}
+
+// DefaultWhen returns the When used for Steps without one.
+func (s *Workflow) DefaultWhen() When {
+	s.optionsMu.RLock()
+	defer s.optionsMu.RUnlock()
+	if s.defaultWhen != nil {
+		return s.defaultWhen
+	}
+	return DefaultWhenFunc
+}
@@ -0,0 +1,781 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/xuxife/pl"
+	"github.com/xuxife/pl/pltest"
+)
+
+func succeed(name string) pl.Steper[struct{}, struct{}] {
+	return pl.FuncNoInOut(name, func(context.Context) error { return nil })
+}
+
+func fail(name string) pl.Steper[struct{}, struct{}] {
+	return pl.FuncNoInOut(name, func(context.Context) error { return fmt.Errorf("%s failed", name) })
+}
+
+func TestWorkflowDefaultCondition(t *testing.T) {
+	newWorkflow := func(cond pl.Condition) (*pl.Workflow, pl.StepReader) {
+		root, leaf := fail("root"), succeed("leaf")
+		w := new(pl.Workflow).
+			WithOptions(pl.WorkflowDefaultCondition(cond)).
+			Add(pl.Step(leaf).ExtraDependsOn(root))
+		return w, leaf
+	}
+	always, alwaysLeaf := newWorkflow(pl.Always)
+	succeeded, succeededLeaf := newWorkflow(pl.Succeeded)
+
+	var wg sync.WaitGroup
+	for _, w := range []*pl.Workflow{always, succeeded} {
+		wg.Add(1)
+		go func(w *pl.Workflow) {
+			defer wg.Done()
+			_ = w.Run(context.Background())
+		}(w)
+	}
+	wg.Wait()
+
+	if got := alwaysLeaf.GetStatus(); got != pl.StepStatusSucceeded {
+		t.Errorf("leaf with default Always: want %s, got %s", pl.StepStatusSucceeded, got)
+	}
+	if got := succeededLeaf.GetStatus(); got != pl.StepStatusCanceled {
+		t.Errorf("leaf with default Succeeded: want %s, got %s", pl.StepStatusCanceled, got)
+	}
+}
+
+func TestWorkflowContinueOnError(t *testing.T) {
+	root, leaf, strict := fail("root"), succeed("leaf"), succeed("strict")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowContinueOnError()).
+		Add(
+			pl.Step(leaf).ExtraDependsOn(root),
+			pl.Step(strict).ExtraDependsOn(root).Condition(pl.Succeeded),
+		)
+	err := w.Run(context.Background())
+	if werr := w.Err(); err == nil || werr[root] == nil {
+		t.Errorf("want the failure collected, got %v", err)
+	}
+	if got := leaf.GetStatus(); got != pl.StepStatusSucceeded {
+		t.Errorf("want leaf run after root failed, got %s", got)
+	}
+	if got := strict.GetStatus(); got != pl.StepStatusCanceled {
+		t.Errorf("want explicit Condition not overridden, got %s", got)
+	}
+}
+
+func TestWorkflowHasRunIsRunning(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	step := pl.FuncNoInOut("step", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	w := new(pl.Workflow).Add(pl.Step(step))
+	if w.HasRun() || w.IsRunning() {
+		t.Errorf("want fresh Workflow neither run nor running")
+	}
+
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	<-started
+	if !w.IsRunning() {
+		t.Errorf("want Workflow running")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if !w.HasRun() || w.IsRunning() {
+		t.Errorf("want completed Workflow run and not running")
+	}
+
+	if err := w.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if w.HasRun() {
+		t.Errorf("want reset Workflow not run")
+	}
+}
+
+func TestWorkflowDryRun(t *testing.T) {
+	var called atomic.Bool
+	do := pl.FuncNoInOut("do", func(context.Context) error {
+		called.Store(true)
+		return nil
+	})
+	a, b, c := succeed("a"), succeed("b"), succeed("c")
+	w := new(pl.Workflow).Add(
+		pl.Step(b).ExtraDependsOn(a),
+		pl.Step(c).ExtraDependsOn(a, b).When(func(context.Context) bool {
+			called.Store(true)
+			return true
+		}),
+		pl.Step(do),
+	)
+	plan, err := w.DryRun(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]pl.StepReader{{a, do}, {b}, {c}}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("want plan %v, got %v", want, plan)
+	}
+	if called.Load() {
+		t.Error("want neither Do nor When called in DryRun")
+	}
+	for _, step := range []pl.StepReader{a, b, c, do} {
+		if got := step.GetStatus(); got != pl.StepStatusPending {
+			t.Errorf("want %s Pending after DryRun, got %s", step, got)
+		}
+	}
+	if err := w.Run(context.Background()); err != nil {
+		t.Errorf("want Run right after DryRun, got %v", err)
+	}
+}
+
+func TestWorkflowTopologicalOrder(t *testing.T) {
+	a, b, c, d := succeed("a"), succeed("b"), succeed("c"), succeed("d")
+	w := new(pl.Workflow).Add(
+		pl.Step(d).ExtraDependsOn(b, c),
+		pl.Step(c).ExtraDependsOn(a),
+		pl.Step(b).ExtraDependsOn(a),
+	)
+	levels, err := w.TopologicalOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]pl.StepDoer{{a}, {b, c}, {d}}
+	if !reflect.DeepEqual(levels, want) {
+		t.Errorf("want levels %v, got %v", want, levels)
+	}
+	sorted, err := w.Dep().TopologicalOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]pl.StepDoer{{a}, {b, c}, {d}}; !reflect.DeepEqual(sorted, want) {
+		t.Errorf("want levels sorted by name %v, got %v", want, sorted)
+	}
+
+	x, y := succeed("x"), succeed("y")
+	cyclic := new(pl.Workflow).Add(
+		pl.Step(x).ExtraDependsOn(y),
+		pl.Step(y).ExtraDependsOn(x),
+	)
+	var cycle pl.ErrCycleDependency
+	if _, err := cyclic.TopologicalOrder(); !errors.As(err, &cycle) || len(cycle) != 2 {
+		t.Errorf("want ErrCycleDependency of x and y, got %v", err)
+	}
+	if err := cyclic.Run(context.Background()); !errors.As(err, &cycle) {
+		t.Errorf("want Run fail with ErrCycleDependency, got %v", err)
+	}
+	for _, step := range []pl.StepReader{x, y} {
+		if got := step.GetStatus(); got != pl.StepStatusPending {
+			t.Errorf("want %s Pending after cycle detected, got %s", step, got)
+		}
+	}
+}
+
+func TestWorkflowDefaultWhen(t *testing.T) {
+	step := succeed("step")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowDefaultWhen(pl.Skip)).
+		Add(pl.Step(step))
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := step.GetStatus(); got != pl.StepStatusSkipped {
+		t.Errorf("want %s, got %s", pl.StepStatusSkipped, got)
+	}
+	if new(pl.Workflow).DefaultWhen() == nil || new(pl.Workflow).DefaultCondition() == nil {
+		t.Error("a fresh Workflow should fall back to the package defaults")
+	}
+}
+
+func TestWorkflowFailFast(t *testing.T) {
+	started := make(chan struct{})
+	failing := pl.FuncNoInOut("failing", func(context.Context) error {
+		<-started
+		return fmt.Errorf("failing")
+	})
+	running := pl.FuncNoInOut("running", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	pending := succeed("pending")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowFailFast()).
+		Add(
+			pl.Steps(failing, running),
+			pl.Step(pending).ExtraDependsOn(running),
+		)
+	if err := w.Run(context.Background()); err == nil {
+		t.Fatal("expect error")
+	}
+	werr := w.Err()
+	if werr[failing] == nil || errors.Is(werr[failing], context.Canceled) {
+		t.Errorf("want the original failure, got %v", werr[failing])
+	}
+	if !errors.Is(werr[running], context.Canceled) {
+		t.Errorf("want running Step cut short, got %v", werr[running])
+	}
+	if got := pending.GetStatus(); got != pl.StepStatusCanceled || !errors.Is(werr[pending], context.Canceled) {
+		t.Errorf("want pending Step Canceled with context.Canceled, got %s: %v", got, werr[pending])
+	}
+	if !w.IsTerminated() {
+		t.Error("want Workflow terminated")
+	}
+}
+
+func TestWorkflowTimeout(t *testing.T) {
+	clock := pltest.NewClock(time.Unix(0, 0))
+	started := make(chan struct{})
+	slow := pl.FuncNoInOut("slow", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return context.Cause(ctx)
+	})
+	next := succeed("next")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowClock(clock), pl.WorkflowTimeout(time.Minute)).
+		Add(pl.Step(next).ExtraDependsOn(slow))
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	<-started
+	clock.Advance(time.Minute)
+	if err := <-done; err == nil {
+		t.Fatal("expect error")
+	}
+	werr := w.Err()
+	if !errors.Is(werr[slow], context.DeadlineExceeded) {
+		t.Errorf("want in-flight Step DeadlineExceeded, got %v", werr[slow])
+	}
+	if got := next.GetStatus(); got != pl.StepStatusCanceled {
+		t.Errorf("want Step not started Canceled, got %s", got)
+	}
+	if !errors.Is(werr[next], pl.ErrWorkflowTimeout) || !errors.Is(werr[next], context.DeadlineExceeded) {
+		t.Errorf("want Canceled Step with ErrWorkflowTimeout, got %v", werr[next])
+	}
+}
+
+type hookKey struct{}
+
+func TestWorkflowStepHooks(t *testing.T) {
+	var fromBefore any
+	ok := pl.FuncNoInOut("ok", func(ctx context.Context) error {
+		fromBefore = ctx.Value(hookKey{})
+		return nil
+	})
+	failing, canceled, skipped := fail("failing"), succeed("canceled"), succeed("skipped")
+
+	var mu sync.Mutex
+	after := map[pl.StepReader]error{}
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowStepHooks(
+			func(ctx context.Context, step pl.StepReader) context.Context {
+				return context.WithValue(ctx, hookKey{}, step.String())
+			},
+			func(_ context.Context, step pl.StepReader, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				after[step] = err
+			},
+		)).
+		Add(
+			pl.Step(ok),
+			pl.Step(canceled).ExtraDependsOn(failing),
+			pl.Step(skipped).When(pl.Skip),
+		)
+	_ = w.Run(context.Background())
+
+	if fromBefore != "ok" {
+		t.Errorf("want context from before hook passed to Do, got %v", fromBefore)
+	}
+	if len(after) != 4 {
+		t.Errorf("want after hook called for every Step, got %v", after)
+	}
+	if after[failing] == nil {
+		t.Error("want after hook receive the error of the failed Step")
+	}
+	for _, step := range []pl.StepReader{ok, canceled, skipped} {
+		if err, called := after[step]; !called || err != nil {
+			t.Errorf("want after hook called with nil error for %s, got %v", step, err)
+		}
+	}
+}
+
+func TestWorkflowStepHooksGuarded(t *testing.T) {
+	ok, panicking := succeed("ok"), succeed("panicking")
+	var mu sync.Mutex
+	fromBefore := map[pl.StepReader]any{}
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowStepHooks(
+			func(ctx context.Context, step pl.StepReader) context.Context {
+				if step == panicking {
+					panic("before boom")
+				}
+				return context.WithValue(ctx, hookKey{}, "span")
+			},
+			func(ctx context.Context, step pl.StepReader, _ error) {
+				mu.Lock()
+				fromBefore[step] = ctx.Value(hookKey{})
+				mu.Unlock()
+				panic("after boom")
+			},
+		)).
+		Add(pl.Steps(ok, panicking))
+	_ = w.Run(context.Background())
+
+	if got := ok.GetStatus(); got != pl.StepStatusSucceeded {
+		t.Errorf("want Step Succeeded despite after hook panic, got %s", got)
+	}
+	if got := panicking.GetStatus(); got != pl.StepStatusFailed || w.Err()[panicking] == nil {
+		t.Errorf("want Step Failed by before hook panic, got %s: %v", got, w.Err()[panicking])
+	}
+	if fromBefore[ok] != "span" {
+		t.Errorf("want after hook receive the context from before hook, got %v", fromBefore[ok])
+	}
+}
+
+func TestWorkflowSnapshotFromStep(t *testing.T) {
+	w := new(pl.Workflow).WithOptions(pl.WorkflowMaxConcurrency(4))
+	var steps []pl.StepDoer
+	for i := 0; i < 50; i++ {
+		steps = append(steps, pl.FuncNoInOut(fmt.Sprintf("step-%d", i), func(context.Context) error {
+			failed := 0
+			for _, status := range w.Status() {
+				if status == pl.StepStatusFailed {
+					failed++
+				}
+			}
+			_ = w.Err()
+			if failed > 3 {
+				return nil // abort expensive work
+			}
+			return fmt.Errorf("failed")
+		}))
+	}
+	w.Add(pl.Steps(steps...))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = w.Run(context.Background())
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock: Status or Err blocked inside a running Step")
+	}
+	if len(w.Status()) != len(steps) {
+		t.Errorf("want status of %d Steps, got %d", len(steps), len(w.Status()))
+	}
+}
+
+func TestWorkflowCancel(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	running := pl.FuncNoInOut("running", func(ctx context.Context) error {
+		close(started)
+		<-release
+		return ctx.Err() // the running Step is left to drain, not interrupted
+	})
+	pending := succeed("pending")
+	w := new(pl.Workflow).Add(pl.Step(pending).ExtraDependsOn(running))
+
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	<-started
+	w.Cancel()
+	w.Cancel()
+	close(release)
+	<-done
+
+	werr := w.Err()
+	if got := running.GetStatus(); got != pl.StepStatusSucceeded || werr[running] != nil {
+		t.Errorf("want running Step drained, got %s: %v", got, werr[running])
+	}
+	if got := pending.GetStatus(); got != pl.StepStatusCanceled || !errors.Is(werr[pending], pl.ErrWorkflowCanceled) {
+		t.Errorf("want pending Step Canceled, got %s: %v", got, werr[pending])
+	}
+}
+
+func TestWorkflowCancelQueuedForLease(t *testing.T) {
+	started := make(chan struct{})
+	long := pl.FuncNoInOut("long", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	queued := succeed("queued")
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowMaxConcurrency(1)).
+		Add(pl.Steps(long, queued))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	<-started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("want Run returned promptly after ctx canceled")
+	}
+	if got := queued.GetStatus(); got != pl.StepStatusCanceled || !errors.Is(w.Err()[queued], context.Canceled) {
+		t.Errorf("want queued Step Canceled with context.Canceled, got %s: %v", got, w.Err()[queued])
+	}
+}
+
+func TestWorkflowCancelRetryWaitingForLease(t *testing.T) {
+	hogStarted, release := make(chan struct{}), make(chan struct{})
+	flaky := pl.FuncNoInOut("flaky", func(context.Context) error {
+		return fmt.Errorf("flaky")
+	})
+	hog := pl.FuncNoInOut("hog", func(context.Context) error {
+		close(hogStarted)
+		<-release
+		return nil
+	})
+	terminated := make(chan pl.StepReader, 2)
+	w := new(pl.Workflow).
+		WithOptions(
+			pl.WorkflowMaxConcurrency(1),
+			pl.WorkflowStepHooks(nil, func(_ context.Context, step pl.StepReader, _ error) {
+				terminated <- step
+			}),
+		).
+		Add(
+			pl.Step(flaky).Retry(pl.RetryOption{
+				Backoff: backoff.NewConstantBackOff(time.Hour),
+				// retry once hog holds the only lease
+				WaitFor: func(context.Context) <-chan struct{} { return hogStarted },
+			}),
+			pl.Step(hog),
+		)
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	<-hogStarted
+	w.Cancel()
+	select {
+	case step := <-terminated:
+		if step != flaky {
+			t.Fatalf("want flaky terminated first, got %s", step)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("want flaky stop waiting for the lease after Cancel")
+	}
+	close(release)
+	<-done
+	if got := flaky.GetStatus(); got != pl.StepStatusCanceled || !errors.Is(w.Err()[flaky], pl.ErrWorkflowCanceled) {
+		t.Errorf("want flaky Canceled with ErrWorkflowCanceled, got %s: %v", got, w.Err()[flaky])
+	}
+	if got := hog.GetStatus(); got != pl.StepStatusSucceeded {
+		t.Errorf("want hog drained, got %s", got)
+	}
+}
+
+func TestWorkflowCancelBeforeRun(t *testing.T) {
+	step := succeed("step")
+	w := new(pl.Workflow).Add(pl.Step(step))
+	w.Cancel()
+	_ = w.Run(context.Background())
+	if got := step.GetStatus(); got != pl.StepStatusCanceled {
+		t.Errorf("want Step Canceled, got %s", got)
+	}
+}
+
+func TestWorkflowDeterministicOrder(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		var mu sync.Mutex
+		var order []string
+		record := func(name string) pl.StepDoer {
+			return pl.FuncNoInOut(name, func(context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, name)
+				return nil
+			})
+		}
+		var steps []pl.StepDoer
+		for _, name := range []string{"e", "d", "c", "b", "a"} {
+			steps = append(steps, record(name))
+		}
+		// concurrency 1 makes Steps run in the order of being considered
+		w := new(pl.Workflow).
+			WithOptions(pl.WorkflowMaxConcurrency(1)).
+			Add(pl.Steps(steps...))
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(order, ""); got != "edcba" {
+			t.Fatalf("want Steps run in the order of being added, got %s", got)
+		}
+	}
+}
+
+func TestWorkflowDownstreamOf(t *testing.T) {
+	root, b, a := succeed("root"), succeed("b"), succeed("a")
+	w := new(pl.Workflow).Add(pl.Steps(b, a).DependsOn(root))
+	if got := w.DownstreamOf(root); len(got) != 2 || got[0] != b || got[1] != a {
+		t.Errorf("want Dependers in the order of being added, got %v", got)
+	}
+	c := succeed("c")
+	w.Add(pl.Step(c).ExtraDependsOn(root))
+	if got := w.DownstreamOf(root); len(got) != 3 || got[2] != c {
+		t.Errorf("want the index invalidated by Add, got %v", got)
+	}
+}
+
+func TestWorkflowPreflightKeepsStatus(t *testing.T) {
+	// a long chain ended with a cycle, so every run stops in preflight
+	steps := make([]pl.Steper[struct{}, struct{}], 1000)
+	for i := range steps {
+		steps[i] = succeed(fmt.Sprint(i))
+	}
+	w := new(pl.Workflow).Add(pl.Step(steps[0]).ExtraDependsOn(steps[len(steps)-1]))
+	for i := 1; i < len(steps); i++ {
+		w.Add(pl.Step(steps[i]).ExtraDependsOn(steps[i-1]))
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			for _, step := range steps {
+				if got := step.GetStatus(); got != pl.StepStatusPending {
+					t.Errorf("want %s Pending during preflight, got %s", step, got)
+					return
+				}
+			}
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+	}()
+	var cycle pl.ErrCycleDependency
+	for i := 0; i < 20; i++ {
+		if err := w.Run(context.Background()); !errors.As(err, &cycle) {
+			t.Fatalf("want ErrCycleDependency, got %v", err)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestWorkflowRerunFailed(t *testing.T) {
+	producerRuns, flaky := 0, true
+	producer := pl.FuncOut("producer", func(context.Context) (func(*int), error) {
+		producerRuns++
+		return func(o *int) { *o = 42 }, nil
+	})
+	var got int
+	consumer := pl.FuncIn("consumer", func(_ context.Context, i int) error {
+		if flaky {
+			flaky = false
+			return fmt.Errorf("flaky")
+		}
+		got = i
+		return nil
+	})
+	downstream := succeed("downstream")
+	w := new(pl.Workflow).Add(
+		pl.Step(consumer).DirectDependsOn(producer),
+		pl.Step(downstream).ExtraDependsOn(consumer),
+	)
+	if err := w.Run(context.Background()); err == nil {
+		t.Fatal("want first run failed")
+	}
+	if got := downstream.GetStatus(); got != pl.StepStatusCanceled && got != pl.StepStatusSkipped {
+		t.Fatalf("want downstream not run, got %s", got)
+	}
+	if err := w.RerunFailed(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if producerRuns != 1 {
+		t.Errorf("want Succeeded producer not re-run, got %d runs", producerRuns)
+	}
+	if got != 42 {
+		t.Errorf("want consumer receive the kept Output 42, got %d", got)
+	}
+	for _, step := range []pl.StepReader{producer, consumer, downstream} {
+		if got := step.GetStatus(); got != pl.StepStatusSucceeded {
+			t.Errorf("want %s Succeeded, got %s", step, got)
+		}
+	}
+}
+
+func TestWorkflowWrapErrors(t *testing.T) {
+	errOriginal := errors.New("original")
+	step := pl.FuncNoInOut("wrapped", func(context.Context) error { return errOriginal })
+	w := new(pl.Workflow).WithOptions(pl.WorkflowWrapErrors()).Add(pl.Step(step))
+	_ = w.Run(context.Background())
+	err := w.Err()[step]
+	if err == nil || !strings.HasPrefix(err.Error(), "wrapped: ") {
+		t.Errorf("want error prefixed with Step name, got %v", err)
+	}
+	if !errors.Is(err, errOriginal) {
+		t.Errorf("want error unwraps to the original, got %v", err)
+	}
+}
+
+func TestWorkflowSkipIfOutputPresent(t *testing.T) {
+	for _, perStep := range []bool{false, true} {
+		runs := 0
+		zero := pl.FuncOut("zero", func(context.Context) (func(*int), error) {
+			runs++
+			return func(o *int) { *o = 0 }, nil // a zero value Output is still reused
+		})
+		flaky := true
+		consumer := pl.FuncIn("consumer", func(context.Context, int) error {
+			if flaky {
+				flaky = false
+				return fmt.Errorf("flaky")
+			}
+			return nil
+		})
+		w := new(pl.Workflow)
+		step := pl.Step(zero)
+		if perStep {
+			step.SkipIfOutputPresent()
+		} else {
+			w.WithOptions(pl.WorkflowSkipIfOutputPresent())
+		}
+		w.Add(step, pl.Step(consumer).DirectDependsOn(zero))
+		if err := w.Run(context.Background()); err == nil {
+			t.Fatal("want first run failed")
+		}
+		if err := w.Reset(); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if runs != 1 {
+			t.Errorf("perStep=%v: want zero run once, got %d", perStep, runs)
+		}
+		if got := zero.GetStatus(); got != pl.StepStatusSucceeded {
+			t.Errorf("perStep=%v: want zero Succeeded, got %s", perStep, got)
+		}
+	}
+}
+
+func TestStepHasRun(t *testing.T) {
+	loaded, ran := succeed("loaded"), succeed("ran")
+	w := new(pl.Workflow).Add(pl.Step(ran).ExtraDependsOn(loaded))
+	if err := w.LoadStatuses(map[string]pl.StepStatus{"loaded": pl.StepStatusSucceeded}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.HasRun() {
+		t.Error("want the loaded Step not HasRun")
+	}
+	if !ran.HasRun() {
+		t.Error("want the run Step HasRun")
+	}
+	if err := w.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if ran.HasRun() {
+		t.Error("want HasRun cleared by Reset")
+	}
+}
+
+func TestWorkflowOptionsSummary(t *testing.T) {
+	w := new(pl.Workflow)
+	want := "max concurrency=unlimited, timeout=none, fail-fast=false, when=unset, default timeout=none, default retry=unset, default condition=unset, default when=unset"
+	if got := w.OptionsSummary(); !strings.HasPrefix(got, want+", ") {
+		t.Errorf("want summary starting with\n%s\ngot\n%s", want, got)
+	}
+	w.WithOptions(
+		pl.WorkflowMaxConcurrency(4),
+		pl.WorkflowTimeout(time.Minute),
+		pl.WorkflowFailFast(),
+		pl.WorkflowWhen(pl.Skip),
+		pl.WorkflowDefaultRetry(pl.RetryOption{Attempts: 2}),
+		pl.WorkflowConcurrencyGroup("db", 2),
+		pl.WorkflowWrapErrors(),
+		pl.WorkflowClock(pltest.NewClock(time.Unix(0, 0))),
+		pl.WorkflowSeed(42),
+		pl.WorkflowAdmissionControl(func(context.Context, pl.StepReader) bool { return true }),
+		pl.WorkflowLogger(slog.Default()),
+		pl.WorkflowDeepCopyFlow(),
+		pl.WorkflowSkipPropagation(),
+		pl.WorkflowPolicyBounds(pl.PolicyBounds{MaxAttempts: 3}),
+		pl.WorkflowHookQueue(8, pl.HookOverflowDropOldest),
+		pl.WorkflowRootStagger(time.Second),
+	)
+	got := w.OptionsSummary()
+	want = "max concurrency=4, timeout=1m0s, fail-fast=true, when=set, default timeout=none, default retry=set, default condition=unset, default when=unset"
+	if !strings.HasPrefix(got, want+", ") {
+		t.Errorf("want summary starting with\n%s\ngot\n%s", want, got)
+	}
+	for _, pair := range []string{
+		"wrap errors=true",
+		"clock=set",
+		"seed=42",
+		"admission control=set",
+		"logger=set",
+		"deep copy flow=true",
+		"skip propagation=true",
+		"policy bounds={MinTimeout:0s MaxTimeout:0s MaxAttempts:3 MaxBackoffInterval:0s MaxRetryElapsed:0s}",
+		"hook queue=8/drop-oldest",
+		"root stagger=1s",
+		"scheduler=unset",
+		"state store=unset",
+	} {
+		if !strings.Contains(", "+got+", ", ", "+pair+", ") {
+			t.Errorf("want %q in summary, got\n%s", pair, got)
+		}
+	}
+	if !strings.HasSuffix(got, ", concurrency group db=2") {
+		t.Errorf("want the concurrency group at the end, got\n%s", got)
+	}
+}
+
+func TestWorkflowSafeAdd(t *testing.T) {
+	a, b, c, d := succeed("a"), succeed("b"), succeed("c"), succeed("d")
+	w := new(pl.Workflow)
+	for _, step := range []pl.WorkflowStep{
+		pl.Step(b).ExtraDependsOn(a),
+		pl.Step(c).ExtraDependsOn(b),
+		pl.Step(d).ExtraDependsOn(a, c),
+	} {
+		if err := w.SafeAdd(step); err != nil {
+			t.Fatalf("want no cycle, got %v", err)
+		}
+	}
+	var edge pl.ErrCycleEdge
+	if err := w.SafeAdd(pl.Step(a).ExtraDependsOn(d)); !errors.As(err, &edge) || edge.Dependee != d || edge.Depender != a {
+		t.Errorf("want ErrCycleEdge from d to a, got %v", err)
+	}
+	if err := w.SafeAdd(pl.Step(c).ExtraDependsOn(c)); !errors.As(err, &edge) || edge.Dependee != c || edge.Depender != c {
+		t.Errorf("want ErrCycleEdge from c to c, got %v", err)
+	}
+	// the cycle formed by the edges of the same call
+	e, f := succeed("e"), succeed("f")
+	if err := w.SafeAdd(pl.Step(e).ExtraDependsOn(f), pl.Step(f).ExtraDependsOn(e)); !errors.As(err, &edge) {
+		t.Errorf("want ErrCycleEdge, got %v", err)
+	}
+	if got := w.Dep().UpstreamOf(a); len(got) != 0 {
+		t.Errorf("want the rejected edges not added, got a depends on %v", got)
+	}
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,85 @@
+package pl
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Template describes a reusable shape of Workflow parameterized by P.
+//
+// Steps keep their status and error after run, so a Workflow can't be shared between runs.
+// Template builds a brand new Workflow with new Steps on each instantiation.
+//
+// Usage:
+//
+//	deploy := pl.Template[string]{
+//		Name: "deploy",
+//		Build: func(region string) *pl.Workflow {
+//			build, push := NewBuild(region), NewPush(region)
+//			return new(pl.Workflow).Add(pl.Step(push).ExtraDependsOn(build))
+//		},
+//	}
+//	eastus, westus := deploy.New("eastus"), deploy.New("westus")
+type Template[P any] struct {
+	Name  string
+	Build func(P) *Workflow
+}
+
+// New instantiates the Template with parameter p.
+func (t *Template[P]) New(p P) *Workflow {
+	return t.Build(p)
+}
+
+// TemplateRegistry holds Templates by their Names,
+// the zero value is an empty registry ready to use.
+//
+// Go methods can't have type parameters, use RegisterTemplate and NewFromTemplate to access it.
+type TemplateRegistry struct {
+	templates sync.Map // map[string]any, any is *Template[P]
+}
+
+// Unregister removes the Template of name from the registry.
+func (r *TemplateRegistry) Unregister(name string) {
+	r.templates.Delete(name)
+}
+
+// RegisterTemplate registers the Template by its Name in the registry,
+// then it can be instantiated by NewFromTemplate.
+//
+// It returns ErrTemplate if the Name is empty or already registered.
+func RegisterTemplate[P any](r *TemplateRegistry, t *Template[P]) error {
+	if t.Name == "" {
+		return ErrTemplate{Err: fmt.Errorf("empty Name")}
+	}
+	if _, loaded := r.templates.LoadOrStore(t.Name, t); loaded {
+		return ErrTemplate{Name: t.Name, Err: fmt.Errorf("already registered")}
+	}
+	return nil
+}
+
+// NewFromTemplate instantiates the Template of name in the registry with parameter p.
+func NewFromTemplate[P any](r *TemplateRegistry, name string, p P) (*Workflow, error) {
+	v, ok := r.templates.Load(name)
+	if !ok {
+		return nil, ErrTemplate{Name: name, Err: fmt.Errorf("not registered")}
+	}
+	t, ok := v.(*Template[P])
+	if !ok {
+		return nil, ErrTemplate{Name: name, Err: fmt.Errorf("parameter type %s mismatches %T", typeOf[P](), v)}
+	}
+	return t.New(p), nil
+}
+
+// ErrTemplate is returned by NewFromTemplate if the Template can't be instantiated.
+type ErrTemplate struct {
+	Name string
+	Err  error
+}
+
+func (e ErrTemplate) Error() string {
+	return fmt.Sprintf("Template %q: %s", e.Name, e.Err)
+}
+
+func (e ErrTemplate) Unwrap() error {
+	return e.Err
+}
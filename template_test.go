@@ -0,0 +1,67 @@
+package pl_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/xuxife/pl"
+)
+
+func TestTemplate(t *testing.T) {
+	var mu sync.Mutex
+	ran := map[string]int{}
+	var registry pl.TemplateRegistry
+	deploy := &pl.Template[string]{
+		Name: "deploy",
+		Build: func(region string) *pl.Workflow {
+			record := func(name string) pl.Steper[struct{}, struct{}] {
+				return pl.FuncNoInOut(region+"/"+name, func(context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					ran[region+"/"+name]++
+					return nil
+				})
+			}
+			build, push := record("build"), record("push")
+			return new(pl.Workflow).Add(pl.Step(push).ExtraDependsOn(build))
+		},
+	}
+	if err := pl.RegisterTemplate(&registry, deploy); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, region := range []string{"eastus", "westus"} {
+		w, err := pl.NewFromTemplate(&registry, "deploy", region)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(ran) != 4 {
+		t.Errorf("want each instance run its own Steps, got %v", ran)
+	}
+	for name, n := range ran {
+		if n != 1 {
+			t.Errorf("want %s run once, got %d", name, n)
+		}
+	}
+
+	var terr pl.ErrTemplate
+	if _, err := pl.NewFromTemplate(&registry, "deploy", 42); !errors.As(err, &terr) {
+		t.Errorf("want ErrTemplate for mismatched parameter type, got %v", err)
+	}
+	if _, err := pl.NewFromTemplate(&registry, "missing", ""); !errors.As(err, &terr) {
+		t.Errorf("want ErrTemplate for unregistered Template, got %v", err)
+	}
+	if err := pl.RegisterTemplate(&registry, deploy); !errors.As(err, &terr) {
+		t.Errorf("want ErrTemplate for registering twice, got %v", err)
+	}
+	registry.Unregister("deploy")
+	if _, err := pl.NewFromTemplate(&registry, "deploy", "eastus"); !errors.As(err, &terr) {
+		t.Errorf("want ErrTemplate after Unregister, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package pl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// chromeTraceEvent is an event of the Chrome Trace Event Format,
+// see https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type chromeTraceEvent struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat,omitempty"`
+	Ph   string         `json:"ph"`
+	Ts   float64        `json:"ts"` // in microseconds
+	Dur  float64        `json:"dur,omitempty"`
+	Pid  int            `json:"pid"`
+	Tid  int            `json:"tid"`
+	Args map[string]any `json:"args,omitempty"`
+	ID   int            `json:"id,omitempty"` // binds the flow events
+	BP   string         `json:"bp,omitempty"` // binding point of the flow end event
+}
+
+type chromeTrace struct {
+	TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+	DisplayTimeUnit string             `json:"displayTimeUnit"`
+}
+
+// ChromeTrace returns the timings of the last run in Chrome Trace Event JSON,
+// which can be loaded into chrome://tracing or Perfetto.
+//
+// Each started Step is a complete event ("ph": "X") in category "step",
+// with its status and error in args, timestamps are relative to the first started Step.
+// Overlapping Steps are put in different rows (tid), so the viewer doesn't nest them.
+// A Step still running lasts until now.
+func (s *Workflow) ChromeTrace() ([]byte, error) {
+	steps, _ := s.Report().chromeTraceSteps()
+	events := make([]chromeTraceEvent, 0, len(steps))
+	for _, step := range steps {
+		events = append(events, step.event())
+	}
+	return json.Marshal(chromeTrace{
+		TraceEvents:     events,
+		DisplayTimeUnit: "ms",
+	})
+}
+
+// tracedStep is a started Step placed in the trace.
+type tracedStep struct {
+	StepReport
+	ts, dur float64 // in microseconds, relative to the first started Step
+	tid     int
+}
+
+// chromeTraceSteps returns the started Steps sorted by start time,
+// overlapping Steps are put in different rows (tid).
+func (r WorkflowReport) chromeTraceSteps() (steps []tracedStep, rows int) {
+	var started []StepReport
+	for _, step := range r.Steps {
+		if step.StartedAt != nil {
+			started = append(started, step)
+		}
+	}
+	if len(started) == 0 {
+		return nil, 0
+	}
+	sort.SliceStable(started, func(i, j int) bool {
+		return started[i].StartedAt.Before(*started[j].StartedAt)
+	})
+	origin := *started[0].StartedAt
+	now := origin.Add(r.Duration) // the Steps still running last until the report is made
+	var rowEnds []time.Time       // the end time of the last event in each row
+	for _, step := range started {
+		end := now
+		if step.FinishedAt != nil {
+			end = *step.FinishedAt
+		}
+		// put the event in the first row available
+		row := 0
+		for ; row < len(rowEnds); row++ {
+			if !rowEnds[row].After(*step.StartedAt) {
+				break
+			}
+		}
+		if row == len(rowEnds) {
+			rowEnds = append(rowEnds, end)
+		} else {
+			rowEnds[row] = end
+		}
+		steps = append(steps, tracedStep{
+			StepReport: step,
+			ts:         microseconds(step.StartedAt.Sub(origin)),
+			dur:        microseconds(end.Sub(*step.StartedAt)),
+			tid:        row + 1,
+		})
+	}
+	return steps, len(rowEnds)
+}
+
+func (t tracedStep) event() chromeTraceEvent {
+	args := map[string]any{"status": t.Status}
+	if t.Err != "" {
+		args["error"] = t.Err
+	}
+	return chromeTraceEvent{
+		Name: t.Name,
+		Cat:  "step",
+		Ph:   "X",
+		Ts:   t.ts,
+		Dur:  t.dur,
+		Pid:  1,
+		Tid:  t.tid,
+		Args: args,
+	}
+}
+
+// WriteChromeTrace writes a detailed trace of the report in Chrome Trace Event JSON to w,
+// which can be loaded into chrome://tracing or Perfetto.
+//
+// Besides the Step events of Workflow.ChromeTrace, the trace contains
+//   - the Phases of each Step as events nested in the Step (category "phase"), one per attempt
+//     at the time it ran, Phases taking no time are left out;
+//   - flow events (category "dependency") from the termination of each Dependee to the start of its Depender;
+//   - metadata naming the process and each row.
+func (r WorkflowReport) WriteChromeTrace(w io.Writer) error {
+	steps, rows := r.chromeTraceSteps()
+	events := []chromeTraceEvent{{
+		Name: "process_name", Ph: "M", Pid: 1,
+		Args: map[string]any{"name": "Workflow"},
+	}}
+	for row := 1; row <= rows; row++ {
+		events = append(events, chromeTraceEvent{
+			Name: "thread_name", Ph: "M", Pid: 1, Tid: row,
+			Args: map[string]any{"name": fmt.Sprintf("row %d", row)},
+		})
+	}
+	var origin time.Time // the start of the first started Step
+	if len(steps) > 0 {
+		origin = *steps[0].StartedAt
+	}
+	traced := make(map[string]tracedStep, len(steps))
+	for _, step := range steps {
+		traced[step.Name] = step
+		events = append(events, step.event())
+		for _, p := range step.Phases {
+			if !p.FinishedAt.After(p.StartedAt) {
+				continue
+			}
+			events = append(events, chromeTraceEvent{
+				Name: string(p.Phase),
+				Cat:  "phase",
+				Ph:   "X",
+				Ts:   microseconds(p.StartedAt.Sub(origin)),
+				Dur:  microseconds(p.FinishedAt.Sub(p.StartedAt)),
+				Pid:  1,
+				Tid:  step.tid,
+			})
+		}
+	}
+	id := 0
+	for _, depender := range steps {
+		for _, edge := range r.Edges {
+			if edge.Depender != depender.Name {
+				continue
+			}
+			e, ok := traced[edge.Dependee]
+			if !ok || e.FinishedAt == nil {
+				continue
+			}
+			id++
+			events = append(events,
+				chromeTraceEvent{
+					Name: "dependency", Cat: "dependency", Ph: "s", ID: id,
+					Ts: e.ts + e.dur, Pid: 1, Tid: e.tid,
+				},
+				chromeTraceEvent{
+					Name: "dependency", Cat: "dependency", Ph: "f", BP: "e", ID: id,
+					Ts: depender.ts, Pid: 1, Tid: depender.tid,
+				},
+			)
+		}
+	}
+	return json.NewEncoder(w).Encode(chromeTrace{
+		TraceEvents:     events,
+		DisplayTimeUnit: "ms",
+	})
+}
+
+func microseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
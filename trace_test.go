@@ -0,0 +1,113 @@
+package pl_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/xuxife/pl"
+	"github.com/xuxife/pl/pltest"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestChromeTrace(t *testing.T) {
+	a, b, c := succeed("a"), fail("b"), succeed("c")
+	skipped := succeed("skipped")
+	w := new(pl.Workflow).Add(
+		pl.Step(b).ExtraDependsOn(a),
+		pl.Step(c).ExtraDependsOn(a),
+		pl.Step(skipped).When(pl.Skip),
+	)
+	_ = w.Run(context.Background())
+
+	data, err := w.ChromeTrace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var trace struct {
+		TraceEvents []struct {
+			Name string            `json:"name"`
+			Cat  string            `json:"cat"`
+			Ph   string            `json:"ph"`
+			Ts   *float64          `json:"ts"`
+			Dur  float64           `json:"dur"`
+			Pid  int               `json:"pid"`
+			Tid  int               `json:"tid"`
+			Args map[string]string `json:"args"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(data, &trace); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if len(trace.TraceEvents) != 3 {
+		t.Fatalf("want one event per started Step, got %s", data)
+	}
+	for i, e := range trace.TraceEvents {
+		if e.Ph != "X" || e.Cat != "step" || e.Ts == nil || e.Pid == 0 || e.Tid == 0 {
+			t.Errorf("want complete event, got %+v", e)
+		}
+		if i == 0 && (e.Name != "a" || *e.Ts != 0) {
+			t.Errorf("want the first started Step at ts 0, got %+v", e)
+		}
+		if e.Name == "b" && (e.Args["status"] != "Failed" || e.Args["error"] == "") {
+			t.Errorf("want status and error in args, got %+v", e.Args)
+		}
+	}
+}
+
+func TestWriteChromeTrace(t *testing.T) {
+	clock := pltest.NewClock(time.Unix(0, 0))
+	work := func(name string, failures int) pl.Steper[struct{}, struct{}] {
+		return pl.FuncNoInOut(name, func(context.Context) error {
+			clock.Advance(10 * time.Millisecond)
+			if failures > 0 {
+				failures--
+				return fmt.Errorf("flaky")
+			}
+			return nil
+		})
+	}
+	a, b, c := work("a", 0), work("b", 1), work("c", 0)
+	w := new(pl.Workflow).
+		WithOptions(pl.WorkflowClock(clock)).
+		Add(
+			pl.Step(b).ExtraDependsOn(a).Retry(pl.RetryOption{
+				Backoff:  backoff.NewConstantBackOff(5 * time.Millisecond),
+				Attempts: 2,
+			}),
+			pl.Step(c).ExtraDependsOn(a, b),
+		)
+	done := make(chan error)
+	go func() { done <- w.Run(context.Background()) }()
+	clock.BlockUntilTimers(1) // b backs off before retrying
+	clock.Advance(5 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	var got bytes.Buffer
+	if err := w.Report().WriteChromeTrace(&got); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "chrome_trace.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("want\n%s\ngot\n%s", want, got.Bytes())
+	}
+}
@@ -60,6 +60,32 @@ type dependee[O any] interface {
 	outputer[O]
 }
 
+// stepOrderer is implemented by WorkflowStep to keep the declared order of Steps.
+type stepOrderer interface {
+	order() []StepDoer
+}
+
+// stepsInOrder returns the Dependers in order, followed by their Dependees in the order of links.
+func stepsInOrder(dependers []StepDoer, d dependency) []StepDoer {
+	var steps []StepDoer
+	seen := map[StepDoer]bool{}
+	add := func(step StepDoer) {
+		if step != nil && !seen[step] {
+			seen[step] = true
+			steps = append(steps, step)
+		}
+	}
+	for _, r := range dependers {
+		add(r)
+	}
+	for _, r := range dependers {
+		for _, l := range d[r] {
+			add(l.Dependee)
+		}
+	}
+	return steps
+}
+
 // dependency is a relationship between Depender(s) and Dependee(s).
 // We say "A depends on B", or "B happened-before A", then A is Depender, B is Dependee.
 type dependency map[StepDoer][]link
@@ -69,6 +95,9 @@ type dependency map[StepDoer][]link
 type link struct {
 	Dependee StepDoer
 	Flow     func(context.Context) error // Flow sends Dependee's Output to Depender's Input
+	Fallback func(context.Context) error // Fallback runs instead of Flow if Dependee's Output doesn't flow, see AdaptOr
+	Direct   bool                        // whether Flow sends the Output as is, see DirectDependsOn
+	Cond     Condition                   // Cond overrides the Condition of Depender for this Dependee, see ConditionFor
 }
 
 // UpstreamOf returns all Dependee(s) of a Depender.
@@ -83,7 +112,7 @@ func (d dependency) UpstreamOf(depender StepDoer) []StepDoer {
 }
 
 // DownstreamOf returns all Depender(s) of a Dependee.
-// WARNING: this is expensive
+// WARNING: this is expensive, O(V*E), use Workflow.DownstreamOf for repeated queries
 func (d dependency) DownstreamOf(dependee StepDoer) []StepDoer {
 	var dependers []StepDoer
 	for r, links := range d {